@@ -0,0 +1,287 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/bulk"
+	"github.com/soundrise/go-payment-system/payment/swift"
+)
+
+// backend performs the operations either on a local state file or on a remote server.
+type backend interface {
+	CreateCustomer(c payment.Customer) (payment.Customer, error)
+	CreateAccount(c payment.Customer, accType string, currencyCode string, amount float32) (payment.Account, error)
+	Account(customerId string, accountNum string) (payment.Account, error)
+	Accounts(customerId string) ([]payment.Account, error)
+	QueryAccounts(q payment.AccountQuery) (payment.AccountPage, error)
+	Block(a payment.Account) (payment.Account, error)
+	Activate(a payment.Account) (payment.Account, error)
+	Emit(currencyCode string, amount float32) (payment.Account, error)
+	Terminate(a payment.Account, amount float32) (payment.Account, error)
+	Disburse(a payment.Account, amount float32, reference string) (payment.Account, error)
+	Transfer(s payment.Account, d payment.Account, amount float32) (payment.Account, payment.Account, error)
+	Statement(a payment.Account, from time.Time, to time.Time) (payment.Statement, error)
+	MoneySupply() ([]payment.MoneySupply, error)
+	Import(f *bulk.File) (bulk.Report, error)
+	CloseDay(interestRate float64, fee float32, feeCurrency string) (payment.DayClose, error)
+	Schedule(source string, dest string, amount float32, valueDate time.Time) (payment.ScheduledTransfer, error)
+	Scheduled() ([]payment.ScheduledTransfer, error)
+	CancelScheduled(id string) error
+	BackValue(source string, dest string, amount float32, valueDate time.Time) (payment.Correction, error)
+	PostSuspense(accountNum string, amount float32, reference string) (payment.Transaction, error)
+	ReceiveMT103(mt swift.MT103, settlement string) (payment.Account, error)
+	Check() (payment.CheckReport, error)
+	Dump() ([]byte, error)
+	Restore(data []byte) error
+	Save() error
+	Close() error
+}
+
+/*
+This struct runs the payment system in process.
+The state is loaded from the file on start and written back by Save
+after a successful command. The limits and the emission ceilings
+are part of the state, the audit log is not: it is kept by the writer
+given to payment.WithAuditLog.
+*/
+type localBackend struct {
+	path   string
+	ps     *payment.PaymentSystem
+	logger *slog.Logger
+}
+
+func openLocal(path string, logger *slog.Logger) (*localBackend, error) {
+	ps := payment.NewPaymentSystem(
+		payment.WithLogger(logger),
+		payment.WithProcessingDelay(0),
+	)
+
+	data, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return nil, err
+	default:
+		if err := ps.LoadSnapshot(data); err != nil {
+			return nil, fmt.Errorf("cannot load state file %s: %w", path, err)
+		}
+	}
+
+	return &localBackend{path: path, ps: ps, logger: logger}, nil
+}
+
+func (b *localBackend) CreateCustomer(c payment.Customer) (payment.Customer, error) {
+	if err := b.ps.CreateCustomer(c); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (b *localBackend) CreateAccount(c payment.Customer, accType string, currencyCode string, amount float32) (payment.Account, error) {
+	if known, err := b.ps.GetCustomer(c.Id); err == nil && c.Name == "" {
+		c.Name = known.Name
+	}
+
+	if err := b.ps.CreateAccount(c, accType, currencyCode, amount); err != nil {
+		return payment.Account{}, err
+	}
+
+	if accType != payment.AccountPrefix {
+		return b.ps.GetSpecialAccountIn(accType, currencyCode)
+	}
+
+	return b.ps.FindAccount(c, currencyCode)
+}
+
+func (b *localBackend) Account(customerId string, accountNum string) (payment.Account, error) {
+	a, err := b.ps.GetAccountByNumber(payment.NewCustomer(customerId, "", ""), accountNum)
+	if err != nil || a.Num == "" {
+		return a, fmt.Errorf("%w: %s", payment.ErrAccountNotFound, accountNum)
+	}
+
+	return a, nil
+}
+
+func (b *localBackend) Accounts(customerId string) ([]payment.Account, error) {
+	return b.ps.Accounts(customerId), nil
+}
+
+func (b *localBackend) QueryAccounts(q payment.AccountQuery) (payment.AccountPage, error) {
+	return b.ps.QueryAccounts(q)
+}
+
+func (b *localBackend) Block(a payment.Account) (payment.Account, error) {
+	if err := b.ps.CloseAccount(a); err != nil {
+		return a, err
+	}
+
+	return b.Account(a.CustomerId, a.Num)
+}
+
+func (b *localBackend) Activate(a payment.Account) (payment.Account, error) {
+	if err := b.ps.ActivateAccount(a); err != nil {
+		return a, err
+	}
+
+	return b.Account(a.CustomerId, a.Num)
+}
+
+func (b *localBackend) Emit(currencyCode string, amount float32) (payment.Account, error) {
+	if err := b.ps.EmitIn(currencyCode, amount); err != nil {
+		return payment.Account{}, err
+	}
+
+	return b.ps.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, currencyCode)
+}
+
+func (b *localBackend) Terminate(a payment.Account, amount float32) (payment.Account, error) {
+	if err := b.ps.Terminate(a, amount); err != nil {
+		return a, err
+	}
+
+	return b.Account(a.CustomerId, a.Num)
+}
+
+func (b *localBackend) Disburse(a payment.Account, amount float32, reference string) (payment.Account, error) {
+	if err := b.ps.Disburse(a, amount, reference); err != nil {
+		return a, err
+	}
+
+	return b.Account(a.CustomerId, a.Num)
+}
+
+func (b *localBackend) Transfer(s payment.Account, d payment.Account, amount float32) (payment.Account, payment.Account, error) {
+	if err := b.ps.Transfer(s, d, amount); err != nil {
+		return s, d, err
+	}
+
+	s, err := b.Account(s.CustomerId, s.Num)
+	if err != nil {
+		return s, d, err
+	}
+
+	d, err = b.Account(d.CustomerId, d.Num)
+
+	return s, d, err
+}
+
+func (b *localBackend) Statement(a payment.Account, from time.Time, to time.Time) (payment.Statement, error) {
+	return b.ps.Statement(payment.NewCustomer(a.CustomerId, "", ""), a.Num, from, to)
+}
+
+func (b *localBackend) MoneySupply() ([]payment.MoneySupply, error) {
+	return b.ps.MoneySupply(), nil
+}
+
+func (b *localBackend) Import(f *bulk.File) (bulk.Report, error) {
+	return bulk.NewImporter(b.ps, payment.WithControllerLogger(b.logger)).Run(f), nil
+}
+
+/*
+This func closes the business day of the state with the interest job
+when the rate is not zero and the monthly fee job when the fee is not zero.
+*/
+func (b *localBackend) CloseDay(interestRate float64, fee float32, feeCurrency string) (payment.DayClose, error) {
+	if interestRate != 0 {
+		b.ps.AddEODJob("interest", payment.InterestJob(interestRate))
+	}
+
+	if fee != 0 {
+		b.ps.AddEODJob("fee", payment.FeeJob(feeCurrency, fee))
+	}
+
+	return b.ps.CloseDay()
+}
+
+func (b *localBackend) Schedule(source string, dest string, amount float32, valueDate time.Time) (payment.ScheduledTransfer, error) {
+	id, err := b.ps.ScheduleTransfer("", source, dest, amount, valueDate)
+	if err != nil {
+		return payment.ScheduledTransfer{}, err
+	}
+
+	for _, st := range b.ps.ScheduledTransfers() {
+		if st.Id == id {
+			return st, nil
+		}
+	}
+
+	return payment.ScheduledTransfer{}, fmt.Errorf("%w: %s", payment.ErrScheduledNotFound, id)
+}
+
+func (b *localBackend) Scheduled() ([]payment.ScheduledTransfer, error) {
+	return b.ps.ScheduledTransfers(), nil
+}
+
+func (b *localBackend) CancelScheduled(id string) error {
+	return b.ps.CancelScheduled(id)
+}
+
+func (b *localBackend) BackValue(source string, dest string, amount float32, valueDate time.Time) (payment.Correction, error) {
+	return b.ps.BackValueTransfer("", source, dest, amount, valueDate)
+}
+
+func (b *localBackend) PostSuspense(accountNum string, amount float32, reference string) (payment.Transaction, error) {
+	return b.ps.PostSuspense(accountNum, amount, reference)
+}
+
+// ReceiveMT103 books the MT103 from the vostro account and returns the beneficiary account.
+func (b *localBackend) ReceiveMT103(mt swift.MT103, settlement string) (payment.Account, error) {
+	if err := swift.Receive(b.ps, mt, settlement); err != nil {
+		return payment.Account{}, err
+	}
+
+	return b.ps.AccountByNumber(mt.BeneficiaryAccount)
+}
+
+func (b *localBackend) Check() (payment.CheckReport, error) {
+	return b.ps.Check(), nil
+}
+
+func (b *localBackend) Dump() ([]byte, error) {
+	return b.ps.DumpStore()
+}
+
+func (b *localBackend) Restore(data []byte) error {
+	return b.ps.Restore(data)
+}
+
+// Save writes the state to a temporary file and renames it over the state file.
+func (b *localBackend) Save() error {
+	data, err := b.ps.Snapshot()
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(b.path), filepath.Base(b.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	return os.Rename(tmp.Name(), b.path)
+}
+
+func (b *localBackend) Close() error {
+	return nil
+}
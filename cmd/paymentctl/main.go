@@ -0,0 +1,1025 @@
+// Command paymentctl operates the payment system either on a local state file
+// or on a remote paymentd server.
+//
+//	paymentctl [-state file | -remote addr] [-o table|json] <command> [flags]
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/bulk"
+	"github.com/soundrise/go-payment-system/payment/iso20022"
+	"github.com/soundrise/go-payment-system/payment/recon"
+	"github.com/soundrise/go-payment-system/payment/swift"
+)
+
+const (
+	DefaultStateFile = "payment-state.json"
+	DefaultTimeout   = 30 * time.Second
+)
+
+var errUsage = errors.New("usage")
+
+/*
+This struct marks the error of a command which processed a batch
+and failed on some of its items: the items which succeeded are saved.
+*/
+type incompleteError struct {
+	error
+}
+
+// env is what a command needs to run.
+type env struct {
+	b backend
+	p printer
+}
+
+type command struct {
+	usage string
+	run   func(e env, args []string) error
+}
+
+var commands = map[string]command{
+	"customer create":    {"-id ID -name NAME", customerCreate},
+	"account create":     {"-customer ID -currency CODE [-amount N] [-type BY|SE|ST]", accountCreate},
+	"account show":       {"[-customer ID] -number NUM", accountShow},
+	"account list":       {"[-customer ID] [-currency CODE] [-status S] [-type T] [-min-balance N] [-max-balance N] [-created-from DATE] [-created-to DATE] [-sort F] [-desc] [-limit N] [-cursor C]", accountList},
+	"account block":      {"[-customer ID] -number NUM", accountBlock},
+	"account activate":   {"[-customer ID] -number NUM", accountActivate},
+	"emit":               {"-amount N [-currency CODE]", emit},
+	"supply":             {"", supply},
+	"check":              {"", check},
+	"terminate":          {"[-customer ID] -number NUM -amount N", terminate},
+	"disburse":           {"[-customer ID] -number NUM -amount N -reference REF", disburse},
+	"disburse batch":     {"-file PATH", disburseBatch},
+	"import":             {"-file PATH [-format csv|pain001] [-pain002 PATH]", importFile},
+	"swift receive":      {"-file PATH -settlement NUM", swiftReceive},
+	"eod":                {"[-interest RATE] [-fee N] [-fee-currency CODE]", eod},
+	"transfer":           {"[-from-customer ID] -from NUM [-to-customer ID] -to NUM -amount N", transfer},
+	"transfer schedule":  {"-from NUM -to NUM -amount N -value-date DATE", transferSchedule},
+	"transfer scheduled": {"", transferScheduled},
+	"transfer cancel":    {"-id ID", transferCancel},
+	"transfer correct":   {"-from NUM -to NUM -amount N -value-date DATE", transferCorrect},
+	"dump":               {"[-file PATH]", dump},
+	"restore":            {"-file PATH", restore},
+	"recon":              {"[-customer ID] -number NUM -file PATH [-format csv|mt940] [-from DATE] [-to DATE] [-amount-tolerance N] [-days N] [-post]", reconcile},
+	"statement":          {"[-customer ID] -number NUM [-from DATE] [-to DATE] [-camt053 PATH] [-mt940 PATH]", statement},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "paymentctl:", err)
+		}
+
+		os.Exit(1)
+	}
+}
+
+/*
+This func parses the global flags, finds the command,
+opens the backend and runs the command on it.
+*/
+func run(args []string, stdout io.Writer, stderr io.Writer) (err error) {
+	fs := flag.NewFlagSet("paymentctl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	state := fs.String("state", envOr("PAYMENTCTL_STATE", DefaultStateFile), "local state `file`")
+	remote := fs.String("remote", os.Getenv("PAYMENTCTL_REMOTE"), "`address` of a paymentd server, overrides -state")
+	output := fs.String("o", outputTable, "output format: table or json")
+	timeout := fs.Duration("timeout", DefaultTimeout, "timeout of remote calls")
+	verbose := fs.Bool("v", false, "log operations to stderr")
+
+	fs.Usage = func() { printUsage(stderr, fs) }
+
+	if err := fs.Parse(args); err != nil {
+		return errUsage
+	}
+
+	name, rest := commandName(fs.Args())
+
+	cmd, ok := commands[name]
+	if !ok {
+		fs.Usage()
+
+		return errUsage
+	}
+
+	p, err := newPrinter(stdout, *output)
+	if err != nil {
+		return err
+	}
+
+	level := slog.LevelWarn
+	if *verbose {
+		level = slog.LevelInfo
+	}
+
+	logger := slog.New(slog.NewTextHandler(stderr, &slog.HandlerOptions{Level: level}))
+
+	var b backend
+
+	if *remote != "" {
+		b, err = openRemote(*remote, *timeout)
+	} else {
+		b, err = openLocal(*state, logger)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		// the state of a failed command is dropped
+		var incomplete incompleteError
+		if err == nil || errors.As(err, &incomplete) {
+			if serr := b.Save(); serr != nil && err == nil {
+				err = serr
+			}
+		}
+
+		if cerr := b.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}()
+
+	return cmd.run(env{b: b, p: p}, rest)
+}
+
+// commandName splits the command, which is one or two words, from its flags.
+func commandName(args []string) (string, []string) {
+	if len(args) == 0 {
+		return "", nil
+	}
+
+	if len(args) > 1 {
+		if _, ok := commands[args[0]+" "+args[1]]; ok {
+			return args[0] + " " + args[1], args[2:]
+		}
+	}
+
+	return args[0], args[1:]
+}
+
+func printUsage(w io.Writer, fs *flag.FlagSet) {
+	fmt.Fprintln(w, "usage: paymentctl [flags] <command> [command flags]")
+	fmt.Fprintln(w, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for n := range commands {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	for _, n := range names {
+		fmt.Fprintf(w, "  %-17s %s\n", n, commands[n].usage)
+	}
+
+	fmt.Fprintln(w, "\nflags:")
+	fs.PrintDefaults()
+}
+
+func envOr(key string, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
+
+// newFlagSet creates the flag set of the command.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	return fs
+}
+
+func parse(fs *flag.FlagSet, args []string, required ...string) error {
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("%s: %w", fs.Name(), err)
+	}
+
+	if fs.NArg() > 0 {
+		return fmt.Errorf("%s: unexpected arguments %s", fs.Name(), strings.Join(fs.Args(), " "))
+	}
+
+	for _, r := range required {
+		if fs.Lookup(r).Value.String() == "" {
+			return fmt.Errorf("%s: flag -%s is required", fs.Name(), r)
+		}
+	}
+
+	return nil
+}
+
+/*
+This func returns the account by number. When the customer is not given
+the account is searched among the accounts of all customers.
+*/
+func resolve(b backend, customerId string, num string) (payment.Account, error) {
+	if customerId != "" {
+		return b.Account(customerId, num)
+	}
+
+	accounts, err := b.Accounts("")
+	if err != nil {
+		return payment.Account{}, err
+	}
+
+	for _, a := range accounts {
+		if a.Num == num {
+			return a, nil
+		}
+	}
+
+	return payment.Account{}, fmt.Errorf("%w: %s", payment.ErrAccountNotFound, num)
+}
+
+// parseDate accepts a date (2006-01-02) or an RFC 3339 timestamp.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	return time.Parse(time.RFC3339, s)
+}
+
+func customerCreate(e env, args []string) error {
+	fs := newFlagSet("customer create")
+	id := fs.String("id", "", "customer id")
+	name := fs.String("name", "", "customer name")
+
+	if err := parse(fs, args, "id"); err != nil {
+		return err
+	}
+
+	c, err := e.b.CreateCustomer(payment.NewCustomer(*id, *name, payment.AccountPrefix))
+	if err != nil {
+		return err
+	}
+
+	return e.p.customer(c)
+}
+
+func accountCreate(e env, args []string) error {
+	fs := newFlagSet("account create")
+	cid := fs.String("customer", "", "customer id")
+	currency := fs.String("currency", "", "currency code")
+	amount := fs.Float64("amount", 0, "initial balance")
+	accType := fs.String("type", payment.AccountPrefix, "account type: BY, SE (emission) or ST (termination)")
+
+	if err := parse(fs, args, "customer", "currency"); err != nil {
+		return err
+	}
+
+	switch *accType {
+	case payment.AccountPrefix, payment.AccountStateEmissionPrefix, payment.AccountStateTerminatePrefix:
+	default:
+		return fmt.Errorf("account create: unknown account type %q", *accType)
+	}
+
+	a, err := e.b.CreateAccount(payment.NewCustomer(*cid, "", *accType), *accType, *currency, float32(*amount))
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+// accountFlags defines the flags selecting one account.
+func accountFlags(name string) (*flag.FlagSet, *string, *string) {
+	fs := newFlagSet(name)
+	cid := fs.String("customer", "", "customer id")
+	num := fs.String("number", "", "account number")
+
+	return fs, cid, num
+}
+
+func accountShow(e env, args []string) error {
+	fs, cid, num := accountFlags("account show")
+
+	if err := parse(fs, args, "number"); err != nil {
+		return err
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+/*
+This func lists the accounts of the customer. Any of the query flags
+turns the list into a page of the accounts matching the query.
+*/
+func accountList(e env, args []string) error {
+	fs := newFlagSet("account list")
+	cid := fs.String("customer", "", "customer id, all customers when empty")
+	cur := fs.String("currency", "", "currency code")
+	status := fs.String("status", "", "active or blocked")
+	accType := fs.String("type", "", "first two letters of the number, e.g. BY, SE or ST")
+	minBalance := fs.String("min-balance", "", "lowest balance")
+	maxBalance := fs.String("max-balance", "", "highest balance")
+	createdFrom := fs.String("created-from", "", "created on or after the date")
+	createdTo := fs.String("created-to", "", "created before the date")
+	sortBy := fs.String("sort", "", "number, balance, created_at or customer_id")
+	desc := fs.Bool("desc", false, "descending order")
+	limit := fs.Int("limit", 0, fmt.Sprintf("accounts of a page, %d when 0", payment.DefaultPageSize))
+	cursor := fs.String("cursor", "", "next cursor of the previous page")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	query := false
+
+	fs.Visit(func(f *flag.Flag) {
+		query = query || f.Name != "customer"
+	})
+
+	if !query {
+		accounts, err := e.b.Accounts(*cid)
+		if err != nil {
+			return err
+		}
+
+		return e.p.accounts(accounts)
+	}
+
+	q := payment.AccountQuery{
+		CustomerId:   *cid,
+		CurrencyCode: *cur,
+		Status:       *status,
+		Type:         *accType,
+		SortBy:       *sortBy,
+		Desc:         *desc,
+		Limit:        *limit,
+		Cursor:       *cursor,
+	}
+
+	var err error
+
+	for _, b := range []struct {
+		name  string
+		value string
+		dst   **float32
+	}{{"min-balance", *minBalance, &q.MinBalance}, {"max-balance", *maxBalance, &q.MaxBalance}} {
+		if b.value == "" {
+			continue
+		}
+
+		f, err := strconv.ParseFloat(b.value, 32)
+		if err != nil {
+			return fmt.Errorf("account list: invalid -%s: %w", b.name, err)
+		}
+
+		amount := float32(f)
+		*b.dst = &amount
+	}
+
+	if q.CreatedFrom, err = parseDate(*createdFrom); err != nil {
+		return fmt.Errorf("account list: invalid -created-from: %w", err)
+	}
+
+	if q.CreatedTo, err = parseDate(*createdTo); err != nil {
+		return fmt.Errorf("account list: invalid -created-to: %w", err)
+	}
+
+	page, err := e.b.QueryAccounts(q)
+	if err != nil {
+		return err
+	}
+
+	return e.p.accountPage(page)
+}
+
+func accountBlock(e env, args []string) error {
+	fs, cid, num := accountFlags("account block")
+
+	if err := parse(fs, args, "number"); err != nil {
+		return err
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	a, err = e.b.Block(a)
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+func accountActivate(e env, args []string) error {
+	fs, cid, num := accountFlags("account activate")
+
+	if err := parse(fs, args, "number"); err != nil {
+		return err
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	a, err = e.b.Activate(a)
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+func emit(e env, args []string) error {
+	fs := newFlagSet("emit")
+	amount := fs.Float64("amount", 0, "amount to emit")
+	currency := fs.String("currency", payment.DefaultCurrency, "currency of the emission")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	a, err := e.b.Emit(*currency, float32(*amount))
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+func supply(e env, args []string) error {
+	if err := parse(newFlagSet("supply"), args); err != nil {
+		return err
+	}
+
+	list, err := e.b.MoneySupply()
+	if err != nil {
+		return err
+	}
+
+	return e.p.moneySupply(list)
+}
+
+// check verifies the invariants of the store, a violated invariant is an error.
+func check(e env, args []string) error {
+	if err := parse(newFlagSet("check"), args); err != nil {
+		return err
+	}
+
+	r, err := e.b.Check()
+	if err != nil {
+		return err
+	}
+
+	if err := e.p.checkReport(r); err != nil {
+		return err
+	}
+
+	if !r.OK() {
+		return fmt.Errorf("check: %d violations", len(r.Violations))
+	}
+
+	return nil
+}
+
+func terminate(e env, args []string) error {
+	fs, cid, num := accountFlags("terminate")
+	amount := fs.Float64("amount", 0, "amount to terminate")
+
+	if err := parse(fs, args, "number"); err != nil {
+		return err
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	a, err = e.b.Terminate(a, float32(*amount))
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+func transfer(e env, args []string) error {
+	fs := newFlagSet("transfer")
+	fromCid := fs.String("from-customer", "", "customer id of the source account")
+	from := fs.String("from", "", "source account number")
+	toCid := fs.String("to-customer", "", "customer id of the destination account")
+	to := fs.String("to", "", "destination account number")
+	amount := fs.Float64("amount", 0, "amount to transfer")
+
+	if err := parse(fs, args, "from", "to"); err != nil {
+		return err
+	}
+
+	s, err := resolve(e.b, *fromCid, *from)
+	if err != nil {
+		return err
+	}
+
+	d, err := resolve(e.b, *toCid, *to)
+	if err != nil {
+		return err
+	}
+
+	s, d, err = e.b.Transfer(s, d, float32(*amount))
+	if err != nil {
+		return err
+	}
+
+	return e.p.accounts([]payment.Account{s, d})
+}
+
+// valueDatedFlags returns the flag set of the transfers with a value date.
+func valueDatedFlags(name string) (*flag.FlagSet, *string, *string, *float64, *string) {
+	fs := newFlagSet(name)
+	from := fs.String("from", "", "source account number")
+	to := fs.String("to", "", "destination account number")
+	amount := fs.Float64("amount", 0, "amount to transfer")
+	date := fs.String("value-date", "", "value date of the transfer")
+
+	return fs, from, to, amount, date
+}
+
+// transferSchedule registers a transfer posted when the business date reaches the value date.
+func transferSchedule(e env, args []string) error {
+	fs, from, to, amount, date := valueDatedFlags("transfer schedule")
+
+	if err := parse(fs, args, "from", "to", "value-date"); err != nil {
+		return err
+	}
+
+	valueDate, err := parseDate(*date)
+	if err != nil {
+		return fmt.Errorf("transfer schedule: invalid -value-date: %w", err)
+	}
+
+	st, err := e.b.Schedule(*from, *to, float32(*amount), valueDate)
+	if err != nil {
+		return err
+	}
+
+	return e.p.scheduled([]payment.ScheduledTransfer{st})
+}
+
+func transferScheduled(e env, args []string) error {
+	if err := parse(newFlagSet("transfer scheduled"), args); err != nil {
+		return err
+	}
+
+	list, err := e.b.Scheduled()
+	if err != nil {
+		return err
+	}
+
+	return e.p.scheduled(list)
+}
+
+func transferCancel(e env, args []string) error {
+	fs := newFlagSet("transfer cancel")
+	id := fs.String("id", "", "id of the scheduled transfer")
+
+	if err := parse(fs, args, "id"); err != nil {
+		return err
+	}
+
+	return e.b.CancelScheduled(*id)
+}
+
+// transferCorrect posts a back-valued correction and recomputes the interest since the value date.
+func transferCorrect(e env, args []string) error {
+	fs, from, to, amount, date := valueDatedFlags("transfer correct")
+
+	if err := parse(fs, args, "from", "to", "value-date"); err != nil {
+		return err
+	}
+
+	valueDate, err := parseDate(*date)
+	if err != nil {
+		return fmt.Errorf("transfer correct: invalid -value-date: %w", err)
+	}
+
+	c, err := e.b.BackValue(*from, *to, float32(*amount), valueDate)
+	if err != nil {
+		return err
+	}
+
+	return e.p.correction(c)
+}
+
+/*
+This func books the inbound MT103 of the file as a transfer to the beneficiary.
+The settlement account, the vostro account of the correspondent bank, pays.
+*/
+func swiftReceive(e env, args []string) error {
+	fs := newFlagSet("swift receive")
+	file := fs.String("file", "", "MT103 message file")
+	settlement := fs.String("settlement", "", "vostro account of the correspondent bank to debit")
+
+	if err := parse(fs, args, "file", "settlement"); err != nil {
+		return err
+	}
+
+	in, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	m, err := swift.Parse(in)
+	if err != nil {
+		return err
+	}
+
+	mt, err := swift.ParseMT103(m)
+	if err != nil {
+		return err
+	}
+
+	d, err := e.b.ReceiveMT103(mt, *settlement)
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(d)
+}
+
+func disburse(e env, args []string) error {
+	fs, cid, num := accountFlags("disburse")
+	amount := fs.Float64("amount", 0, "amount to disburse")
+	reference := fs.String("reference", "", "unique reference of the disbursement")
+
+	if err := parse(fs, args, "number", "reference"); err != nil {
+		return err
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	a, err = e.b.Disburse(a, float32(*amount), *reference)
+	if err != nil {
+		return err
+	}
+
+	return e.p.account(a)
+}
+
+/*
+This func disburses every line of the CSV file and prints the result
+of each line. Lines with a reference disbursed before fail,
+so the file can be run again after a partial failure.
+*/
+func disburseBatch(e env, args []string) error {
+	fs := newFlagSet("disburse batch")
+	file := fs.String("file", "", "CSV file with the header customer_id,account,amount,reference")
+
+	if err := parse(fs, args, "file"); err != nil {
+		return err
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	batch, err := payment.ParseDisbursements(f)
+	if err != nil {
+		return err
+	}
+
+	results := make([]payment.DisbursementResult, 0, len(batch))
+	failed := 0
+
+	for i, item := range batch {
+		r := payment.DisbursementResult{Disbursement: item, Row: i + 1}
+
+		a, err := resolve(e.b, item.CustomerId, item.Account)
+		if err == nil {
+			_, err = e.b.Disburse(a, item.Amount, item.Reference)
+		}
+
+		if err != nil {
+			r.Error = strings.TrimSpace(err.Error())
+			failed++
+		}
+
+		results = append(results, r)
+	}
+
+	if err := e.p.disbursements(results); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return incompleteError{fmt.Errorf("disburse batch: %d of %d disbursements failed", failed, len(results))}
+	}
+
+	return nil
+}
+
+/*
+This func imports the transfers of a CSV or pain.001 file, prints
+the result of every line and writes the pain.002 status report when asked.
+*/
+func importFile(e env, args []string) error {
+	fs := newFlagSet("import")
+	file := fs.String("file", "", "CSV or pain.001 file")
+	format := fs.String("format", "", "csv or pain001, detected from the content when empty")
+	report := fs.String("pain002", "", "file to write the pain.002 status report to")
+
+	if err := parse(fs, args, "file"); err != nil {
+		return err
+	}
+
+	in, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	f, err := bulk.Parse(in, *format)
+	if err != nil {
+		return err
+	}
+
+	r, err := e.b.Import(f)
+	if err != nil {
+		return err
+	}
+
+	if *report != "" {
+		if err := writePain002(*report, r); err != nil {
+			return err
+		}
+	}
+
+	if err := e.p.bulkReport(r); err != nil {
+		return err
+	}
+
+	if r.Rejected > 0 {
+		return incompleteError{fmt.Errorf("import: %d of %d lines rejected", r.Rejected, len(r.Results))}
+	}
+
+	return nil
+}
+
+func writePain002(path string, r bulk.Report) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	if err := r.Pain002("STS"+now.Format("20060102150405"), now).Write(out); err != nil {
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
+
+func dump(e env, args []string) error {
+	fs := newFlagSet("dump")
+	file := fs.String("file", "", "write the dump to the file instead of stdout")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	data, err := e.b.Dump()
+	if err != nil {
+		return err
+	}
+
+	if *file != "" {
+		return os.WriteFile(*file, data, 0o600)
+	}
+
+	_, err = fmt.Fprintln(e.p.w, string(data))
+
+	return err
+}
+
+func restore(e env, args []string) error {
+	fs := newFlagSet("restore")
+	file := fs.String("file", "", "dump file, - reads stdin")
+
+	if err := parse(fs, args, "file"); err != nil {
+		return err
+	}
+
+	var (
+		data []byte
+		err  error
+	)
+
+	if *file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*file)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return e.b.Restore(data)
+}
+
+/*
+This func closes the business day. The interest rate is annual,
+e.g. 0.05, the fee is charged monthly on the last day of the month.
+*/
+func eod(e env, args []string) error {
+	fs := newFlagSet("eod")
+	rate := fs.Float64("interest", 0, "annual interest rate paid on positive balances")
+	fee := fs.Float64("fee", 0, "monthly fee charged on the last day of the month")
+	feeCurrency := fs.String("fee-currency", payment.DefaultCurrency, "currency of the accounts charged with the fee")
+
+	if err := parse(fs, args); err != nil {
+		return err
+	}
+
+	if *rate < 0 || *fee < 0 {
+		return fmt.Errorf("eod: -interest and -fee must not be negative")
+	}
+
+	dc, err := e.b.CloseDay(*rate, float32(*fee), *feeCurrency)
+	if err != nil {
+		return err
+	}
+
+	return e.p.dayClose(dc)
+}
+
+/*
+This func reconciles the ledger of the account in the period with
+the external statement of the file. With -post the breaks are parked
+on the suspense account, otherwise a report with breaks is an error.
+*/
+func reconcile(e env, args []string) error {
+	fs, cid, num := accountFlags("recon")
+	file := fs.String("file", "", "external statement, CSV or MT940")
+	format := fs.String("format", "", "csv or mt940, detected from the content when empty")
+	fromFlag := fs.String("from", "", "start of the period, inclusive")
+	toFlag := fs.String("to", "", "end of the period, exclusive")
+	amountTol := fs.Float64("amount-tolerance", 0, "allowed difference of the amounts")
+	days := fs.Int("days", 0, "allowed difference of the dates in days")
+	post := fs.Bool("post", false, "park the breaks on the suspense account")
+
+	if err := parse(fs, args, "number", "file"); err != nil {
+		return err
+	}
+
+	from, err := parseDate(*fromFlag)
+	if err != nil {
+		return fmt.Errorf("recon: invalid -from: %w", err)
+	}
+
+	to, err := parseDate(*toFlag)
+	if err != nil {
+		return fmt.Errorf("recon: invalid -to: %w", err)
+	}
+
+	in, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	external, err := recon.Parse(in, *format)
+	if err != nil {
+		return err
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	st, err := e.b.Statement(a, from, to)
+	if err != nil {
+		return err
+	}
+
+	r := recon.Reconcile(recon.FromStatement(st), external, recon.Tolerance{Amount: float32(*amountTol), Days: *days})
+
+	var postings []recon.Posting
+	if *post {
+		postings = recon.PostBreaks(e.b, a.Num, r)
+	}
+
+	if err := e.p.reconReport(r, postings); err != nil {
+		return err
+	}
+
+	breaks := len(r.UnmatchedLedger) + len(r.UnmatchedExternal)
+
+	for _, p := range postings {
+		if p.Error == "" {
+			breaks--
+		}
+	}
+
+	if breaks > 0 {
+		return incompleteError{fmt.Errorf("recon: %d breaks", breaks)}
+	}
+
+	return nil
+}
+
+func statement(e env, args []string) error {
+	fs, cid, num := accountFlags("statement")
+	fromFlag := fs.String("from", "", "start of the period, inclusive")
+	toFlag := fs.String("to", "", "end of the period, exclusive")
+	camt := fs.String("camt053", "", "file to write the statement to in camt.053 format")
+	mt940 := fs.String("mt940", "", "file to write the statement to as MT940")
+
+	if err := parse(fs, args, "number"); err != nil {
+		return err
+	}
+
+	from, err := parseDate(*fromFlag)
+	if err != nil {
+		return fmt.Errorf("statement: invalid -from: %w", err)
+	}
+
+	to, err := parseDate(*toFlag)
+	if err != nil {
+		return fmt.Errorf("statement: invalid -to: %w", err)
+	}
+
+	a, err := resolve(e.b, *cid, *num)
+	if err != nil {
+		return err
+	}
+
+	st, err := e.b.Statement(a, from, to)
+	if err != nil {
+		return err
+	}
+
+	if *camt != "" {
+		if err := writeCamt053(*camt, st); err != nil {
+			return err
+		}
+	}
+
+	if *mt940 != "" {
+		now := time.Now().UTC()
+		msg := swift.NewMT940("STMT"+now.Format("20060102150405"), 1, st, now).Message()
+
+		if err := os.WriteFile(*mt940, []byte(msg.String()), 0o644); err != nil {
+			return err
+		}
+	}
+
+	return e.p.statement(st)
+}
+
+func writeCamt053(path string, st payment.Statement) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+
+	if err := iso20022.NewCamt053("STMT"+now.Format("20060102150405"), now, st).Write(out); err != nil {
+		out.Close()
+
+		return err
+	}
+
+	return out.Close()
+}
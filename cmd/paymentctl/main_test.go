@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/grpcapi"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"github.com/soundrise/go-payment-system/payment/recon"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+)
+
+// ctl runs paymentctl with the flags and returns its stdout.
+func ctl(t *testing.T, flags []string, args ...string) (string, error) {
+	t.Helper()
+
+	var stdout, stderr bytes.Buffer
+
+	err := run(append(append([]string{}, flags...), args...), &stdout, &stderr)
+
+	return stdout.String(), err
+}
+
+// scenario creates accounts, emits money and moves it between customers.
+func scenario(t *testing.T, flags []string) {
+	t.Helper()
+
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	_, err := ctl(t, flags, "account", "create", "-customer", "0", "-type", "SE", "-currency", "BYN")
+	require.NoError(t, err)
+
+	_, err = ctl(t, flags, "account", "create", "-customer", "0", "-type", "ST", "-currency", "BYN")
+	require.NoError(t, err)
+
+	out, err := ctl(t, flags, "customer", "create", "-id", "1", "-name", "Customer One")
+	require.NoError(t, err)
+	assert.Contains(t, out, "Customer One")
+
+	out, err = ctl(t, json, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "100")
+	require.NoError(t, err)
+
+	var a1 payment.Account
+	require.NoError(t, unmarshal(out, &a1))
+
+	out, err = ctl(t, json, "account", "create", "-customer", "2", "-currency", "BYN")
+	require.NoError(t, err)
+
+	var a2 payment.Account
+	require.NoError(t, unmarshal(out, &a2))
+
+	_, err = ctl(t, flags, "emit", "-amount", "1000")
+	require.NoError(t, err)
+
+	out, err = ctl(t, flags, "transfer", "-from", a1.Num, "-to", a2.Num, "-amount", "40")
+	require.NoError(t, err)
+	assert.Contains(t, out, a1.Num)
+	assert.Contains(t, out, "60.00")
+
+	_, err = ctl(t, flags, "terminate", "-customer", "2", "-number", a2.Num, "-amount", "15")
+	require.NoError(t, err)
+
+	out, err = ctl(t, json, "account", "list", "-customer", "2")
+	require.NoError(t, err)
+
+	var list []payment.Account
+	require.NoError(t, unmarshal(out, &list))
+	require.Len(t, list, 1)
+	assert.Equal(t, float32(25), list[0].Balance)
+
+	out, err = ctl(t, json, "account", "list", "-currency", "BYN", "-min-balance", "50", "-sort", "balance", "-desc", "-limit", "1")
+	require.NoError(t, err)
+
+	var page payment.AccountPage
+	require.NoError(t, unmarshal(out, &page))
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Accounts, 1)
+	assert.Equal(t, payment.AccountStateEmissionNumber, page.Accounts[0].Num)
+
+	out, err = ctl(t, flags, "account", "list", "-currency", "BYN", "-min-balance", "50", "-sort", "balance", "-desc", "-limit", "1", "-cursor", page.NextCursor)
+	require.NoError(t, err)
+	assert.Contains(t, out, a1.Num)
+	assert.Contains(t, out, "1 of 2 accounts")
+	assert.NotContains(t, out, "next page")
+
+	_, err = ctl(t, flags, "account", "list", "-sort", "name")
+	assert.ErrorIs(t, err, payment.ErrInvalidQuery)
+
+	out, err = ctl(t, json, "statement", "-number", a2.Num)
+	require.NoError(t, err)
+
+	var st payment.Statement
+	require.NoError(t, unmarshal(out, &st))
+	assert.Equal(t, float32(0), st.Opening)
+	assert.Equal(t, float32(25), st.Closing)
+	require.Len(t, st.Lines, 2)
+	assert.Equal(t, float32(40), st.Lines[0].Credit)
+	assert.Equal(t, float32(15), st.Lines[1].Debit)
+
+	camt := filepath.Join(t.TempDir(), "camt053.xml")
+
+	_, err = ctl(t, flags, "statement", "-number", a2.Num, "-camt053", camt)
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(camt)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<IBAN>"+a2.Num+"</IBAN>")
+	assert.Contains(t, string(data), "<CdtDbtInd>DBIT</CdtDbtInd>")
+
+	mt940 := filepath.Join(t.TempDir(), "mt940.txt")
+
+	_, err = ctl(t, flags, "statement", "-number", a2.Num, "-mt940", mt940)
+	require.NoError(t, err)
+
+	data, err = os.ReadFile(mt940)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ":25:"+a2.Num)
+	assert.Contains(t, string(data), "BYN25,00")
+
+	out, err = ctl(t, flags, "account", "block", "-number", a2.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, payment.Blocked)
+
+	_, err = ctl(t, flags, "transfer", "-from", a1.Num, "-to", a2.Num, "-amount", "1")
+	assert.ErrorContains(t, err, "is not valid or blocked")
+
+	out, err = ctl(t, flags, "account", "activate", "-customer", "2", "-number", a2.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, payment.Active)
+
+	dumpFile := filepath.Join(t.TempDir(), "dump.json")
+
+	_, err = ctl(t, flags, "dump", "-file", dumpFile)
+	require.NoError(t, err)
+
+	_, err = ctl(t, flags, "transfer", "-from", a1.Num, "-to", a2.Num, "-amount", "10")
+	require.NoError(t, err)
+
+	_, err = ctl(t, flags, "restore", "-file", dumpFile)
+	require.NoError(t, err)
+
+	out, err = ctl(t, flags, "account", "show", "-number", a1.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, "60.00")
+}
+
+func unmarshal(s string, v any) error {
+	return json.Unmarshal([]byte(s), v)
+}
+
+func TestRun_Local(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	scenario(t, []string{"-state", state})
+}
+
+func TestRun_Remote(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	gs := grpc.NewServer()
+	pb.RegisterPaymentServiceServer(gs, grpcapi.NewServer(payment.NewPaymentSystem(payment.WithProcessingDelay(0))))
+
+	go func() { _ = gs.Serve(lis) }()
+
+	defer gs.Stop()
+
+	scenario(t, []string{"-remote", lis.Addr().String()})
+}
+
+func TestRun_Errors(t *testing.T) {
+	state := filepath.Join(t.TempDir(), "state.json")
+	flags := []string{"-state", state}
+
+	_, err := ctl(t, flags, "unknown")
+	assert.ErrorIs(t, err, errUsage)
+
+	_, err = ctl(t, flags, "account", "create", "-customer", "1")
+	assert.EqualError(t, err, "account create: flag -currency is required")
+
+	_, err = ctl(t, flags, "account", "show", "-number", "BY00ABCD00000000000000000000")
+	assert.ErrorIs(t, err, payment.ErrAccountNotFound)
+
+	_, err = ctl(t, append(flags, "-o", "yaml"), "account", "list")
+	assert.ErrorContains(t, err, "unknown output format")
+
+	_, err = ctl(t, flags, "customer", "create", "-id", "1")
+	require.NoError(t, err)
+
+	_, err = ctl(t, flags, "customer", "create", "-id", "1")
+	assert.ErrorIs(t, err, payment.ErrCustomerExists)
+
+	out, err := ctl(t, flags, "account", "list")
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(out, "\n"), "only the header is printed")
+
+	// a failed command does not write the state
+	before, err := os.ReadFile(state)
+	require.NoError(t, err)
+
+	_, err = ctl(t, flags, "transfer", "-from", "BY00ABCD00000000000000000000", "-to", "BY00ABCD00000000000000000001", "-amount", "10")
+	require.Error(t, err)
+
+	after, err := os.ReadFile(state)
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+}
+
+func TestRun_Import(t *testing.T) {
+	dir := t.TempDir()
+	flags := []string{"-state", filepath.Join(dir, "state.json")}
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	out, err := ctl(t, json, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "100")
+	require.NoError(t, err)
+
+	var a1 payment.Account
+	require.NoError(t, unmarshal(out, &a1))
+
+	out, err = ctl(t, json, "account", "create", "-customer", "2", "-currency", "BYN")
+	require.NoError(t, err)
+
+	var a2 payment.Account
+	require.NoError(t, unmarshal(out, &a2))
+
+	file := filepath.Join(dir, "bulk.csv")
+	csv := "source,destination,currency,amount,reference\n" +
+		a1.Num + "," + a2.Num + ",BYN,30,R1\n" +
+		a1.Num + "," + a2.Num + ",BYN,500,R2\n"
+	require.NoError(t, os.WriteFile(file, []byte(csv), 0o600))
+
+	report := filepath.Join(dir, "pain002.xml")
+
+	out, err = ctl(t, flags, "import", "-file", file, "-pain002", report)
+	assert.EqualError(t, err, "import: 1 of 2 lines rejected")
+	assert.Contains(t, out, "AM04")
+
+	data, err := os.ReadFile(report)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "<GrpSts>PART</GrpSts>")
+
+	out, err = ctl(t, flags, "account", "show", "-number", a2.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, "30.00")
+}
+
+func TestRun_SwiftReceive(t *testing.T) {
+	dir := t.TempDir()
+	flags := []string{"-state", filepath.Join(dir, "state.json")}
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	out, err := ctl(t, json, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "100")
+	require.NoError(t, err)
+
+	var a1 payment.Account
+	require.NoError(t, unmarshal(out, &a1))
+
+	out, err = ctl(t, json, "account", "create", "-customer", "2", "-currency", "BYN")
+	require.NoError(t, err)
+
+	var a2 payment.Account
+	require.NoError(t, unmarshal(out, &a2))
+
+	file := filepath.Join(dir, "mt103.txt")
+	msg := ":20:R1\n:32A:240131BYN12,50\n:50K:/BY99ZZZZ00000000000000000099\nAbroad\n:59:/" + a2.Num + "\n"
+	require.NoError(t, os.WriteFile(file, []byte(msg), 0o600))
+
+	_, err = ctl(t, flags, "swift", "receive", "-file", file)
+	assert.Error(t, err, "the ordering account is never debited")
+
+	_, err = ctl(t, flags, "swift", "receive", "-file", file, "-settlement", a1.Num)
+	assert.ErrorContains(t, err, "is not a vostro account")
+
+	out, err = ctl(t, flags, "account", "show", "-number", a1.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, "100.00")
+}
+
+func TestRun_EOD(t *testing.T) {
+	dir := t.TempDir()
+	flags := []string{"-state", filepath.Join(dir, "state.json")}
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	_, err := ctl(t, json, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "3650")
+	require.NoError(t, err)
+
+	out, err := ctl(t, json, "eod", "-interest", "0.1")
+	require.NoError(t, err)
+
+	var first payment.DayClose
+	require.NoError(t, unmarshal(out, &first))
+	require.Len(t, first.Jobs, 1)
+	assert.Equal(t, 1, first.Jobs[0].Postings)
+	assert.InDelta(t, 1, first.Jobs[0].Amount, 0.001)
+
+	out, err = ctl(t, flags, "eod")
+	require.NoError(t, err)
+	assert.Contains(t, out, "business date "+first.Date.AddDate(0, 0, 1).Format(time.DateOnly)+" closed")
+	assert.Contains(t, out, "BALANCED")
+
+	_, err = ctl(t, flags, "eod", "-fee", "-1")
+	assert.Error(t, err)
+}
+
+func TestRun_ValueDatedTransfers(t *testing.T) {
+	dir := t.TempDir()
+	flags := []string{"-state", filepath.Join(dir, "state.json")}
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	out, err := ctl(t, json, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "100")
+	require.NoError(t, err)
+
+	var a1 payment.Account
+	require.NoError(t, unmarshal(out, &a1))
+
+	out, err = ctl(t, json, "account", "create", "-customer", "2", "-currency", "BYN")
+	require.NoError(t, err)
+
+	var a2 payment.Account
+	require.NoError(t, unmarshal(out, &a2))
+
+	today := time.Now()
+	tomorrow := today.AddDate(0, 0, 1).Format(time.DateOnly)
+
+	out, err = ctl(t, json, "transfer", "schedule", "-from", a1.Num, "-to", a2.Num, "-amount", "30", "-value-date", tomorrow)
+	require.NoError(t, err)
+
+	var st []payment.ScheduledTransfer
+	require.NoError(t, unmarshal(out, &st))
+	require.Len(t, st, 1)
+	assert.Equal(t, payment.ScheduledWaiting, st[0].Status)
+
+	_, err = ctl(t, flags, "transfer", "correct", "-from", a1.Num, "-to", a2.Num, "-amount", "5", "-value-date", tomorrow)
+	assert.ErrorIs(t, err, payment.ErrInvalidValueDate)
+
+	out, err = ctl(t, flags, "eod")
+	require.NoError(t, err)
+	assert.Contains(t, out, "scheduled transfers posted on "+tomorrow)
+
+	out, err = ctl(t, flags, "transfer", "scheduled")
+	require.NoError(t, err)
+	assert.Contains(t, out, payment.ScheduledPosted)
+
+	out, err = ctl(t, flags, "transfer", "correct", "-from", a1.Num, "-to", a2.Num, "-amount", "5", "-value-date", today.Format(time.DateOnly))
+	require.NoError(t, err)
+	assert.Contains(t, out, "with value date "+today.Format(time.DateOnly))
+	assert.Contains(t, out, "closed day "+today.Format(time.DateOnly)+" restated")
+
+	out, err = ctl(t, flags, "account", "show", "-number", a2.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, "35.00")
+}
+
+func TestRun_Recon(t *testing.T) {
+	dir := t.TempDir()
+	flags := []string{"-state", filepath.Join(dir, "state.json")}
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	out, err := ctl(t, json, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "100")
+	require.NoError(t, err)
+
+	var a1 payment.Account
+	require.NoError(t, unmarshal(out, &a1))
+
+	out, err = ctl(t, json, "account", "create", "-customer", "2", "-currency", "BYN")
+	require.NoError(t, err)
+
+	var a2 payment.Account
+	require.NoError(t, unmarshal(out, &a2))
+
+	_, err = ctl(t, flags, "transfer", "-from", a1.Num, "-to", a2.Num, "-amount", "30")
+	require.NoError(t, err)
+
+	today := time.Now().Format(time.DateOnly)
+	file := filepath.Join(dir, "bank.csv")
+	require.NoError(t, os.WriteFile(file, []byte("date,amount,reference,description\n"+
+		today+",-30,,transfer\n"+
+		today+",12.5,INT1,interest\n"), 0o600))
+
+	out, err = ctl(t, flags, "recon", "-number", a1.Num, "-file", file)
+	assert.ErrorContains(t, err, "recon: 1 breaks")
+	assert.Contains(t, out, "matched 1, unmatched ledger 0, unmatched external 1")
+
+	out, err = ctl(t, json, "recon", "-number", a1.Num, "-file", file, "-post")
+	require.NoError(t, err)
+
+	var r struct {
+		recon.Report
+		Postings []recon.Posting `json:"postings"`
+	}
+	require.NoError(t, unmarshal(out, &r))
+	require.Len(t, r.Postings, 1)
+	assert.Empty(t, r.Postings[0].Error)
+	assert.Equal(t, float32(12.5), r.Postings[0].Amount)
+
+	out, err = ctl(t, flags, "account", "show", "-number", a1.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, "82.50")
+	// the booked break is not posted again
+	out, err = ctl(t, flags, "recon", "-number", a1.Num, "-file", file, "-post")
+	require.NoError(t, err)
+	assert.Contains(t, out, "booked 1")
+
+	out, err = ctl(t, flags, "account", "show", "-number", a1.Num)
+	require.NoError(t, err)
+	assert.Contains(t, out, "82.50")
+}
+
+func TestRun_Check(t *testing.T) {
+	dir := t.TempDir()
+	flags := []string{"-state", filepath.Join(dir, "state.json")}
+	json := append(append([]string{}, flags...), "-o", "json")
+
+	_, err := ctl(t, flags, "account", "create", "-customer", "0", "-type", "SE", "-currency", "BYN")
+	require.NoError(t, err)
+
+	_, err = ctl(t, flags, "emit", "-amount", "100")
+	require.NoError(t, err)
+
+	out, err := ctl(t, flags, "check")
+	assert.ErrorContains(t, err, "check: 1 violations")
+	assert.Contains(t, out, "missing special account "+payment.AccountStateTerminateNumber)
+
+	_, err = ctl(t, flags, "account", "create", "-customer", "0", "-type", "ST", "-currency", "BYN")
+	require.NoError(t, err)
+
+	out, err = ctl(t, flags, "check")
+	require.NoError(t, err)
+	assert.Contains(t, out, "checked 2 accounts of 1 customers")
+
+	// the opening balance creates money outside of the emission
+	_, err = ctl(t, flags, "account", "create", "-customer", "1", "-currency", "BYN", "-amount", "50")
+	require.NoError(t, err)
+
+	out, err = ctl(t, json, "check")
+	assert.Error(t, err)
+
+	var r payment.CheckReport
+	require.NoError(t, unmarshal(out, &r))
+	require.Len(t, r.Violations, 1)
+	assert.Equal(t, payment.CheckConservation, r.Violations[0].Check)
+}
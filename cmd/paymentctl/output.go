@@ -0,0 +1,341 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/bulk"
+	"github.com/soundrise/go-payment-system/payment/recon"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printer renders the results as an aligned table or as indented JSON.
+type printer struct {
+	w    io.Writer
+	json bool
+}
+
+func newPrinter(w io.Writer, format string) (printer, error) {
+	switch format {
+	case outputTable:
+		return printer{w: w}, nil
+	case outputJSON:
+		return printer{w: w, json: true}, nil
+	}
+
+	return printer{}, fmt.Errorf("unknown output format %q, use %s or %s", format, outputTable, outputJSON)
+}
+
+func (p printer) writeJSON(v any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(v)
+}
+
+func (p printer) customer(c payment.Customer) error {
+	if p.json {
+		return p.writeJSON(c)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME")
+	fmt.Fprintf(tw, "%s\t%s\n", c.Id, c.Name)
+
+	return tw.Flush()
+}
+
+func (p printer) account(a payment.Account) error {
+	if p.json {
+		return p.writeJSON(a)
+	}
+
+	return p.accounts([]payment.Account{a})
+}
+
+func (p printer) accounts(accounts []payment.Account) error {
+	if p.json {
+		if accounts == nil {
+			accounts = []payment.Account{}
+		}
+
+		return p.writeJSON(accounts)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CUSTOMER\tNUMBER\tCURRENCY\tSTATUS\tBALANCE\tRESERVED")
+
+	for _, a := range accounts {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f\t%.2f\n", a.CustomerId, a.Num, a.CurrencyCode, a.Status, a.Balance, a.Reserved)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) accountPage(page payment.AccountPage) error {
+	if p.json {
+		return p.writeJSON(page)
+	}
+
+	if err := p.accounts(page.Accounts); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(p.w, "\n%d of %d accounts\n", len(page.Accounts), page.Total)
+
+	if page.NextCursor != "" {
+		fmt.Fprintf(p.w, "next page: -cursor %s\n", page.NextCursor)
+	}
+
+	return nil
+}
+
+func (p printer) statement(st payment.Statement) error {
+	if p.json {
+		return p.writeJSON(st)
+	}
+
+	fmt.Fprintf(p.w, "Account:  %s (%s)\n", st.Account.Num, st.Account.CurrencyCode)
+	fmt.Fprintf(p.w, "Period:   %s - %s\n", formatBound(st.From), formatBound(st.To))
+	fmt.Fprintf(p.w, "Opening:  %.2f\n", st.Opening)
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tTIME\tVALUE DATE\tTYPE\tCOUNTERPARTY\tDEBIT\tCREDIT\tBALANCE")
+
+	for _, l := range st.Lines {
+		counterparty := l.Source
+		if l.Debit > 0 {
+			counterparty = l.Dest
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%.2f\t%.2f\t%.2f\n",
+			l.Id, l.Time.Format(time.DateTime), l.ValueDate.Format(time.DateOnly), l.Type, counterparty, l.Debit, l.Credit, l.Balance)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(p.w, "Closing:  %.2f\n", st.Closing)
+
+	return err
+}
+
+func formatBound(t time.Time) string {
+	if t.IsZero() {
+		return "*"
+	}
+
+	return t.Format(time.DateOnly)
+}
+
+func (p printer) moneySupply(list []payment.MoneySupply) error {
+	if p.json {
+		if list == nil {
+			list = []payment.MoneySupply{}
+		}
+
+		return p.writeJSON(list)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "CURRENCY\tEMITTED\tTERMINATED\tSUPPLY\tCIRCULATION\tDIFFERENCE\tBALANCED")
+
+	for _, ms := range list {
+		fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%t\n",
+			ms.CurrencyCode, ms.Emitted, ms.Terminated, ms.Supply, ms.Circulation, ms.Difference, ms.Balanced)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) checkReport(r payment.CheckReport) error {
+	if p.json {
+		return p.writeJSON(r)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "checked %d accounts of %d customers at %s\n\n", r.Accounts, r.Customers, r.CheckedAt.Format(time.RFC3339))
+	fmt.Fprintln(tw, "CHECK\tRESULT")
+
+	for _, name := range payment.Checks {
+		result := "ok"
+		if n := r.Counts[name]; n > 0 {
+			result = fmt.Sprintf("%d violations", n)
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\n", name, result)
+	}
+
+	if len(r.Violations) > 0 {
+		fmt.Fprintln(tw, "\nCHECK\tCUSTOMER\tACCOUNT\tCURRENCY\tDETAIL")
+
+		for _, v := range r.Violations {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", v.Check, v.CustomerId, v.Account, v.CurrencyCode, v.Detail)
+		}
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) dayClose(dc payment.DayClose) error {
+	if p.json {
+		return p.writeJSON(dc)
+	}
+
+	fmt.Fprintf(p.w, "business date %s closed, %d accounts snapshotted\n\n", dc.Date.Format(time.DateOnly), len(dc.Balances))
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "JOB\tPOSTINGS\tSKIPPED\tAMOUNT\tRESULT")
+
+	for _, j := range dc.Jobs {
+		result := "ok"
+		if j.Error != "" {
+			result = j.Error
+		}
+
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%s\n", j.Name, j.Postings, j.Skipped, j.Amount, result)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "CURRENCY\tACCOUNTS\tOPENING\tDEBITS\tCREDITS\tCLOSING\tUNPOSTED\tBALANCED")
+
+	for _, tb := range dc.TrialBalance {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\t%t\n",
+			tb.Currency, tb.Accounts, tb.Opening, tb.Debits, tb.Credits, tb.Closing, tb.Unposted, tb.Balanced)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(dc.Scheduled) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(p.w, "\nscheduled transfers posted on %s:\n", dc.Date.AddDate(0, 0, 1).Format(time.DateOnly))
+
+	return p.scheduled(dc.Scheduled)
+}
+
+func (p printer) scheduled(list []payment.ScheduledTransfer) error {
+	if p.json {
+		if list == nil {
+			list = []payment.ScheduledTransfer{}
+		}
+
+		return p.writeJSON(list)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tVALUE DATE\tSOURCE\tDESTINATION\tAMOUNT\tSTATUS")
+
+	for _, st := range list {
+		status := st.Status
+		if st.Error != "" {
+			status += ": " + st.Error
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.2f\t%s\n", st.Id, st.ValueDate.Format(time.DateOnly), st.Source, st.Destination, st.Amount, status)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) correction(c payment.Correction) error {
+	if p.json {
+		return p.writeJSON(c)
+	}
+
+	if c.PendingId != "" {
+		fmt.Fprintf(p.w, "correction with value date %s waits as %s\n", c.ValueDate.Format(time.DateOnly), c.PendingId)
+
+		return nil
+	}
+
+	fmt.Fprintf(p.w, "transaction %s booked on %s with value date %s\n", c.TransactionId, c.BookingDate.Format(time.DateOnly), c.ValueDate.Format(time.DateOnly))
+
+	for _, date := range c.Restated {
+		fmt.Fprintf(p.w, "closed day %s restated\n", date.Format(time.DateOnly))
+	}
+
+	if len(c.Interest) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT\tDAYS\tINTEREST")
+
+	for _, adj := range c.Interest {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f\n", adj.Account, adj.Days, adj.Amount)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) reconReport(r recon.Report, postings []recon.Posting) error {
+	if p.json {
+		return p.writeJSON(struct {
+			recon.Report
+			Postings []recon.Posting `json:"postings,omitempty"`
+		}{r, postings})
+	}
+
+	if err := r.Write(p.w); err != nil {
+		return err
+	}
+
+	if len(postings) == 0 {
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nSIDE\tDATE\tAMOUNT\tTRANSACTION\tRESULT")
+
+	for _, ps := range postings {
+		result := "ok"
+		if ps.Error != "" {
+			result = ps.Error
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%.2f\t%s\t%s\n", ps.Side, ps.Entry.Date.Format(time.DateOnly), ps.Amount, ps.TransactionId, result)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) disbursements(results []payment.DisbursementResult) error {
+	if p.json {
+		return p.writeJSON(results)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ROW\tACCOUNT\tAMOUNT\tREFERENCE\tRESULT")
+
+	for _, r := range results {
+		result := "ok"
+		if r.Error != "" {
+			result = r.Error
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%.2f\t%s\t%s\n", r.Row, r.Account, r.Amount, r.Reference, result)
+	}
+
+	return tw.Flush()
+}
+
+func (p printer) bulkReport(r bulk.Report) error {
+	if p.json {
+		return p.writeJSON(r)
+	}
+
+	return r.Write(p.w)
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/bulk"
+	"github.com/soundrise/go-payment-system/payment/grpcapi"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"github.com/soundrise/go-payment-system/payment/swift"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// remoteBackend calls the PaymentService of a paymentd server.
+type remoteBackend struct {
+	conn    *grpc.ClientConn
+	client  pb.PaymentServiceClient
+	timeout time.Duration
+}
+
+func openRemote(addr string, timeout time.Duration) (*remoteBackend, error) {
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &remoteBackend{
+		conn:    conn,
+		client:  pb.NewPaymentServiceClient(conn),
+		timeout: timeout,
+	}
+
+	return b, nil
+}
+
+func (b *remoteBackend) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), b.timeout)
+}
+
+// remoteError turns the gRPC status into a plain error with the code.
+func remoteError(err error) error {
+	st := status.Convert(err)
+
+	return fmt.Errorf("%s: %s", st.Code(), st.Message())
+}
+
+func ref(a payment.Account) *pb.AccountRef {
+	return &pb.AccountRef{CustomerId: a.CustomerId, Number: a.Num}
+}
+
+func accountType(accType string) pb.AccountType {
+	switch accType {
+	case payment.AccountStateEmissionPrefix:
+		return pb.AccountType_ACCOUNT_TYPE_EMISSION
+	case payment.AccountStateTerminatePrefix:
+		return pb.AccountType_ACCOUNT_TYPE_TERMINATION
+	}
+
+	return pb.AccountType_ACCOUNT_TYPE_CUSTOMER
+}
+
+func (b *remoteBackend) CreateCustomer(c payment.Customer) (payment.Customer, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	if _, err := b.client.CreateCustomer(ctx, &pb.Customer{Id: c.Id, Name: c.Name}); err != nil {
+		return c, remoteError(err)
+	}
+
+	return c, nil
+}
+
+func (b *remoteBackend) CreateAccount(c payment.Customer, accType string, currencyCode string, amount float32) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	if c.Name == "" {
+		known, err := b.client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: c.Id})
+
+		switch {
+		case err == nil:
+			c.Name = known.GetName()
+		case status.Code(err) != codes.NotFound:
+			return payment.Account{}, remoteError(err)
+		}
+	}
+
+	a, err := b.client.CreateAccount(ctx, &pb.CreateAccountRequest{
+		Customer:     &pb.Customer{Id: c.Id, Name: c.Name},
+		Type:         accountType(accType),
+		CurrencyCode: currencyCode,
+		Amount:       amount,
+	})
+	if err != nil {
+		return payment.Account{}, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(a), nil
+}
+
+func (b *remoteBackend) Account(customerId string, accountNum string) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	a, err := b.client.GetAccount(ctx, &pb.AccountRef{CustomerId: customerId, Number: accountNum})
+	if err != nil {
+		return payment.Account{}, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(a), nil
+}
+
+func (b *remoteBackend) Accounts(customerId string) ([]payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.ListAccounts(ctx, &pb.ListAccountsRequest{CustomerId: customerId})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	accounts := make([]payment.Account, 0, len(res.GetAccounts()))
+	for _, a := range res.GetAccounts() {
+		accounts = append(accounts, grpcapi.FromAccount(a))
+	}
+
+	return accounts, nil
+}
+
+// QueryAccounts filters the accounts listed by paymentd, which has no query call.
+func (b *remoteBackend) QueryAccounts(q payment.AccountQuery) (payment.AccountPage, error) {
+	accounts, err := b.Accounts(q.CustomerId)
+	if err != nil {
+		return payment.AccountPage{}, err
+	}
+
+	return payment.FilterAccounts(accounts, q)
+}
+
+func (b *remoteBackend) Block(a payment.Account) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.CloseAccount(ctx, ref(a))
+	if err != nil {
+		return a, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(res), nil
+}
+
+func (b *remoteBackend) Activate(a payment.Account) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.ActivateAccount(ctx, ref(a))
+	if err != nil {
+		return a, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(res), nil
+}
+
+func (b *remoteBackend) Emit(currencyCode string, amount float32) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.Emit(ctx, &pb.EmitRequest{Amount: amount, CurrencyCode: currencyCode})
+	if err != nil {
+		return payment.Account{}, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(res), nil
+}
+
+func (b *remoteBackend) Terminate(a payment.Account, amount float32) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.Terminate(ctx, &pb.TerminateRequest{Account: ref(a), Amount: amount})
+	if err != nil {
+		return a, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(res), nil
+}
+
+func (b *remoteBackend) Disburse(a payment.Account, amount float32, reference string) (payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.Disburse(ctx, &pb.DisburseRequest{Account: ref(a), Amount: amount, Reference: reference})
+	if err != nil {
+		return a, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(res), nil
+}
+
+func (b *remoteBackend) Transfer(s payment.Account, d payment.Account, amount float32) (payment.Account, payment.Account, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.Transfer(ctx, &pb.TransferRequest{Source: ref(s), Destination: ref(d), Amount: amount})
+	if err != nil {
+		return s, d, remoteError(err)
+	}
+
+	return grpcapi.FromAccount(res.GetSource()), grpcapi.FromAccount(res.GetDestination()), nil
+}
+
+func (b *remoteBackend) MoneySupply() ([]payment.MoneySupply, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.GetMoneySupply(ctx, &pb.GetMoneySupplyRequest{})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	list := make([]payment.MoneySupply, 0, len(res.GetCurrencies()))
+
+	for _, ms := range res.GetCurrencies() {
+		list = append(list, grpcapi.FromMoneySupply(ms))
+	}
+
+	return list, nil
+}
+
+func (b *remoteBackend) Statement(a payment.Account, from time.Time, to time.Time) (payment.Statement, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	req := &pb.GetStatementRequest{Account: ref(a)}
+
+	if !from.IsZero() {
+		req.From = timestamppb.New(from)
+	}
+
+	if !to.IsZero() {
+		req.To = timestamppb.New(to)
+	}
+
+	res, err := b.client.GetStatement(ctx, req)
+	if err != nil {
+		return payment.Statement{}, remoteError(err)
+	}
+
+	st := grpcapi.FromStatement(res)
+	st.From, st.To = from, to
+
+	return st, nil
+}
+
+// Import is not served by paymentd, bulk files are imported on the local state.
+func (b *remoteBackend) Import(f *bulk.File) (bulk.Report, error) {
+	return bulk.Report{}, fmt.Errorf("import is not supported by the remote backend, use -state")
+}
+
+// CloseDay is not served by paymentd, the end of day is run on the local state.
+func (b *remoteBackend) CloseDay(interestRate float64, fee float32, feeCurrency string) (payment.DayClose, error) {
+	return payment.DayClose{}, fmt.Errorf("end of day is not supported by the remote backend, use -state")
+}
+
+// Value-dated transfers are not served by paymentd, they are posted on the local state.
+func (b *remoteBackend) Schedule(source string, dest string, amount float32, valueDate time.Time) (payment.ScheduledTransfer, error) {
+	return payment.ScheduledTransfer{}, errValueDates
+}
+
+func (b *remoteBackend) Scheduled() ([]payment.ScheduledTransfer, error) {
+	return nil, errValueDates
+}
+
+func (b *remoteBackend) CancelScheduled(id string) error {
+	return errValueDates
+}
+
+func (b *remoteBackend) BackValue(source string, dest string, amount float32, valueDate time.Time) (payment.Correction, error) {
+	return payment.Correction{}, errValueDates
+}
+
+// PostSuspense is not served by paymentd, the breaks are posted on the local state.
+func (b *remoteBackend) PostSuspense(accountNum string, amount float32, reference string) (payment.Transaction, error) {
+	return payment.Transaction{}, fmt.Errorf("suspense postings are not supported by the remote backend, use -state")
+}
+
+// ReceiveMT103 is not served by paymentd, the MT103 is booked on the local state.
+func (b *remoteBackend) ReceiveMT103(mt swift.MT103, settlement string) (payment.Account, error) {
+	return payment.Account{}, fmt.Errorf("MT103 settlement is not supported by the remote backend, use -state")
+}
+
+// Check is not served by paymentd, the self-audit runs on the local state.
+func (b *remoteBackend) Check() (payment.CheckReport, error) {
+	return payment.CheckReport{}, fmt.Errorf("self-audit is not supported by the remote backend, use -state")
+}
+
+var errValueDates = fmt.Errorf("value-dated transfers are not supported by the remote backend, use -state")
+
+func (b *remoteBackend) Dump() ([]byte, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	res, err := b.client.DumpStore(ctx, &pb.DumpStoreRequest{})
+	if err != nil {
+		return nil, remoteError(err)
+	}
+
+	return res.GetData(), nil
+}
+
+func (b *remoteBackend) Restore(data []byte) error {
+	ctx, cancel := b.ctx()
+	defer cancel()
+
+	if _, err := b.client.RestoreStore(ctx, &pb.RestoreStoreRequest{Data: data}); err != nil {
+		return remoteError(err)
+	}
+
+	return nil
+}
+
+// Save does nothing, paymentd keeps its state itself.
+func (b *remoteBackend) Save() error {
+	return nil
+}
+
+func (b *remoteBackend) Close() error {
+	return b.conn.Close()
+}
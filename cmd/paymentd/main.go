@@ -0,0 +1,162 @@
+// Command paymentd serves the payment system over gRPC
+// and optionally the account queries over HTTP.
+// The state is loaded from the state file on start and written back on shutdown.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/fs"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/grpcapi"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"github.com/soundrise/go-payment-system/payment/httpapi"
+	"github.com/soundrise/go-payment-system/payment/webhook"
+	"google.golang.org/grpc"
+)
+
+func main() {
+	addr := flag.String("addr", ":50051", "gRPC listen address")
+	state := flag.String("state", "", "state `file`, kept in memory only when empty")
+	metricsAddr := flag.String("metrics", "", "listen address of the metrics endpoint, disabled when empty")
+	httpAddr := flag.String("http", "", "listen address of the HTTP account queries, disabled when empty")
+	delay := flag.Duration("processing-delay", 0, "simulated processing time of account creation")
+	webhooks := flag.String("webhooks", "", "JSON `file` with the webhook endpoints of customers, disabled when empty")
+	flag.Parse()
+
+	metrics := payment.NewMetrics()
+
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(*delay),
+		payment.WithMetrics(metrics),
+	)
+
+	if *state != "" {
+		data, err := os.ReadFile(*state)
+
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			log.Fatal(err)
+		default:
+			if err := ps.LoadSnapshot(data); err != nil {
+				log.Fatalf("cannot load state file %s: %v", *state, err)
+			}
+		}
+	}
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(payment.MetricsPath, metrics.Handler())
+
+		go func() {
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil { //nolint:gosec
+				slog.Error("metrics endpoint stopped", "error", err)
+			}
+		}()
+	}
+
+	if *httpAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(*httpAddr, httpapi.NewHandler(ps)); err != nil { //nolint:gosec
+				slog.Error("HTTP endpoint stopped", "error", err)
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go ps.RunExpiry(ctx, payment.DefaultExpiryInterval)
+
+	if *webhooks != "" {
+		wh, err := loadWebhooks(*webhooks)
+		if err != nil {
+			log.Fatalf("cannot load webhooks file %s: %v", *webhooks, err)
+		}
+
+		ps.Subscribe("webhooks", wh)
+
+		go ps.RunDispatcher(ctx, payment.DefaultDispatchRetry)
+		go wh.Run(ctx, webhookInterval)
+	}
+
+	lis, err := net.Listen("tcp", *addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gs := grpc.NewServer()
+	pb.RegisterPaymentServiceServer(gs, grpcapi.NewServer(ps))
+
+	go func() {
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		<-sig
+
+		cancel()
+		gs.GracefulStop()
+	}()
+
+	slog.Info("payment server started", "addr", lis.Addr().String())
+
+	if err := gs.Serve(lis); err != nil {
+		log.Fatal(err)
+	}
+
+	if *state == "" {
+		return
+	}
+
+	_ = ps.Lock()
+	data, err := ps.Snapshot()
+	_ = ps.Unlock()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.WriteFile(*state, data, 0o600); err != nil {
+		log.Fatal(err)
+	}
+
+	slog.Info("payment server stopped", "state", *state)
+}
+
+// webhookInterval is how often due webhook deliveries are sent.
+const webhookInterval = time.Second
+
+// loadWebhooks registers the endpoints listed in the file.
+func loadWebhooks(path string) (*webhook.Dispatcher, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []webhook.Endpoint
+
+	if err := json.Unmarshal(data, &endpoints); err != nil {
+		return nil, err
+	}
+
+	wh := webhook.NewDispatcher()
+
+	for _, ep := range endpoints {
+		if _, err := wh.Register(ep.CustomerId, ep.URL, ep.Secret, ep.Events...); err != nil {
+			return nil, err
+		}
+	}
+
+	return wh, nil
+}
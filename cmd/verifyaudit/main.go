@@ -0,0 +1,30 @@
+// Command verifyaudit checks the hash chain of an audit log file
+// written by the payment system.
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+func main() {
+	if len(os.Args) != 2 {
+		fmt.Fprintln(os.Stderr, "usage: verifyaudit <audit.log>")
+		os.Exit(2)
+	}
+
+	f, err := os.Open(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := payment.VerifyAuditLog(f); err != nil {
+		log.Fatalf("audit log %s is not valid: %v", os.Args[1], err)
+	}
+
+	log.Printf("audit log %s is valid\n", os.Args[1])
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/scenario"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDemoScenario(t *testing.T) {
+	sc, err := scenario.Parse(demoScenario)
+	require.NoError(t, err)
+
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))),
+	)
+
+	report := scenario.NewRunner(ps).Run(sc)
+
+	for _, res := range report.Results {
+		assert.True(t, res.Passed, "step %d %s: %s", res.Step, res.Name, res.Error)
+	}
+}
@@ -1,5 +1,7 @@
 package payment
 
+import "time"
+
 const (
 	BYN = "BYN"
 	RU  = "RU"
@@ -13,12 +15,16 @@ const (
 )
 
 type Account struct {
-	CustomerId   string  `json:"customer_id"`
-	Num          string  `json:"num"`
-	CurrencyCode string  `json:"currency_code"`
-	Status       string  `json:"status"`
-	Balance      float32 `json:"balance"`
-	Description  string  `json:"desc"`
+	CustomerId   string    `json:"customer_id"`
+	Num          string    `json:"num"`
+	CurrencyCode string    `json:"currency_code"`
+	Status       string    `json:"status"`
+	Balance      float32   `json:"balance"`
+	Reserved     float32   `json:"reserved"`
+	Description  string    `json:"desc"`
+	CreatedAt    time.Time `json:"created_at"`
+	// Version is incremented by every change of the account.
+	Version int `json:"version"`
 }
 
 func NewAccount(cid string, currencyCode string, accountNum string, amount float32) Account {
@@ -0,0 +1,220 @@
+package payment
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	OpCreateAccount    = "create_account"
+	OpCreateCustomer   = "create_customer"
+	OpCloseAccount     = "close_account"
+	OpActivateAccount  = "activate_account"
+	OpEmit             = "emit"
+	OpTerminate        = "terminate"
+	OpTransfer         = "transfer"
+	OpApproveTransfer  = "approve_transfer"
+	OpRejectTransfer   = "reject_transfer"
+	OpClearReview      = "clear_review"
+	OpDeclineReview    = "decline_review"
+	OpRequestEmission  = "request_emission"
+	OpApproveEmission  = "approve_emission"
+	OpRejectEmission   = "reject_emission"
+	OpDisburse         = "disburse"
+	OpCloseDay         = "close_day"
+	OpScheduleTransfer = "schedule_transfer"
+	OpBackValue        = "back_value"
+	OpPostSuspense     = "post_suspense"
+)
+
+const (
+	AuditResultOk   = "ok"
+	DefaultActor    = "system"
+	auditGenesisKey = ""
+)
+
+// auditInput is the input of an operation recorded in the audit log.
+type auditInput map[string]any
+
+// AuditEntry is one record of the audit trail. Each entry carries the hash
+// of the previous one, so editing or removing an entry breaks the chain.
+type AuditEntry struct {
+	Seq      int             `json:"seq"`
+	Time     time.Time       `json:"time"`
+	Actor    string          `json:"actor"`
+	Op       string          `json:"op"`
+	Input    json.RawMessage `json:"input"`
+	Result   string          `json:"result"`
+	Error    string          `json:"error,omitempty"`
+	PrevHash string          `json:"prev_hash"`
+	Hash     string          `json:"hash"`
+}
+
+// AuditLog keeps the hash chained audit trail in memory
+// and writes every entry as a JSON line to the optional writer.
+type AuditLog struct {
+	entries []AuditEntry
+	w       io.Writer
+}
+
+func NewAuditLog(w io.Writer) *AuditLog {
+	al := &AuditLog{
+		w: w,
+	}
+
+	return al
+}
+
+// WithAuditLog replaces the default in-memory audit log.
+func WithAuditLog(al *AuditLog) Option {
+	return func(ps *PaymentSystem) {
+		ps.auditLog = al
+	}
+}
+
+// SetActor sets who is performing the following operations.
+func (ps *PaymentSystem) SetActor(actor string) {
+	ps.actor = actor
+}
+
+// AuditLog returns the audit log of the store.
+func (ps *PaymentSystem) AuditLog() *AuditLog {
+	return ps.auditLog
+}
+
+// audit appends the outcome of the operation to the audit log.
+func (ps *PaymentSystem) audit(op string, actor string, input any, result string, err error) {
+	if actor == "" {
+		actor = ps.actor
+	}
+
+	data, jerr := json.Marshal(input)
+	if jerr != nil {
+		data, _ = json.Marshal(fmt.Sprint(input))
+	}
+
+	e := AuditEntry{
+		Time:   ps.now().UTC(),
+		Actor:  actor,
+		Op:     op,
+		Input:  data,
+		Result: result,
+	}
+
+	if err != nil {
+		e.Result = "error"
+		e.Error = err.Error()
+	} else if e.Result == "" {
+		e.Result = AuditResultOk
+	}
+
+	if err := ps.auditLog.append(e); err != nil {
+		ps.logger.Error("error while writing audit entry", "op", op, "error", err)
+	}
+}
+
+func (al *AuditLog) append(e AuditEntry) error {
+	e.Seq = len(al.entries) + 1
+	e.PrevHash = auditGenesisKey
+
+	if len(al.entries) > 0 {
+		e.PrevHash = al.entries[len(al.entries)-1].Hash
+	}
+
+	e.Hash = e.hash()
+	al.entries = append(al.entries, e)
+
+	if al.w == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = al.w.Write(append(data, '\n'))
+
+	return err
+}
+
+// Entries returns a copy of the audit trail.
+func (al *AuditLog) Entries() []AuditEntry {
+	res := make([]AuditEntry, len(al.entries))
+	copy(res, al.entries)
+
+	return res
+}
+
+// Verify checks the hash chain of the entries kept in memory.
+func (al *AuditLog) Verify() error {
+	return verifyChain(al.entries)
+}
+
+// hash is the SHA-256 of the entry encoded to JSON without its own hash.
+func (e AuditEntry) hash() string {
+	e.Hash = ""
+
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+This func reads an audit log written as JSON lines and checks
+that no entry was changed, removed or inserted.
+*/
+func VerifyAuditLog(r io.Reader) error {
+	var entries []AuditEntry
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for sc.Scan() {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+
+		var e AuditEntry
+
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return fmt.Errorf("%w: entry %d cannot be parsed: %v", ErrAuditChainBroken, len(entries)+1, err)
+		}
+
+		entries = append(entries, e)
+	}
+
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	return verifyChain(entries)
+}
+
+func verifyChain(entries []AuditEntry) error {
+	prev := auditGenesisKey
+
+	for i, e := range entries {
+		if e.Seq != i+1 {
+			return fmt.Errorf("%w: entry %d has sequence number %d", ErrAuditChainBroken, i+1, e.Seq)
+		}
+
+		if e.PrevHash != prev {
+			return fmt.Errorf("%w: entry %d does not follow the previous entry", ErrAuditChainBroken, e.Seq)
+		}
+
+		if e.hash() != e.Hash {
+			return fmt.Errorf("%w: entry %d was modified", ErrAuditChainBroken, e.Seq)
+		}
+
+		prev = e.Hash
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package payment_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_AuditLog(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	var buf bytes.Buffer
+
+	ps := payment.NewPaymentSystem(
+		payment.WithAuditLog(payment.NewAuditLog(&buf)),
+		payment.WithClock(func() time.Time { return now }),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	ps.SetActor("operator")
+	require.NoError(t, ps.Transfer(s, d, 100))
+	require.Error(t, ps.Emit(100))
+	require.NoError(t, ps.CloseAccount(d))
+
+	entries := ps.AuditLog().Entries()
+	require.Len(t, entries, 3)
+
+	assert.Equal(t, payment.OpTransfer, entries[0].Op)
+	assert.Equal(t, "operator", entries[0].Actor)
+	assert.Equal(t, payment.AuditResultOk, entries[0].Result)
+	assert.JSONEq(t, `{"source": "`+sourceNum+`", "destination": "`+destNum+`", "amount": 100}`, string(entries[0].Input))
+	assert.Equal(t, payment.OpEmit, entries[1].Op)
+	assert.NotEmpty(t, entries[1].Error)
+	assert.Equal(t, entries[1].Hash, entries[2].PrevHash)
+
+	assert.NoError(t, ps.AuditLog().Verify())
+	assert.NoError(t, payment.VerifyAuditLog(bytes.NewReader(buf.Bytes())))
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	testCases := []struct {
+		desc string
+		log  string
+	}{
+		{
+			desc: "modified entry is detected",
+			log:  strings.Replace(buf.String(), `"amount":100`, `"amount":10`, 1),
+		},
+		{
+			desc: "removed entry is detected",
+			log:  lines[0] + "\n" + lines[2] + "\n",
+		},
+		{
+			desc: "reordered entries are detected",
+			log:  lines[1] + "\n" + lines[0] + "\n" + lines[2] + "\n",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.ErrorIs(t, payment.VerifyAuditLog(strings.NewReader(tt.log)), payment.ErrAuditChainBroken)
+		})
+	}
+}
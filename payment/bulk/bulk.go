@@ -0,0 +1,195 @@
+// Package bulk imports files of transfers in CSV or ISO 20022 pain.001 format.
+package bulk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/iso20022"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatPain001 = "pain001"
+)
+
+// csvColumns is the header of the CSV file.
+var csvColumns = []string{"source", "destination", "currency", "amount", "reference"}
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// File is a parsed bulk file.
+type File struct {
+	// MessageId is the MsgId of pain.001, empty for CSV.
+	MessageId string `json:"message_id,omitempty"`
+	Format    string `json:"format"`
+	Lines     []Line `json:"lines"`
+}
+
+// Line is one transfer of the file.
+type Line struct {
+	// Row is the line of the CSV file or the number of the transfer in pain.001.
+	Row         int     `json:"row"`
+	PaymentInfo string  `json:"payment_info,omitempty"`
+	Reference   string  `json:"reference"`
+	Source      string  `json:"source"`
+	Destination string  `json:"destination"`
+	Currency    string  `json:"currency"`
+	Amount      float32 `json:"amount"`
+	// Error is set when the line cannot be parsed, e.g. the amount is not a number.
+	Error string `json:"error,omitempty"`
+}
+
+/*
+This func reads the file in the format, csv or pain001.
+An empty format is detected from the content: XML is pain.001.
+*/
+func Parse(r io.Reader, format string) (*File, error) {
+	br := bufio.NewReader(r)
+
+	if format == "" {
+		format = FormatCSV
+
+		if head, _ := br.Peek(64); bytes.HasPrefix(bytes.TrimSpace(head), []byte("<")) {
+			format = FormatPain001
+		}
+	}
+
+	switch format {
+	case FormatCSV:
+		return ParseCSV(br)
+	case FormatPain001:
+		return ParsePain001(br)
+	}
+
+	return nil, fmt.Errorf("unknown bulk format %q", format)
+}
+
+/*
+This func reads transfers from CSV with the header
+source,destination,currency,amount,reference.
+A line with an invalid amount is kept with its error,
+so it is reported with the other lines.
+*/
+func ParseCSV(r io.Reader) (*File, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvColumns)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("bulk file is empty")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, col := range csvColumns {
+		if strings.TrimSpace(header[i]) != col {
+			return nil, fmt.Errorf("bulk file: column %d must be %s, got %q", i+1, col, header[i])
+		}
+	}
+
+	f := &File{Format: FormatCSV}
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return f, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row, _ := cr.FieldPos(0)
+
+		l := Line{
+			Row:         row,
+			Source:      strings.TrimSpace(rec[0]),
+			Destination: strings.TrimSpace(rec[1]),
+			Currency:    strings.TrimSpace(rec[2]),
+			Reference:   strings.TrimSpace(rec[4]),
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(rec[3]), 32)
+		if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+			l.Error = fmt.Sprintf("invalid amount %q", rec[3])
+		}
+
+		l.Amount = float32(amount)
+		f.Lines = append(f.Lines, l)
+	}
+}
+
+/*
+This func reads transfers from the pain.001 credit transfer initiation.
+The debtor account of the payment information is the source of its transfers.
+*/
+func ParsePain001(r io.Reader) (*File, error) {
+	doc, err := iso20022.ParsePain001(r)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &File{MessageId: doc.GrpHdr.MsgId, Format: FormatPain001}
+
+	for _, pi := range doc.PmtInf {
+		for _, tx := range pi.CdtTrfTxInf {
+			l := Line{
+				Row:         len(f.Lines) + 1,
+				PaymentInfo: pi.PmtInfId,
+				Reference:   tx.EndToEndId,
+				Source:      strings.TrimSpace(pi.DbtrAcct.IBAN),
+				Destination: strings.TrimSpace(tx.CdtrAcct.IBAN),
+				Currency:    tx.Amount.Ccy,
+			}
+
+			amount, err := tx.Amount.Float()
+			if err != nil {
+				l.Error = err.Error()
+			}
+
+			l.Amount = amount
+			f.Lines = append(f.Lines, l)
+		}
+	}
+
+	return f, nil
+}
+
+/*
+This func checks the line before it is executed:
+both account numbers, the currency code and the amount.
+It returns the pain.002 reason code with the error.
+*/
+func Validate(l Line) (string, error) {
+	switch {
+	case l.Error != "":
+		return iso20022.ReasonNarrative, errors.New(l.Error)
+	case payment.VerifyAccountNumber(l.Source) != nil:
+		return iso20022.ReasonIncorrectAccount, fmt.Errorf("not valid source account number %q", l.Source)
+	case payment.VerifyAccountNumber(l.Destination) != nil:
+		return iso20022.ReasonIncorrectAccount, fmt.Errorf("not valid destination account number %q", l.Destination)
+	case l.Source == l.Destination:
+		return iso20022.ReasonIncorrectAccount, fmt.Errorf("source and destination are the same account")
+	case !currencyCode.MatchString(l.Currency):
+		return iso20022.ReasonNotAllowedCurrency, fmt.Errorf("not valid currency %q", l.Currency)
+	case math.IsInf(float64(l.Amount), 0):
+		return iso20022.ReasonNotAllowedAmount, fmt.Errorf("amount must be finite")
+	case !(l.Amount > 0):
+		return iso20022.ReasonZeroAmount, fmt.Errorf("amount must be positive")
+	}
+
+	return "", nil
+}
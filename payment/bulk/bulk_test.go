@@ -0,0 +1,175 @@
+package bulk_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/bulk"
+	"github.com/soundrise/go-payment-system/payment/iso20022"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sourceNum = "BY11ABCD00000000000000000001"
+	destNum   = "BY22ABCD00000000000000000002"
+	usdNum    = "BY33ABCD00000000000000000003"
+)
+
+const store = `{
+	"1": {"K1": {"customer_id": "1", "num": "` + sourceNum + `", "currency_code": "BYN", "status": "active", "balance": 1000}},
+	"2": {"K2": {"customer_id": "2", "num": "` + destNum + `", "currency_code": "BYN", "status": "active", "balance": 0}},
+	"3": {"K3": {"customer_id": "3", "num": "` + usdNum + `", "currency_code": "USD", "status": "active", "balance": 0}}
+}`
+
+func newImporter(t *testing.T) (*payment.PaymentSystem, *bulk.Importer) {
+	t.Helper()
+
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithLogger(discard))
+	require.NoError(t, ps.Restore([]byte(store)))
+
+	return ps, bulk.NewImporter(ps, payment.WithControllerLogger(discard))
+}
+
+func TestImporter_RunCSV(t *testing.T) {
+	ps, im := newImporter(t)
+
+	f, err := bulk.Parse(strings.NewReader(
+		"source,destination,currency,amount,reference\n"+
+			sourceNum+","+destNum+",BYN,100,R1\n"+
+			sourceNum+",BY00,BYN,10,R2\n"+
+			sourceNum+","+usdNum+",BYN,10,R3\n"+
+			sourceNum+","+destNum+",BYN,0,R4\n"+
+			sourceNum+","+destNum+",BYN,ten,R5\n"+
+			sourceNum+","+destNum+",BYN,5000,R6\n"+
+			sourceNum+","+destNum+",BYN,100,R1\n"+
+			sourceNum+","+destNum+",byn,1,R7\n"+
+			sourceNum+","+destNum+",BYN,NaN,R8\n"+
+			sourceNum+","+destNum+",BYN,-Inf,R9\n",
+	), "")
+	require.NoError(t, err)
+	assert.Equal(t, bulk.FormatCSV, f.Format)
+	require.Len(t, f.Lines, 10)
+
+	report := im.Run(f)
+	assert.Equal(t, 1, report.Accepted)
+	assert.Equal(t, 9, report.Rejected)
+
+	testCases := []struct {
+		desc   string
+		status string
+		reason string
+	}{
+		{desc: "accepted", status: iso20022.StatusAccepted},
+		{desc: "invalid account number", status: iso20022.StatusRejected, reason: iso20022.ReasonIncorrectAccount},
+		{desc: "currency of the account", status: iso20022.StatusRejected, reason: iso20022.ReasonNotAllowedCurrency},
+		{desc: "zero amount", status: iso20022.StatusRejected, reason: iso20022.ReasonZeroAmount},
+		{desc: "amount is not a number", status: iso20022.StatusRejected, reason: iso20022.ReasonNarrative},
+		{desc: "insufficient funds", status: iso20022.StatusRejected, reason: iso20022.ReasonInsufficientFunds},
+		{desc: "repeated reference", status: iso20022.StatusRejected, reason: iso20022.ReasonDuplication},
+		{desc: "invalid currency", status: iso20022.StatusRejected, reason: iso20022.ReasonNotAllowedCurrency},
+		{desc: "amount is NaN", status: iso20022.StatusRejected, reason: iso20022.ReasonNarrative},
+		{desc: "amount is infinite", status: iso20022.StatusRejected, reason: iso20022.ReasonNarrative},
+	}
+
+	for i, tt := range testCases {
+		i, tt := i, tt
+		t.Run(tt.desc, func(t *testing.T) {
+			res := report.Results[i]
+			assert.Equal(t, i+2, res.Row, "row is the line of the file")
+			assert.Equal(t, tt.status, res.Status)
+			assert.Equal(t, tt.reason, res.Reason, res.Message)
+		})
+	}
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(100), d.Balance)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "1 accepted, 9 rejected")
+}
+
+func TestImporter_RunPain001(t *testing.T) {
+	_, im := newImporter(t)
+
+	in, err := os.Open("testdata/payroll.xml")
+	require.NoError(t, err)
+	defer in.Close()
+
+	f, err := bulk.Parse(in, "")
+	require.NoError(t, err)
+	assert.Equal(t, bulk.FormatPain001, f.Format)
+	assert.Equal(t, "PAYROLL-2024-01", f.MessageId)
+	require.Len(t, f.Lines, 3)
+	assert.Equal(t, bulk.Line{Row: 1, PaymentInfo: "SALARY", Reference: "SAL-1", Source: sourceNum, Destination: destNum, Currency: payment.BYN, Amount: 300}, f.Lines[0])
+
+	report := im.Run(f)
+	assert.Equal(t, 1, report.Accepted)
+
+	var out bytes.Buffer
+	require.NoError(t, report.Pain002("STS-1", time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)).Write(&out))
+
+	xml := out.String()
+	assert.Contains(t, xml, `<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.002.001.03">`)
+	assert.Contains(t, xml, "<OrgnlMsgId>PAYROLL-2024-01</OrgnlMsgId>")
+	assert.Contains(t, xml, "<GrpSts>PART</GrpSts>")
+	assert.Contains(t, xml, "<OrgnlEndToEndId>SAL-2</OrgnlEndToEndId>")
+	assert.Contains(t, xml, "<Cd>AC01</Cd>")
+	assert.Contains(t, xml, "<Cd>AM04</Cd>")
+}
+
+func TestValidate_Amount(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		amount float32
+		reason string
+	}{
+		{desc: "positive", amount: 10},
+		{desc: "zero", amount: 0, reason: iso20022.ReasonZeroAmount},
+		{desc: "negative", amount: -10, reason: iso20022.ReasonZeroAmount},
+		{desc: "NaN", amount: float32(math.NaN()), reason: iso20022.ReasonZeroAmount},
+		{desc: "infinite", amount: float32(math.Inf(1)), reason: iso20022.ReasonNotAllowedAmount},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			reason, err := bulk.Validate(bulk.Line{Source: sourceNum, Destination: destNum, Currency: payment.BYN, Amount: tt.amount})
+			assert.Equal(t, tt.reason, reason)
+			assert.Equal(t, tt.reason != "", err != nil)
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		in     string
+		format string
+	}{
+		{desc: "empty csv", in: ""},
+		{desc: "wrong header", in: "from,to,currency,amount,reference\n"},
+		{desc: "wrong number of columns", in: "source,destination,currency,amount,reference\na,b\n"},
+		{desc: "broken xml", in: "<Document><CstmrCdtTrfInitn>"},
+		{desc: "xml without payments", in: "<Document><CstmrCdtTrfInitn><GrpHdr><MsgId>M</MsgId></GrpHdr></CstmrCdtTrfInitn></Document>"},
+		{desc: "unknown format", in: "", format: "mt101"},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := bulk.Parse(strings.NewReader(tt.in), tt.format)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package bulk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/iso20022"
+)
+
+// Result is the outcome of one line of the file.
+type Result struct {
+	Line
+	// Status is ACCP or RJCT, Reason is the pain.002 reason code of a rejection.
+	Status   string        `json:"status"`
+	Reason   string        `json:"reason,omitempty"`
+	Message  string        `json:"message,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report is the result of every line of the file in the order of the file.
+type Report struct {
+	MessageId string   `json:"message_id,omitempty"`
+	Format    string   `json:"format"`
+	Accepted  int      `json:"accepted"`
+	Rejected  int      `json:"rejected"`
+	Results   []Result `json:"results"`
+}
+
+// Importer executes the lines of bulk files through a PaymentController.
+type Importer struct {
+	ps payment.Store
+	pc payment.PaymentController
+}
+
+func NewImporter(ps payment.Store, opts ...payment.ControllerOption) *Importer {
+	im := &Importer{
+		ps: ps,
+		pc: payment.NewPaymentController(ps, opts...),
+	}
+
+	return im
+}
+
+/*
+This func validates every line of the file and executes the valid ones
+as transfers. The lines are added to the controller in batches that fit
+its queue. A failed line does not stop the others.
+*/
+func (im *Importer) Run(f *File) Report {
+	report := Report{
+		MessageId: f.MessageId,
+		Format:    f.Format,
+		Results:   make([]Result, len(f.Lines)),
+	}
+
+	im.ps.Lock()
+	accounts := make(map[string]payment.Account)
+
+	for _, a := range im.ps.Accounts("") {
+		accounts[a.Num] = a
+	}
+	im.ps.Unlock()
+
+	seen := make(map[string]bool)
+
+	for from := 0; from < len(f.Lines); from += payment.ControllerQueueSize {
+		to := min(from+payment.ControllerQueueSize, len(f.Lines))
+
+		for i := from; i < to; i++ {
+			i := i
+
+			im.pc.Add(func() error {
+				start := time.Now()
+				l := f.Lines[i]
+
+				reason, err := im.execute(l, accounts, seen)
+
+				res := Result{Line: l, Status: iso20022.StatusAccepted, Duration: time.Since(start)}
+				if err != nil {
+					res.Status = iso20022.StatusRejected
+					res.Reason = reason
+					res.Message = strings.TrimSpace(err.Error())
+				}
+
+				report.Results[i] = res
+
+				return err
+			})
+		}
+
+		done := make(chan bool)
+
+		im.pc.Run(done)
+		<-done
+	}
+
+	for _, res := range report.Results {
+		if res.Status == iso20022.StatusAccepted {
+			report.Accepted++
+		} else {
+			report.Rejected++
+		}
+	}
+
+	return report
+}
+
+func (im *Importer) execute(l Line, accounts map[string]payment.Account, seen map[string]bool) (string, error) {
+	if reason, err := Validate(l); err != nil {
+		return reason, err
+	}
+
+	if l.Reference != "" {
+		if seen[l.Reference] {
+			return iso20022.ReasonDuplication, fmt.Errorf("reference %s is repeated in the file", l.Reference)
+		}
+
+		seen[l.Reference] = true
+	}
+
+	for _, num := range []string{l.Source, l.Destination} {
+		a, ok := accounts[num]
+
+		switch {
+		case !ok:
+			return iso20022.ReasonIncorrectAccount, fmt.Errorf("account %s not found", num)
+		case a.CurrencyCode != l.Currency:
+			return iso20022.ReasonNotAllowedCurrency, fmt.Errorf("account %s is in %s, the line is in %s", num, a.CurrencyCode, l.Currency)
+		}
+	}
+
+	if err := im.ps.TransferByNumber(l.Source, l.Destination, l.Amount); err != nil {
+		return reasonOf(err), err
+	}
+
+	return "", nil
+}
+
+// reasonOf returns the pain.002 reason code of the error of the store.
+func reasonOf(err error) string {
+	switch {
+	case errors.Is(err, payment.ErrInsufficientFunds):
+		return iso20022.ReasonInsufficientFunds
+	case errors.Is(err, payment.ErrLimitExceeded):
+		return iso20022.ReasonNotAllowedAmount
+	case strings.Contains(err.Error(), "not valid or blocked"):
+		return iso20022.ReasonClosedAccount
+	}
+
+	return iso20022.ReasonNarrative
+}
+
+/*
+This func returns the pain.002 status report of the file.
+Lines of a CSV file are reported under the payment information "CSV".
+*/
+func (r Report) Pain002(msgId string, created time.Time) *iso20022.Pain002 {
+	p := iso20022.NewPain002(msgId, created, r.MessageId)
+
+	for _, res := range r.Results {
+		pmtInf := res.PaymentInfo
+		if pmtInf == "" {
+			pmtInf = "CSV"
+		}
+
+		ts := iso20022.TransactionStatus{
+			StsId:           fmt.Sprintf("%s-%d", msgId, res.Row),
+			OrgnlEndToEndId: res.Reference,
+			TxSts:           res.Status,
+		}
+
+		if res.Status == iso20022.StatusRejected {
+			ts.StsRsnInf = &iso20022.StatusReason{Cd: res.Reason, AddtlInf: res.Message}
+		}
+
+		p.Add(pmtInf, ts)
+	}
+
+	return p
+}
+
+// Write prints the result of every line and the totals.
+func (r Report) Write(w io.Writer) error {
+	for _, res := range r.Results {
+		line := fmt.Sprintf("%4d  %-4s  %s -> %s  %.2f %s  %s", res.Row, res.Status, res.Source, res.Destination, res.Amount, res.Currency, res.Reference)
+		if res.Status == iso20022.StatusRejected {
+			line += fmt.Sprintf("  %s: %s", res.Reason, res.Message)
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d accepted, %d rejected\n", r.Accepted, r.Rejected)
+
+	return err
+}
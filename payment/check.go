@@ -0,0 +1,199 @@
+package payment
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Invariants verified by Check.
+const (
+	CheckConservation    = "conservation"
+	CheckUniqueNumbers   = "unique_numbers"
+	CheckAccountNumbers  = "account_numbers"
+	CheckNegativeBalance = "negative_balance"
+	CheckCustomerKeys    = "customer_keys"
+	CheckSpecialAccounts = "special_accounts"
+)
+
+// Checks lists the invariants in the order Check verifies them.
+var Checks = []string{
+	CheckConservation,
+	CheckUniqueNumbers,
+	CheckAccountNumbers,
+	CheckNegativeBalance,
+	CheckCustomerKeys,
+	CheckSpecialAccounts,
+}
+
+// Violation is a broken invariant of the store.
+type Violation struct {
+	Check        string `json:"check"`
+	CustomerId   string `json:"customer_id,omitempty"`
+	Key          string `json:"key,omitempty"`
+	Account      string `json:"account,omitempty"`
+	CurrencyCode string `json:"currency_code,omitempty"`
+	Detail       string `json:"detail"`
+}
+
+// CheckReport is the result of the self-audit of the store.
+type CheckReport struct {
+	CheckedAt  time.Time      `json:"checked_at"`
+	Customers  int            `json:"customers"`
+	Accounts   int            `json:"accounts"`
+	Supply     []MoneySupply  `json:"supply"`
+	Violations []Violation    `json:"violations"`
+	Counts     map[string]int `json:"counts"`
+}
+
+// OK tells whether every invariant holds.
+func (r CheckReport) OK() bool {
+	return len(r.Violations) == 0
+}
+
+/*
+This func verifies the invariants of the store and reports every violation:
+the money supply of each currency equals the money in circulation, account
+numbers are unique and valid, no account has a negative balance or reserves
+more than its balance, accounts are stored under the id of their customer,
+and the emission and termination accounts of every currency exist.
+The internal accounts of the bank, e.g. interest or suspense, are
+the only accounts allowed to run an overdraft. The caller holds the lock.
+*/
+func (ps *PaymentSystem) Check() CheckReport {
+	r := CheckReport{
+		CheckedAt: ps.now(),
+		Supply:    ps.MoneySupply(),
+		Counts:    make(map[string]int, len(Checks)),
+	}
+
+	add := func(v Violation) {
+		r.Violations = append(r.Violations, v)
+		r.Counts[v.Check]++
+	}
+
+	for _, ms := range r.Supply {
+		if !ms.Balanced {
+			add(Violation{
+				Check:        CheckConservation,
+				CurrencyCode: ms.CurrencyCode,
+				Detail:       fmt.Sprintf("circulation %.2f differs from supply %.2f by %.2f", ms.Circulation, ms.Supply, ms.Difference),
+			})
+		}
+	}
+
+	owners := make(map[string]string)
+	currencies := make(map[string]bool)
+
+	for _, cid := range sortedKeys(ps.store) {
+		list := ps.store[cid]
+
+		keys := make([]string, 0, len(list))
+		for k := range list {
+			keys = append(keys, k)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			a := list[key]
+			r.Accounts++
+			currencies[a.CurrencyCode] = true
+
+			v := Violation{CustomerId: cid, Key: key, Account: a.Num, CurrencyCode: a.CurrencyCode}
+
+			if first, ok := owners[a.Num]; ok {
+				v.Check, v.Detail = CheckUniqueNumbers, "number is already used by an account of customer "+first
+				add(v)
+			} else {
+				owners[a.Num] = cid
+			}
+
+			if err := ps.checkNumber(cid, key, a); err != nil {
+				v.Check, v.Detail = CheckAccountNumbers, err.Error()
+				add(v)
+			}
+
+			if a.Balance < 0 && cid != SpecialCustomerId {
+				v.Check, v.Detail = CheckNegativeBalance, fmt.Sprintf("balance %.2f without an overdraft", a.Balance)
+				add(v)
+			}
+
+			if a.Reserved > 0 && a.Reserved > a.Balance {
+				v.Check, v.Detail = CheckNegativeBalance, fmt.Sprintf("reserved %.2f exceeds balance %.2f", a.Reserved, a.Balance)
+				add(v)
+			}
+
+			if a.CustomerId != cid {
+				v.Check, v.Detail = CheckCustomerKeys, fmt.Sprintf("account of customer %q is stored under %q", a.CustomerId, cid)
+				add(v)
+			}
+		}
+	}
+
+	for _, id := range sortedKeys(ps.customers) {
+		if c := ps.customers[id]; c.Id != id {
+			add(Violation{Check: CheckCustomerKeys, CustomerId: id, Detail: fmt.Sprintf("customer %q is registered under %q", c.Id, id)})
+		}
+	}
+
+	r.Customers = len(ps.store)
+
+	currencies[DefaultCurrency] = true
+
+	for _, cur := range sortedKeys(currencies) {
+		for _, prefix := range []string{AccountStateEmissionPrefix, AccountStateTerminatePrefix} {
+			if _, ok := ps.store[SpecialCustomerId][specialKey(prefix, cur)]; !ok {
+				add(Violation{
+					Check:        CheckSpecialAccounts,
+					CustomerId:   SpecialCustomerId,
+					Key:          specialKey(prefix, cur),
+					CurrencyCode: cur,
+					Detail:       "missing special account " + SpecialAccountNumber(prefix, cur),
+				})
+			}
+		}
+	}
+
+	return r
+}
+
+/*
+This func validates the number of the account stored under the key.
+Customer accounts must be IBANs, the accounts of the bank and the vostro
+accounts of other banks are stored under the key and number derived
+from their prefix and currency.
+*/
+func (ps *PaymentSystem) checkNumber(cid string, key string, a Account) error {
+	if key == VostroPrefix+a.CurrencyCode {
+		if want := ps.VostroAccountNumber(cid, a.CurrencyCode); a.Num != want {
+			return fmt.Errorf("vostro account %s must be numbered %s", a.Num, want)
+		}
+
+		return nil
+	}
+
+	if cid != SpecialCustomerId {
+		if err := VerifyAccountNumber(a.Num); err != nil {
+			return fmt.Errorf("%s is not a valid IBAN", a.Num)
+		}
+
+		return nil
+	}
+
+	if len(a.Num) < 2 {
+		return fmt.Errorf("%q is not a bank account number", a.Num)
+	}
+
+	prefix := a.Num[:2]
+
+	if want := SpecialAccountNumber(prefix, a.CurrencyCode); a.Num != want {
+		return fmt.Errorf("bank account %s must be numbered %s", a.Num, want)
+	}
+
+	if want := specialKey(prefix, a.CurrencyCode); key != want {
+		return fmt.Errorf("bank account %s must be stored under key %s", a.Num, want)
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_Check(t *testing.T) {
+	ps := newSupplySystem(t)
+	c := payment.NewCustomer("1", "Ivan", "")
+
+	require.NoError(t, ps.Emit(500))
+	require.NoError(t, ps.CreateAccount(c, payment.AccountPrefix, payment.BYN, 0))
+
+	se, err := ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+
+	a, err := ps.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+	require.NoError(t, ps.Transfer(se, a, 200))
+
+	// the suspense account of the bank may run negative
+	_, err = ps.PostSuspense(a.Num, 10, "RECON B1")
+	require.NoError(t, err)
+
+	r := ps.Check()
+	assert.True(t, r.OK(), "%+v", r.Violations)
+	assert.Equal(t, 6, r.Accounts)
+
+	// the vostro accounts of other banks are numbered by their bank code
+	ib := newSupplySystem(t, payment.WithBankCode("ABCD"))
+	_, err = ib.OpenVostro("EFGH", payment.BYN, 0)
+	require.NoError(t, err)
+	assert.Empty(t, ib.Check().Violations)
+
+	require.NoError(t, ps.Restore([]byte(`{
+		"0": {
+			"SE": {"customer_id": "0", "num": "SE00MMMM00000000000000000001", "currency_code": "BYN", "status": "active", "balance": 100},
+			"ST": {"customer_id": "0", "num": "ST00MMMM00000000000000000002", "currency_code": "BYN", "status": "active", "balance": 0},
+			"SUBYN": {"customer_id": "0", "num": "SU00MMMM00000000000000000001", "currency_code": "BYN", "status": "active", "balance": -10}
+		},
+		"1": {
+			"K1": {"customer_id": "1", "num": "BY11ABCD00000000000000000001", "currency_code": "BYN", "status": "active", "balance": -50},
+			"K2": {"customer_id": "2", "num": "BY11ABCD00000000000000000001", "currency_code": "USD", "status": "active", "balance": 20, "reserved": 30},
+			"K3": {"customer_id": "1", "num": "XX11", "currency_code": "BYN", "status": "active", "balance": 0}
+		}
+	}`)))
+
+	r = ps.Check()
+	assert.False(t, r.OK())
+
+	var got []payment.Violation
+	for _, v := range r.Violations {
+		got = append(got, payment.Violation{Check: v.Check, Key: v.Key, CurrencyCode: v.CurrencyCode})
+	}
+
+	assert.Equal(t, []payment.Violation{
+		{Check: payment.CheckConservation, CurrencyCode: payment.BYN},
+		{Check: payment.CheckConservation, CurrencyCode: payment.USD},
+		{Check: payment.CheckAccountNumbers, Key: "SUBYN", CurrencyCode: payment.BYN},
+		{Check: payment.CheckNegativeBalance, Key: "K1", CurrencyCode: payment.BYN},
+		{Check: payment.CheckUniqueNumbers, Key: "K2", CurrencyCode: payment.USD},
+		{Check: payment.CheckNegativeBalance, Key: "K2", CurrencyCode: payment.USD},
+		{Check: payment.CheckCustomerKeys, Key: "K2", CurrencyCode: payment.USD},
+		{Check: payment.CheckAccountNumbers, Key: "K3", CurrencyCode: payment.BYN},
+		{Check: payment.CheckSpecialAccounts, Key: "SEUSD", CurrencyCode: payment.USD},
+		{Check: payment.CheckSpecialAccounts, Key: "STUSD", CurrencyCode: payment.USD},
+	}, got)
+	assert.Equal(t, 2, r.Counts[payment.CheckNegativeBalance])
+	assert.Contains(t, r.Violations[0].Detail, "circulation 40.00 differs from supply 500.00")
+}
@@ -0,0 +1,363 @@
+// Package clearing simulates a local clearing house between several banks,
+// each of them a PaymentSystem with its own bank code.
+package clearing
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+var (
+	ErrUnknownBank = errors.New("bank is not a member of the clearing house")
+	ErrBankExists  = errors.New("bank is already a member of the clearing house")
+)
+
+// Statuses of a transfer in the report of the cycle.
+const (
+	StatusDelivered = "delivered"
+	StatusReturned  = "returned"
+)
+
+// Item is an interbank transfer processed by the cycle.
+type Item struct {
+	payment.InterbankTransfer
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Position is what the bank sent to and received from the counterparty in the cycle.
+type Position struct {
+	Bank         string  `json:"bank"`
+	Counterparty string  `json:"counterparty"`
+	Currency     string  `json:"currency_code"`
+	Sent         float64 `json:"sent"`
+	Received     float64 `json:"received"`
+	// Net is positive when the bank owes the counterparty.
+	Net float64 `json:"net"`
+}
+
+// Settlement is the payment of the net position of the debtor to the creditor.
+type Settlement struct {
+	Debtor   string  `json:"debtor"`
+	Creditor string  `json:"creditor"`
+	Currency string  `json:"currency_code"`
+	Amount   float32 `json:"amount"`
+	Error    string  `json:"error,omitempty"`
+}
+
+// Report is the end-of-day settlement report of a clearing cycle.
+type Report struct {
+	Cycle       int          `json:"cycle"`
+	Time        time.Time    `json:"time"`
+	Items       []Item       `json:"items"`
+	Positions   []Position   `json:"positions"`
+	Settlements []Settlement `json:"settlements"`
+}
+
+// House collects the outbound transfers of its members, delivers them and settles the net positions.
+type House struct {
+	mu    sync.Mutex
+	banks map[string]*payment.PaymentSystem
+	now   func() time.Time
+	cycle int
+	// unsettled are the positions whose settlement failed, they are added to the next cycle.
+	unsettled map[pair]float64
+}
+
+type Option func(h *House)
+
+// WithClock replaces the time source of the reports.
+func WithClock(now func() time.Time) Option {
+	return func(h *House) {
+		h.now = now
+	}
+}
+
+func NewHouse(opts ...Option) *House {
+	h := &House{
+		banks:     make(map[string]*payment.PaymentSystem),
+		now:       time.Now,
+		unsettled: make(map[pair]float64),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	return h
+}
+
+// Join makes the bank a member. The bank must have a bank code.
+func (h *House) Join(ps *payment.PaymentSystem) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	code := ps.BankCode()
+	if code == "" {
+		return fmt.Errorf("clearing: bank without bank code cannot join")
+	}
+
+	if _, ok := h.banks[code]; ok {
+		return fmt.Errorf("%w: %s", ErrBankExists, code)
+	}
+
+	h.banks[code] = ps
+
+	return nil
+}
+
+/*
+This func opens the vostro accounts of the two banks with each other
+in the currency, each funded with the amount. The vostro account of a bank
+is its nostro account seen from the other bank.
+*/
+func (h *House) Link(a string, b string, currencyCode string, amount float32) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, p := range [][2]string{{a, b}, {b, a}} {
+		ps, ok := h.banks[p[0]]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownBank, p[0])
+		}
+
+		if _, ok := h.banks[p[1]]; !ok {
+			return fmt.Errorf("%w: %s", ErrUnknownBank, p[1])
+		}
+
+		ps.Lock()
+		_, err := ps.OpenVostro(p[1], currencyCode, amount)
+		ps.Unlock()
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Nostro returns the balance of the nostro account of the bank with the counterparty.
+func (h *House) Nostro(bank string, counterparty string, currencyCode string) (float32, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ps, ok := h.banks[counterparty]
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownBank, counterparty)
+	}
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	v, ok := ps.Vostro(bank, currencyCode)
+	if !ok {
+		return 0, fmt.Errorf("%w: nostro of %s with %s in %s", payment.ErrAccountNotFound, bank, counterparty, currencyCode)
+	}
+
+	return v.Balance, nil
+}
+
+// pair is the direction and currency of a position.
+type pair struct {
+	from     string
+	to       string
+	currency string
+}
+
+/*
+This func runs a clearing cycle: it collects the outbound transfers
+of every bank, delivers them to the receiving banks or returns them
+to the senders, nets the positions between every two banks and settles
+them through the vostro accounts. A failed settlement, e.g. when the vostro
+account of the debtor has not enough money, is carried to the next cycle.
+Only one bank is locked at a time.
+*/
+func (h *House) RunCycle() Report {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.cycle++
+
+	report := Report{Cycle: h.cycle, Time: h.now()}
+	gross := make(map[pair]float64)
+
+	for p, amount := range h.unsettled {
+		gross[p] += amount
+	}
+
+	h.unsettled = make(map[pair]float64)
+
+	codes := h.codes()
+
+	for _, code := range codes {
+		ps := h.banks[code]
+
+		ps.Lock()
+		queue := ps.TakeOutbound()
+		ps.Unlock()
+
+		for _, it := range queue {
+			item := Item{InterbankTransfer: it, Status: StatusDelivered}
+
+			if err := h.deliver(it); err != nil {
+				item.Status = StatusReturned
+				item.Reason = strings.TrimSpace(err.Error())
+
+				ps.Lock()
+				rerr := ps.ReturnOutbound(it, item.Reason)
+				ps.Unlock()
+
+				if rerr != nil {
+					item.Reason += "; return failed: " + strings.TrimSpace(rerr.Error())
+				}
+			} else {
+				gross[pair{from: it.FromBank, to: it.ToBank, currency: it.Currency}] += float64(it.Amount)
+			}
+
+			report.Items = append(report.Items, item)
+		}
+	}
+
+	report.Positions = positions(codes, gross)
+
+	for _, pos := range report.Positions {
+		if pos.Net <= payment.SupplyTolerance {
+			continue
+		}
+
+		st := Settlement{Debtor: pos.Bank, Creditor: pos.Counterparty, Currency: pos.Currency, Amount: float32(pos.Net)}
+
+		if err := h.settle(st, report.Cycle); err != nil {
+			st.Error = strings.TrimSpace(err.Error())
+			h.unsettled[pair{from: st.Debtor, to: st.Creditor, currency: st.Currency}] += pos.Net
+		}
+
+		report.Settlements = append(report.Settlements, st)
+	}
+
+	return report
+}
+
+func (h *House) codes() []string {
+	codes := make([]string, 0, len(h.banks))
+	for code := range h.banks {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	return codes
+}
+
+func (h *House) deliver(it payment.InterbankTransfer) error {
+	ps, ok := h.banks[it.ToBank]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownBank, it.ToBank)
+	}
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	return ps.ReceiveInbound(it)
+}
+
+/*
+This func takes the net amount from the vostro account of the debtor
+with the creditor and then books it out of the clearing account of the debtor.
+*/
+func (h *House) settle(st Settlement, cycle int) error {
+	creditor := h.banks[st.Creditor]
+	debtor := h.banks[st.Debtor]
+	ref := fmt.Sprintf("CYCLE%d-%s-%s", cycle, st.Debtor, st.Creditor)
+
+	creditor.Lock()
+	nostro := creditor.VostroAccountNumber(st.Debtor, st.Currency)
+	err := creditor.SettleCredit(st.Debtor, st.Currency, st.Amount, ref)
+	creditor.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	debtor.Lock()
+	defer debtor.Unlock()
+
+	return debtor.SettleDebit(st.Creditor, st.Currency, st.Amount, nostro, ref)
+}
+
+// positions returns the positions of every bank with every counterparty it has exchanged money with.
+func positions(codes []string, gross map[pair]float64) []Position {
+	currencies := make(map[string]bool)
+	for p := range gross {
+		currencies[p.currency] = true
+	}
+
+	curs := make([]string, 0, len(currencies))
+	for c := range currencies {
+		curs = append(curs, c)
+	}
+
+	sort.Strings(curs)
+
+	var res []Position
+
+	for _, cur := range curs {
+		for _, a := range codes {
+			for _, b := range codes {
+				sent := gross[pair{from: a, to: b, currency: cur}]
+				received := gross[pair{from: b, to: a, currency: cur}]
+
+				if a == b || sent == 0 && received == 0 {
+					continue
+				}
+
+				res = append(res, Position{Bank: a, Counterparty: b, Currency: cur, Sent: sent, Received: received, Net: sent - received})
+			}
+		}
+	}
+
+	return res
+}
+
+// Write prints the settlement report.
+func (r Report) Write(w io.Writer) error {
+	fmt.Fprintf(w, "clearing cycle %d at %s\n\ntransfers:\n", r.Cycle, r.Time.Format(time.RFC3339))
+
+	for _, it := range r.Items {
+		line := fmt.Sprintf("  %s  %s -> %s  %s -> %s  %.2f %s  %s", it.Id, it.FromBank, it.ToBank, it.Source, it.Destination, it.Amount, it.Currency, it.Status)
+		if it.Reason != "" {
+			line += ": " + it.Reason
+		}
+
+		fmt.Fprintln(w, line)
+	}
+
+	fmt.Fprintln(w, "\npositions:")
+
+	for _, p := range r.Positions {
+		fmt.Fprintf(w, "  %s/%s  %s  sent %.2f  received %.2f  net %.2f\n", p.Bank, p.Counterparty, p.Currency, p.Sent, p.Received, p.Net)
+	}
+
+	fmt.Fprintln(w, "\nsettlements:")
+
+	for _, s := range r.Settlements {
+		line := fmt.Sprintf("  %s pays %s  %.2f %s", s.Debtor, s.Creditor, s.Amount, s.Currency)
+		if s.Error != "" {
+			line += "  FAILED: " + s.Error
+		}
+
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
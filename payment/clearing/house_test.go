@@ -0,0 +1,120 @@
+package clearing_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/clearing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBank(t *testing.T, code string, balance float32) (*payment.PaymentSystem, payment.Account) {
+	t.Helper()
+
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithBankCode(code))
+	c := payment.NewCustomer("1", "Customer of "+code, payment.AccountPrefix)
+
+	require.NoError(t, ps.CreateAccount(c, payment.AccountPrefix, payment.BYN, balance))
+
+	a, err := ps.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, code, payment.BankCodeOf(a.Num))
+
+	return ps, a
+}
+
+func balance(t *testing.T, ps *payment.PaymentSystem, num string) float32 {
+	t.Helper()
+
+	ps.Lock()
+	defer ps.Unlock()
+
+	a, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+	require.Equal(t, num, a.Num)
+
+	return a.Balance
+}
+
+func TestHouse_RunCycle(t *testing.T) {
+	bankA, a := newBank(t, "AAAA", 1000)
+	bankB, b := newBank(t, "BBBB", 500)
+
+	h := clearing.NewHouse()
+	require.NoError(t, h.Join(bankA))
+	require.NoError(t, h.Join(bankB))
+	assert.ErrorIs(t, h.Join(bankA), clearing.ErrBankExists)
+	require.NoError(t, h.Link("AAAA", "BBBB", payment.BYN, 10000))
+	assert.ErrorIs(t, h.Link("AAAA", "CCCC", payment.BYN, 1), clearing.ErrUnknownBank)
+
+	require.NoError(t, bankA.TransferByNumber(a.Num, b.Num, 300))
+	require.NoError(t, bankA.TransferByNumber(a.Num, "BY00CCCC00000000000000000001", 50))
+	require.NoError(t, bankB.TransferByNumber(b.Num, a.Num, 100))
+
+	// the money waits in the clearing accounts until the cycle
+	assert.Len(t, bankA.Outbound(), 2)
+	assert.Equal(t, float32(650), balance(t, bankA, a.Num))
+	assert.Equal(t, float32(350), bankA.ClearingAccount(payment.BYN).Balance)
+	assert.Equal(t, float32(500-100), balance(t, bankB, b.Num))
+
+	report := h.RunCycle()
+
+	require.Len(t, report.Items, 3)
+	assert.Equal(t, clearing.StatusDelivered, report.Items[0].Status)
+	assert.Equal(t, clearing.StatusReturned, report.Items[1].Status)
+	assert.Contains(t, report.Items[1].Reason, "CCCC")
+	assert.Equal(t, clearing.StatusDelivered, report.Items[2].Status)
+
+	assert.Equal(t, []clearing.Position{
+		{Bank: "AAAA", Counterparty: "BBBB", Currency: payment.BYN, Sent: 300, Received: 100, Net: 200},
+		{Bank: "BBBB", Counterparty: "AAAA", Currency: payment.BYN, Sent: 100, Received: 300, Net: -200},
+	}, report.Positions)
+	assert.Equal(t, []clearing.Settlement{{Debtor: "AAAA", Creditor: "BBBB", Currency: payment.BYN, Amount: 200}}, report.Settlements)
+
+	// the customers got the money, the returned transfer is back
+	assert.Equal(t, float32(1000-300+100), balance(t, bankA, a.Num))
+	assert.Equal(t, float32(500-100+300), balance(t, bankB, b.Num))
+
+	// the clearing accounts are settled and the nostro of the debtor paid the net position
+	assert.Zero(t, bankA.ClearingAccount(payment.BYN).Balance)
+	assert.Zero(t, bankB.ClearingAccount(payment.BYN).Balance)
+
+	nostro, err := h.Nostro("AAAA", "BBBB", payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(10000-200), nostro)
+
+	nostro, err = h.Nostro("BBBB", "AAAA", payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(10000), nostro)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	assert.Contains(t, buf.String(), "AAAA pays BBBB  200.00 BYN")
+
+	assert.Empty(t, h.RunCycle().Items, "the queues are empty after the cycle")
+}
+
+func TestHouse_RunCycle_Unsettled(t *testing.T) {
+	bankA, a := newBank(t, "AAAA", 1000)
+	bankB, b := newBank(t, "BBBB", 0)
+
+	h := clearing.NewHouse()
+	require.NoError(t, h.Join(bankA))
+	require.NoError(t, h.Join(bankB))
+	require.NoError(t, h.Link("AAAA", "BBBB", payment.BYN, 100))
+
+	require.NoError(t, bankA.TransferByNumber(a.Num, b.Num, 300))
+
+	report := h.RunCycle()
+	require.Len(t, report.Settlements, 1)
+	assert.Contains(t, report.Settlements[0].Error, "insufficient funds")
+	assert.Equal(t, float32(300), balance(t, bankB, b.Num), "the transfer is delivered before the settlement")
+
+	// the failed settlement is carried over to the next cycle
+	report = h.RunCycle()
+	require.Len(t, report.Settlements, 1)
+	assert.NotEmpty(t, report.Settlements[0].Error)
+	assert.Equal(t, 300.0, report.Positions[0].Net)
+}
@@ -3,12 +3,15 @@
 package payment
 
 import (
-	"log"
+	"log/slog"
 	"time"
 )
 
 type HandlerFunc func() error
 
+// ControllerQueueSize is the number of tasks that can be added before Run.
+const ControllerQueueSize = 100
+
 type Controller interface {
 	Add(h HandlerFunc)
 	Run()
@@ -18,12 +21,28 @@ type Controller interface {
 type PaymentController struct {
 	ps      Store
 	workers chan HandlerFunc
+	logger  *slog.Logger
+}
+
+// ControllerOption configures optional behaviour of the PaymentController.
+type ControllerOption func(pc *PaymentController)
+
+// WithControllerLogger sets the logger of the controller. slog.Default() is used otherwise.
+func WithControllerLogger(l *slog.Logger) ControllerOption {
+	return func(pc *PaymentController) {
+		pc.logger = l
+	}
 }
 
-func NewPaymentController(ps Store) PaymentController {
+func NewPaymentController(ps Store, opts ...ControllerOption) PaymentController {
 	pc := PaymentController{
 		ps:      ps,
-		workers: make(chan HandlerFunc, 100),
+		workers: make(chan HandlerFunc, ControllerQueueSize),
+		logger:  slog.Default(),
+	}
+
+	for _, opt := range opts {
+		opt(&pc)
 	}
 
 	return pc
@@ -40,7 +59,7 @@ func (pc PaymentController) Add(h HandlerFunc) {
 func (pc PaymentController) Run(end chan bool) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Println("Recovered in f", r)
+			pc.logger.Error("recovered in controller", "panic", r)
 		}
 	}()
 
@@ -49,6 +68,8 @@ func (pc PaymentController) Run(end chan bool) {
 			select {
 			case worker, ok := <-pc.workers:
 				{
+					start := time.Now()
+
 					pc.ps.Lock()
 
 					err := worker()
@@ -56,11 +77,9 @@ func (pc PaymentController) Run(end chan bool) {
 					pc.ps.Unlock()
 
 					if err != nil {
-						log.Printf("error occurred")
-
-						continue
+						pc.logger.Error("task failed", "duration", time.Since(start), "error", err)
 					} else {
-						log.Println("task is done")
+						pc.logger.Debug("task is done", "duration", time.Since(start))
 					}
 
 					if ok && len(pc.workers) == 0 {
@@ -71,7 +90,7 @@ func (pc PaymentController) Run(end chan bool) {
 				}
 
 			default:
-				// log.Println("processing")
+				// pc.logger.Debug("processing")
 				time.Sleep(time.Millisecond * 50)
 			}
 		}
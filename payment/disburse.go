@@ -0,0 +1,212 @@
+package payment
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// disbursementColumns is the header of the disbursement CSV file.
+var disbursementColumns = []string{"customer_id", "account", "amount", "reference"}
+
+// Disbursement is one payment from the emission account to a customer.
+type Disbursement struct {
+	CustomerId string  `json:"customer_id"`
+	Account    string  `json:"account"`
+	Amount     float32 `json:"amount"`
+	Reference  string  `json:"reference"`
+}
+
+// DisbursementResult is the outcome of one disbursement of a batch.
+type DisbursementResult struct {
+	Disbursement
+	Row           int    `json:"row"`
+	TransactionId string `json:"transaction_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+/*
+This func moves the amount from the emission account of the currency
+of the destination to the destination account, which is found by number
+when its customer is not given. The reference identifies
+the disbursement: a reference can be disbursed only once, so a batch
+can be run again after a failure without paying anybody twice.
+The limits of the emission account apply.
+*/
+func (ps *PaymentSystem) Disburse(d Account, amount float32, reference string) (err error) {
+	_, err = ps.disburse(d, amount, reference)
+
+	return err
+}
+
+func (ps *PaymentSystem) disburse(d Account, amount float32, reference string) (tx Transaction, err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpDisburse, "", start, auditInput{"destination": d.Num, "amount": amount, "reference": reference}, tx.Id, err)
+
+		if err != nil {
+			ps.publish(EventTransferFailed, TransferFailed{Destination: d.Num, Amount: amount, Reason: strings.TrimSpace(err.Error())})
+		}
+	}()
+
+	ps.logger.Debug("try to disburse amount", "op", OpDisburse, "destination", d.Num, "amount", amount, "reference", reference)
+
+	if !positive(amount) {
+		return tx, fmt.Errorf("Disbursement is imposible: amount <= 0 \n")
+	}
+
+	if reference == "" {
+		return tx, fmt.Errorf("Disbursement is imposible: without reference\n")
+	}
+
+	if prev, ok := ps.disbursed(reference); ok {
+		return tx, fmt.Errorf("%w: %s was disbursed by %s", ErrDuplicateReference, reference, prev.Id)
+	}
+
+	if err := ps.checkVersion(d); err != nil {
+		return tx, err
+	}
+
+	if d.CustomerId == "" {
+		if a, ok := ps.findByNumber(d.Num); ok {
+			d.CustomerId = a.CustomerId
+		}
+	}
+
+	key1, dst, ok := ps.lookup(d.CustomerId, d.Num)
+	if !ok || !accountAvailable(dst) || dst.CustomerId == SpecialCustomerId {
+		return tx, fmt.Errorf("Disbursement is imposible: account %s is not valid or blocked", d.Num)
+	}
+
+	se, err := ps.GetSpecialAccountIn(AccountStateEmissionPrefix, dst.CurrencyCode)
+	if err != nil {
+		return tx, err
+	}
+
+	if err := ps.checkLimits(se, amount); err != nil {
+		return tx, err
+	}
+
+	if err := ps.screenParty(dst); err != nil {
+		return tx, err
+	}
+
+	key, se, _ := ps.lookup(se.CustomerId, se.Num)
+
+	if se.Balance-se.Reserved < amount {
+		return tx, fmt.Errorf("Disbursement is imposible: insufficient funds on account %s: %w", se.Num, ErrInsufficientFunds)
+	}
+
+	se.Balance -= amount
+	dst.Balance += amount
+
+	se, dst, err = ps.putPair(key, se, key1, dst)
+	if err != nil {
+		return tx, err
+	}
+
+	tx = ps.recordTx(Transaction{
+		Type:      TxDisbursement,
+		Source:    se.Num,
+		Dest:      dst.Num,
+		Currency:  dst.CurrencyCode,
+		Amount:    amount,
+		Reference: reference,
+	})
+
+	ps.publish(EventTransferCompleted, TransferCompleted{Source: se, Destination: dst, Amount: amount, TransactionId: tx.Id})
+
+	return tx, nil
+}
+
+// disbursed returns the disbursement with the reference if it was executed.
+func (ps *PaymentSystem) disbursed(reference string) (Transaction, bool) {
+	for _, tx := range ps.journal {
+		if tx.Type == TxDisbursement && tx.Reference == reference {
+			return tx, true
+		}
+	}
+
+	return Transaction{}, false
+}
+
+/*
+This func executes every disbursement of the batch independently
+and returns the result of each of them in the same order.
+*/
+func (ps *PaymentSystem) DisburseBatch(batch []Disbursement) []DisbursementResult {
+	res := make([]DisbursementResult, 0, len(batch))
+
+	for i, item := range batch {
+		r := DisbursementResult{Disbursement: item, Row: i + 1}
+
+		tx, err := ps.disburse(Account{CustomerId: item.CustomerId, Num: item.Account}, item.Amount, item.Reference)
+		if err != nil {
+			r.Error = strings.TrimSpace(err.Error())
+		} else {
+			r.TransactionId = tx.Id
+		}
+
+		res = append(res, r)
+	}
+
+	return res
+}
+
+/*
+This func reads the disbursements from CSV with the header
+customer_id,account,amount,reference.
+*/
+func ParseDisbursements(r io.Reader) ([]Disbursement, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(disbursementColumns)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("disbursement file is empty")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, col := range disbursementColumns {
+		if strings.TrimSpace(header[i]) != col {
+			return nil, fmt.Errorf("disbursement file: column %d must be %s, got %q", i+1, col, header[i])
+		}
+	}
+
+	var res []Disbursement
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := cr.FieldPos(0)
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(rec[2]), 32)
+		if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+			return nil, fmt.Errorf("disbursement file: line %d: invalid amount %q", line, rec[2])
+		}
+
+		res = append(res, Disbursement{
+			CustomerId: strings.TrimSpace(rec[0]),
+			Account:    strings.TrimSpace(rec[1]),
+			Amount:     float32(amount),
+			Reference:  strings.TrimSpace(rec[3]),
+		})
+	}
+}
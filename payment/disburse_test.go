@@ -0,0 +1,119 @@
+package payment_test
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDisburseSystem(t *testing.T) *payment.PaymentSystem {
+	t.Helper()
+
+	ps := newSupplySystem(t)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	gov := payment.NewCustomer(payment.SpecialCustomerId, "GOVERNMENT", "")
+	require.NoError(t, ps.CreateAccount(gov, payment.AccountStateEmissionPrefix, payment.BYN, 0))
+	require.NoError(t, ps.CreateAccount(gov, payment.AccountStateTerminatePrefix, payment.BYN, 0))
+	require.NoError(t, ps.Emit(1000))
+
+	return ps
+}
+
+func TestPaymentSystem_Disburse(t *testing.T) {
+	ps := newDisburseSystem(t)
+	ps.SetAccountLimit(payment.AccountStateEmissionNumber, payment.Limit{MaxAmount: 300})
+
+	se, err := ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc      string
+		account   payment.Account
+		amount    float32
+		reference string
+		fails     bool
+		err       error
+	}{
+		{desc: "by number", account: payment.Account{Num: destNum}, amount: 200, reference: "PAY-1"},
+		{desc: "duplicate reference", account: payment.Account{Num: destNum}, amount: 200, reference: "PAY-1", fails: true, err: payment.ErrDuplicateReference},
+		{desc: "over the limit", account: payment.Account{Num: destNum}, amount: 400, reference: "PAY-2", fails: true, err: payment.ErrLimitExceeded},
+		{desc: "without reference", account: payment.Account{Num: destNum}, amount: 10, fails: true},
+		{desc: "zero amount", account: payment.Account{Num: destNum}, reference: "PAY-3", fails: true},
+		{desc: "NaN amount", account: payment.Account{Num: destNum}, amount: float32(math.NaN()), reference: "PAY-7", fails: true},
+		{desc: "infinite amount", account: payment.Account{Num: destNum}, amount: float32(math.Inf(1)), reference: "PAY-8", fails: true},
+		{desc: "special account", account: se, amount: 10, reference: "PAY-4", fails: true},
+		{desc: "unknown account", account: payment.Account{Num: "BY00ABCD00000000000000000404"}, amount: 10, reference: "PAY-5", fails: true},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			err := ps.Disburse(tt.account, tt.amount, tt.reference)
+
+			switch {
+			case !tt.fails:
+				assert.NoError(t, err)
+			case tt.err != nil:
+				assert.ErrorIs(t, err, tt.err)
+			default:
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(200), d.Balance)
+
+	se, err = ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, float32(800), se.Balance)
+
+	require.NoError(t, ps.CloseAccount(d))
+	assert.Error(t, ps.Disburse(d, 10, "PAY-6"), "blocked account")
+}
+
+func TestPaymentSystem_DisburseBatch(t *testing.T) {
+	ps := newDisburseSystem(t)
+
+	batch, err := payment.ParseDisbursements(strings.NewReader(
+		"customer_id,account,amount,reference\n" +
+			"2," + destNum + ",100,B-1\n" +
+			"," + sourceNum + ",50.5,B-2\n" +
+			"2," + destNum + ",100,B-1\n" +
+			"2," + destNum + ",5000,B-3\n",
+	))
+	require.NoError(t, err)
+	require.Len(t, batch, 4)
+	assert.Equal(t, payment.Disbursement{Account: sourceNum, Amount: 50.5, Reference: "B-2"}, batch[1])
+
+	res := ps.DisburseBatch(batch)
+	require.Len(t, res, 4)
+
+	assert.Empty(t, res[0].Error)
+	assert.NotEmpty(t, res[0].TransactionId)
+	assert.Empty(t, res[1].Error)
+	assert.Contains(t, res[2].Error, payment.ErrDuplicateReference.Error())
+	assert.Contains(t, res[3].Error, payment.ErrInsufficientFunds.Error())
+	assert.Equal(t, 4, res[3].Row)
+
+	se, err := ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, float32(849.5), se.Balance)
+
+	_, err = payment.ParseDisbursements(strings.NewReader("customer,account,amount,reference\n"))
+	assert.Error(t, err, "wrong header")
+
+	_, err = payment.ParseDisbursements(strings.NewReader("customer_id,account,amount,reference\n1," + sourceNum + ",ten,R\n"))
+	assert.Error(t, err, "wrong amount")
+
+	for _, amount := range []string{"NaN", "Inf", "-Inf"} {
+		_, err = payment.ParseDisbursements(strings.NewReader("customer_id,account,amount,reference\n1," + sourceNum + "," + amount + ",R\n"))
+		assert.Error(t, err, amount)
+	}
+}
@@ -0,0 +1,384 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// TxInterest credits a customer with the interest of the day.
+	TxInterest = "interest"
+	// TxFee charges a customer with a fee.
+	TxFee = "fee"
+
+	// InterestPrefix is the key prefix of the account the interest is paid from.
+	InterestPrefix = "IN"
+	// FeePrefix is the key prefix of the account the fees are collected to.
+	FeePrefix = "FE"
+
+	// DaysInYear is the day count basis of the interest.
+	DaysInYear = 365
+)
+
+// ClosingBalance is the balance of an account at the end of the business day.
+type ClosingBalance struct {
+	CustomerId string  `json:"customer_id"`
+	Num        string  `json:"num"`
+	Currency   string  `json:"currency_code"`
+	Balance    float32 `json:"balance"`
+}
+
+/*
+This struct is the trial balance of a currency for the business day:
+the sum of the balances at the previous and at this close and the turnover
+of the journal entries booked on the day. Unposted is the change of the balances
+not explained by the journal, e.g. the opening balances of new accounts.
+*/
+type TrialBalance struct {
+	Currency string  `json:"currency_code"`
+	Accounts int     `json:"accounts"`
+	Opening  float64 `json:"opening"`
+	Debits   float64 `json:"debits"`
+	Credits  float64 `json:"credits"`
+	Closing  float64 `json:"closing"`
+	Unposted float64 `json:"unposted"`
+	Balanced bool    `json:"balanced"`
+}
+
+// JobResult is the outcome of an end-of-day job.
+type JobResult struct {
+	Name     string  `json:"name"`
+	Postings int     `json:"postings"`
+	Skipped  int     `json:"skipped,omitempty"`
+	Amount   float64 `json:"amount"`
+	// Rate is the annual interest rate of the interest job.
+	Rate  float64 `json:"rate,omitempty"`
+	Error string  `json:"error,omitempty"`
+}
+
+// DayClose is the result of the end-of-day process of a business date.
+type DayClose struct {
+	Date         time.Time        `json:"date"`
+	ClosedAt     time.Time        `json:"closed_at"`
+	Balances     []ClosingBalance `json:"balances"`
+	TrialBalance []TrialBalance   `json:"trial_balance"`
+	Jobs         []JobResult      `json:"jobs"`
+	// Scheduled are the transfers posted on the opening of the next business date.
+	Scheduled []ScheduledTransfer `json:"scheduled,omitempty"`
+	// Restatements are the back-valued corrections booked after the close which change the balances of the day.
+	Restatements []Restatement `json:"restatements,omitempty"`
+}
+
+/*
+EODJob is run by CloseDay for the closed business date after the balances
+are snapshotted. Its postings get the value date of the next business day.
+The name and the error of the result are filled by CloseDay.
+*/
+type EODJob func(ps *PaymentSystem, date time.Time) (JobResult, error)
+
+type eodJob struct {
+	name string
+	run  EODJob
+}
+
+// WithEODJob adds the job to the end-of-day process, the jobs run in the order they are added.
+func WithEODJob(name string, job EODJob) Option {
+	return func(ps *PaymentSystem) {
+		ps.AddEODJob(name, job)
+	}
+}
+
+// AddEODJob adds the job to the end-of-day process after the jobs added before.
+func (ps *PaymentSystem) AddEODJob(name string, job EODJob) {
+	ps.eodJobs = append(ps.eodJobs, eodJob{name: name, run: job})
+}
+
+// WithBusinessDate opens the business date instead of the date of the clock.
+func WithBusinessDate(date time.Time) Option {
+	return func(ps *PaymentSystem) {
+		ps.businessDate = truncateDay(date)
+	}
+}
+
+func truncateDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// BusinessDate returns the open business date; the first one is the date of the clock.
+func (ps *PaymentSystem) BusinessDate() time.Time {
+	if ps.businessDate.IsZero() {
+		ps.businessDate = truncateDay(ps.now())
+	}
+
+	return ps.businessDate
+}
+
+// bookingDate returns the booking date of a new posting: the next business date during the end-of-day process.
+func (ps *PaymentSystem) bookingDate() time.Time {
+	if ps.inEOD {
+		return ps.BusinessDate().AddDate(0, 0, 1)
+	}
+
+	return ps.BusinessDate()
+}
+
+/*
+This func runs the end-of-day process: it freezes the business date,
+snapshots the closing balance of every account, runs the end-of-day jobs,
+builds the trial balance of every currency and opens the next business date
+with the transfers scheduled for it.
+A failing job is reported in the result and does not stop the process.
+*/
+func (ps *PaymentSystem) CloseDay() (dc DayClose, err error) {
+	start := time.Now()
+	date := ps.BusinessDate()
+
+	defer func() {
+		ps.finish(OpCloseDay, "", start, auditInput{"date": date.Format(time.DateOnly)}, date.Format(time.DateOnly), err)
+	}()
+
+	if ps.inEOD {
+		return DayClose{}, fmt.Errorf("Closing day is imposible: end of day of %s is running", date.Format(time.DateOnly))
+	}
+
+	ps.inEOD = true
+	dc = DayClose{Date: date, Balances: ps.closingBalances()}
+
+	for _, job := range ps.eodJobs {
+		res, err := job.run(ps, date)
+		res.Name = job.name
+
+		if err != nil {
+			res.Error = strings.TrimSpace(err.Error())
+			ps.logger.Warn("end of day job failed", "job", job.name, "date", date.Format(time.DateOnly), "error", err)
+		}
+
+		dc.Jobs = append(dc.Jobs, res)
+	}
+
+	dc.TrialBalance = ps.trialBalance(date, dc.Balances)
+	dc.ClosedAt = ps.now()
+
+	ps.inEOD = false
+	ps.businessDate = date.AddDate(0, 0, 1)
+
+	// the transfers scheduled for the new business date are posted when it opens
+	dc.Scheduled = ps.PostScheduled()
+
+	ps.dayCloses = append(ps.dayCloses, dc)
+
+	return dc, nil
+}
+
+// DayCloses returns the results of the closed business dates, oldest first.
+func (ps *PaymentSystem) DayCloses() []DayClose {
+	return append([]DayClose(nil), ps.dayCloses...)
+}
+
+// DayClose returns the result of the closed business date.
+func (ps *PaymentSystem) DayClose(date time.Time) (DayClose, bool) {
+	for _, dc := range ps.dayCloses {
+		if dc.Date.Equal(truncateDay(date)) {
+			return dc, true
+		}
+	}
+
+	return DayClose{}, false
+}
+
+func (ps *PaymentSystem) closingBalances() []ClosingBalance {
+	var res []ClosingBalance
+
+	for _, a := range ps.Accounts("") {
+		res = append(res, ClosingBalance{CustomerId: a.CustomerId, Num: a.Num, Currency: a.CurrencyCode, Balance: a.Balance})
+	}
+
+	return res
+}
+
+/*
+This func builds the trial balance of the date from the closing balances,
+the closing balances of the previous close and the journal entries booked on the date.
+The first close has no previous balances, its opening is derived from the turnover.
+*/
+func (ps *PaymentSystem) trialBalance(date time.Time, balances []ClosingBalance) []TrialBalance {
+	lines := make(map[string]*TrialBalance)
+
+	line := func(cur string) *TrialBalance {
+		if _, ok := lines[cur]; !ok {
+			lines[cur] = &TrialBalance{Currency: cur}
+		}
+
+		return lines[cur]
+	}
+
+	for _, b := range balances {
+		l := line(b.Currency)
+		l.Accounts++
+		l.Closing += float64(b.Balance)
+	}
+
+	for _, tx := range ps.journal {
+		if !tx.BookingDate.Equal(date) {
+			continue
+		}
+
+		if _, ok := ps.findByNumber(tx.Source); ok {
+			line(tx.Currency).Debits += float64(tx.Amount)
+		}
+
+		if _, ok := ps.findByNumber(tx.Dest); ok {
+			line(tx.Currency).Credits += float64(tx.Amount)
+		}
+	}
+
+	if n := len(ps.dayCloses); n > 0 {
+		for _, b := range ps.dayCloses[n-1].Balances {
+			line(b.Currency).Opening += float64(b.Balance)
+		}
+	} else {
+		for _, l := range lines {
+			l.Opening = l.Closing - l.Credits + l.Debits
+		}
+	}
+
+	res := make([]TrialBalance, 0, len(lines))
+
+	for _, cur := range sortedKeys(lines) {
+		l := lines[cur]
+		l.Unposted = l.Closing - (l.Opening + l.Credits - l.Debits)
+		l.Balanced = math.Abs(l.Unposted) <= SupplyTolerance
+
+		res = append(res, *l)
+	}
+
+	return res
+}
+
+/*
+This func returns the available accounts of the customers in the currency ordered by number,
+all currencies for empty code. The accounts of the bank and the vostro accounts
+of correspondent banks are not accounts of customers.
+*/
+func (ps *PaymentSystem) customerAccounts(currencyCode string) []Account {
+	var res []Account
+
+	for _, a := range ps.Accounts("") {
+		if a.CustomerId == SpecialCustomerId || ps.vostro(a) || !accountAvailable(a) || (currencyCode != "" && a.CurrencyCode != currencyCode) {
+			continue
+		}
+
+		res = append(res, a)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Num < res[j].Num })
+
+	return res
+}
+
+/*
+This func moves the amount between the account of the customer and
+the internal account with the prefix: to the customer for a credit,
+from the customer otherwise.
+*/
+func (ps *PaymentSystem) postInternal(txType string, prefix string, a Account, amount float32, credit bool, reference string) error {
+	key, a, ok := ps.lookup(a.CustomerId, a.Num)
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, a.Num)
+	}
+
+	inKey, in := ps.internalAccount(prefix, a.CurrencyCode)
+
+	src, dst := in.Num, a.Num
+	if !credit {
+		amount = -amount
+		src, dst = a.Num, in.Num
+	}
+
+	a.Balance += amount
+	in.Balance -= amount
+
+	if _, _, err := ps.putPair(key, a, inKey, in); err != nil {
+		return err
+	}
+
+	ps.recordTx(Transaction{Type: txType, Source: src, Dest: dst, Currency: a.CurrencyCode, Amount: float32(math.Abs(float64(amount))), Reference: reference})
+
+	return nil
+}
+
+// roundCents rounds the amount to cents.
+func roundCents(v float64) float32 {
+	return float32(math.Round(v*100) / 100)
+}
+
+// dailyInterest returns the interest of one day on the balance, rounded to cents.
+func dailyInterest(balance float32, annualRate float64) float32 {
+	return roundCents(float64(balance) * annualRate / DaysInYear)
+}
+
+/*
+This func returns the job crediting every customer account with a positive
+balance with the daily interest at the annual rate, e.g. 0.05 for 5%.
+The interest is paid from the interest account of the currency.
+*/
+func InterestJob(annualRate float64) EODJob {
+	return func(ps *PaymentSystem, date time.Time) (JobResult, error) {
+		res := JobResult{Rate: annualRate}
+
+		for _, a := range ps.customerAccounts("") {
+			interest := dailyInterest(a.Balance, annualRate)
+			if interest <= 0 {
+				res.Skipped++
+
+				continue
+			}
+
+			if err := ps.postInternal(TxInterest, InterestPrefix, a, interest, true, ""); err != nil {
+				return res, err
+			}
+
+			res.Postings++
+			res.Amount += float64(interest)
+		}
+
+		return res, nil
+	}
+}
+
+/*
+This func returns the job charging every customer account in the currency
+with the monthly fee on the last day of the month. Accounts without
+enough available money are skipped. The fees go to the fee account.
+*/
+func FeeJob(currencyCode string, amount float32) EODJob {
+	return func(ps *PaymentSystem, date time.Time) (JobResult, error) {
+		var res JobResult
+
+		if date.AddDate(0, 0, 1).Day() != 1 {
+			return res, nil
+		}
+
+		for _, a := range ps.customerAccounts(currencyCode) {
+			if a.Balance-a.Reserved < amount {
+				res.Skipped++
+
+				continue
+			}
+
+			if err := ps.postInternal(TxFee, FeePrefix, a, amount, false, ""); err != nil {
+				return res, err
+			}
+
+			res.Postings++
+			res.Amount += float64(amount)
+		}
+
+		return res, nil
+	}
+}
@@ -0,0 +1,132 @@
+package payment_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_CloseDay_Vostro(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithBankCode("ABCD"),
+		payment.WithClock(func() time.Time { return now }),
+		payment.WithEODJob("interest", payment.InterestJob(0.0365)),
+		payment.WithEODJob("fee", payment.FeeJob(payment.BYN, 2)),
+	)
+	// the vostro account carries an ordinary account number
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "`+sourceNum+`", "currency_code": "BYN", "status": "active", "balance": 1000}},
+		"2": {"K2": {"customer_id": "2", "num": "`+destNum+`", "currency_code": "BYN", "status": "active", "balance": 0}},
+		"CORR": {"`+payment.VostroPrefix+`BYN": {"customer_id": "CORR", "num": "BY33ABCD00000000000000000003", "currency_code": "BYN", "status": "active", "balance": 1000}}
+	}`)))
+
+	vostro, ok := ps.Vostro("CORR", payment.BYN)
+	require.True(t, ok)
+
+	dc, err := ps.CloseDay()
+	require.NoError(t, err)
+
+	require.Len(t, dc.Jobs, 2)
+	assert.Equal(t, 1, dc.Jobs[0].Postings, "interest accrues only on the customer with a balance")
+	assert.Equal(t, 1, dc.Jobs[1].Postings, "the fee is charged only on the customer who can pay it")
+
+	vostro, err = ps.AccountByNumber(vostro.Num)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1000), vostro.Balance)
+	assert.Empty(t, ps.Transactions(vostro.Num))
+}
+
+func TestPaymentSystem_CloseDay(t *testing.T) {
+	const (
+		num1 = "BY11ABCD00000000000000000001"
+		num2 = "BY22ABCD00000000000000000002"
+	)
+
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	jan31 := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	feb1 := jan31.AddDate(0, 0, 1)
+
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithClock(func() time.Time { return now }),
+		payment.WithEODJob("interest", payment.InterestJob(0.0365)),
+		payment.WithEODJob("fee", payment.FeeJob(payment.BYN, 2)),
+		payment.WithEODJob("broken", func(ps *payment.PaymentSystem, date time.Time) (payment.JobResult, error) {
+			return payment.JobResult{}, errors.New("job failed")
+		}),
+	)
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "`+num1+`", "currency_code": "BYN", "status": "active", "balance": 1000}},
+		"2": {"K2": {"customer_id": "2", "num": "`+num2+`", "currency_code": "BYN", "status": "active", "balance": 0}}
+	}`)))
+
+	assert.Equal(t, jan31, ps.BusinessDate())
+	require.NoError(t, ps.TransferByNumber(num1, num2, 100))
+
+	dc, err := ps.CloseDay()
+	require.NoError(t, err)
+
+	assert.Equal(t, jan31, dc.Date)
+	assert.Equal(t, []payment.ClosingBalance{
+		{CustomerId: "1", Num: num1, Currency: payment.BYN, Balance: 900},
+		{CustomerId: "2", Num: num2, Currency: payment.BYN, Balance: 100},
+	}, dc.Balances, "the balances are snapshotted before the jobs")
+
+	require.Len(t, dc.Jobs, 3)
+	assert.Equal(t, "interest", dc.Jobs[0].Name)
+	assert.Equal(t, 2, dc.Jobs[0].Postings)
+	assert.InDelta(t, 0.09+0.01, dc.Jobs[0].Amount, 0.0001)
+	assert.Equal(t, payment.JobResult{Name: "fee", Postings: 2, Amount: 4}, dc.Jobs[1], "the fee is charged on the last day of the month")
+	assert.Equal(t, payment.JobResult{Name: "broken", Error: "job failed"}, dc.Jobs[2])
+
+	require.Len(t, dc.TrialBalance, 1)
+	assert.Equal(t, payment.TrialBalance{Currency: payment.BYN, Accounts: 2, Opening: 1000, Debits: 100, Credits: 100, Closing: 1000, Balanced: true}, dc.TrialBalance[0])
+
+	// the postings of the jobs belong to the next day
+	txs := ps.Transactions(num1)
+	require.Len(t, txs, 3)
+	assert.Equal(t, jan31, txs[0].ValueDate)
+	assert.Equal(t, payment.TxInterest, txs[1].Type)
+	assert.Equal(t, feb1, txs[1].ValueDate)
+	assert.Equal(t, payment.TxFee, txs[2].Type)
+	assert.Equal(t, feb1, txs[2].ValueDate)
+
+	assert.Equal(t, feb1, ps.BusinessDate())
+
+	now = now.AddDate(0, 0, 1)
+	require.NoError(t, ps.TransferByNumber(num2, num1, 10))
+
+	dc, err = ps.CloseDay()
+	require.NoError(t, err)
+
+	assert.Equal(t, feb1, dc.Date)
+	assert.Equal(t, 0, dc.Jobs[1].Postings, "no fee in the middle of the month")
+
+	tb := dc.TrialBalance[0]
+	assert.Equal(t, 4, tb.Accounts, "the interest and fee accounts are opened")
+	assert.InDelta(t, 1000, tb.Opening, 0.001)
+	assert.InDelta(t, 1000, tb.Closing, 0.001)
+	assert.InDelta(t, 10+0.1+4, tb.Debits, 0.001)
+	assert.InDelta(t, 10+0.1+4, tb.Credits, 0.001)
+	assert.True(t, tb.Balanced)
+
+	// the business days survive the snapshot
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, restored.LoadSnapshot(data))
+	assert.Equal(t, feb1.AddDate(0, 0, 1), restored.BusinessDate())
+	assert.Len(t, restored.DayCloses(), 2)
+
+	got, ok := restored.DayClose(jan31)
+	require.True(t, ok)
+	assert.Equal(t, jan31, got.Date)
+}
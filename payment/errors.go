@@ -0,0 +1,33 @@
+package payment
+
+import "errors"
+
+var (
+	ErrInsufficientFunds     = errors.New("insufficient funds")
+	ErrTransferNotFound      = errors.New("pending transfer not found")
+	ErrTransferNotPending    = errors.New("transfer is not pending")
+	ErrApproverNotAllowed    = errors.New("approver is not authorized")
+	ErrApproverIsInitiator   = errors.New("approver cannot be the initiator of the transfer")
+	ErrLimitExceeded         = errors.New("transaction limit exceeded")
+	ErrTransferDenied        = errors.New("transfer denied")
+	ErrReviewerNotAllowed    = errors.New("reviewer is not authorized")
+	ErrTransferNotInReview   = errors.New("transfer is not in review")
+	ErrCustomerBlocked       = errors.New("customer is blocked by watchlist screening")
+	ErrCustomerNotInReview   = errors.New("customer has no watchlist hit to review")
+	ErrAuditChainBroken      = errors.New("audit log chain is broken")
+	ErrCustomerNotFound      = errors.New("customer not found")
+	ErrCustomerExists        = errors.New("customer already exists")
+	ErrAccountNotFound       = errors.New("account not found")
+	ErrConflict              = errors.New("account was changed concurrently")
+	ErrEmissionNotAuthorized = errors.New("emission needs authorization")
+	ErrEmissionCeiling       = errors.New("emission ceiling exceeded")
+	ErrEmissionNotFound      = errors.New("emission request not found")
+	ErrEmissionNotPending    = errors.New("emission request is not pending")
+	ErrAlreadyApproved       = errors.New("request is already approved by this approver")
+	ErrDuplicateReference    = errors.New("reference is already used")
+	ErrInvalidValueDate      = errors.New("invalid value date")
+	ErrScheduledNotFound     = errors.New("scheduled transfer not found")
+	ErrInvalidQuery          = errors.New("invalid account query")
+	ErrSameAccount           = errors.New("source and destination are the same account")
+	ErrMakerRequired         = errors.New("held transfer needs an initiator")
+)
@@ -0,0 +1,316 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	EventAccountCreated    = "AccountCreated"
+	EventAccountBlocked    = "AccountBlocked"
+	EventAccountActivated  = "AccountActivated"
+	EventFundsEmitted      = "FundsEmitted"
+	EventFundsTerminated   = "FundsTerminated"
+	EventTransferCompleted = "TransferCompleted"
+	EventTransferFailed    = "TransferFailed"
+)
+
+const (
+	EventPrefix            = "EV"
+	DefaultDispatchRetry   = 5 * time.Second
+	eventNotifyChannelSize = 1
+)
+
+// AccountCreated is published when an account is opened.
+type AccountCreated struct {
+	Account Account `json:"account"`
+}
+
+// AccountBlocked is published when an account is closed.
+type AccountBlocked struct {
+	Account Account `json:"account"`
+}
+
+// AccountActivated is published when a blocked account is activated again.
+type AccountActivated struct {
+	Account Account `json:"account"`
+}
+
+// FundsEmitted is published when money is emitted to the emission account.
+type FundsEmitted struct {
+	Account       Account `json:"account"`
+	Amount        float32 `json:"amount"`
+	TransactionId string  `json:"transaction_id"`
+}
+
+// FundsTerminated is published when money is moved to the termination account.
+type FundsTerminated struct {
+	Account       Account `json:"account"`
+	Amount        float32 `json:"amount"`
+	TransactionId string  `json:"transaction_id"`
+}
+
+// TransferCompleted is published when money has moved between two accounts.
+type TransferCompleted struct {
+	Source        Account `json:"source"`
+	Destination   Account `json:"destination"`
+	Amount        float32 `json:"amount"`
+	TransactionId string  `json:"transaction_id"`
+}
+
+// TransferFailed is published when a transfer is refused, rejected or expires.
+type TransferFailed struct {
+	Source      string  `json:"source"`
+	Destination string  `json:"destination"`
+	Amount      float32 `json:"amount"`
+	PendingId   string  `json:"pending_id,omitempty"`
+	Reason      string  `json:"reason"`
+}
+
+/*
+This struct is a domain event kept in the outbox until all subscribers
+have received it. Payload holds one of the typed events above,
+Seq orders the events and is used by subscribers to skip duplicates.
+*/
+type Event struct {
+	Id      string    `json:"id"`
+	Seq     int       `json:"seq"`
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	Payload any       `json:"payload"`
+}
+
+// UnmarshalJSON decodes the payload into the typed event named by Type.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	type event Event
+
+	var raw struct {
+		event
+		Payload json.RawMessage `json:"payload"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var payload any
+
+	switch raw.Type {
+	case EventAccountCreated:
+		payload = &AccountCreated{}
+	case EventAccountBlocked:
+		payload = &AccountBlocked{}
+	case EventAccountActivated:
+		payload = &AccountActivated{}
+	case EventFundsEmitted:
+		payload = &FundsEmitted{}
+	case EventFundsTerminated:
+		payload = &FundsTerminated{}
+	case EventTransferCompleted:
+		payload = &TransferCompleted{}
+	case EventTransferFailed:
+		payload = &TransferFailed{}
+	default:
+		return fmt.Errorf("unknown event type %q", raw.Type)
+	}
+
+	if err := json.Unmarshal(raw.Payload, payload); err != nil {
+		return err
+	}
+
+	*e = Event(raw.event)
+
+	// keep the payload a value, like the published events
+	switch p := payload.(type) {
+	case *AccountCreated:
+		e.Payload = *p
+	case *AccountBlocked:
+		e.Payload = *p
+	case *AccountActivated:
+		e.Payload = *p
+	case *FundsEmitted:
+		e.Payload = *p
+	case *FundsTerminated:
+		e.Payload = *p
+	case *TransferCompleted:
+		e.Payload = *p
+	case *TransferFailed:
+		e.Payload = *p
+	}
+
+	return nil
+}
+
+// Subscriber receives the events. An error makes the event to be delivered again later.
+type Subscriber interface {
+	HandleEvent(e Event) error
+}
+
+// SubscriberFunc adapts a function to the Subscriber interface.
+type SubscriberFunc func(e Event) error
+
+func (f SubscriberFunc) HandleEvent(e Event) error {
+	return f(e)
+}
+
+/*
+This func appends the event to the outbox and wakes up the dispatcher.
+Without subscribers the event is only counted: a new subscriber starts
+at the current end of the outbox and would never receive it.
+*/
+func (ps *PaymentSystem) publish(eventType string, payload any) {
+	ps.eventSeq++
+
+	if len(ps.eventOffsets) == 0 {
+		return
+	}
+
+	e := Event{
+		Id:      ps.nextID(EventPrefix),
+		Seq:     ps.eventSeq,
+		Type:    eventType,
+		Time:    ps.now(),
+		Payload: payload,
+	}
+
+	ps.outbox = append(ps.outbox, e)
+
+	select {
+	case ps.eventNotify <- struct{}{}:
+	default:
+	}
+}
+
+// publishFailed publishes the failure of the pending transfer.
+func (ps *PaymentSystem) publishFailed(pt *PendingTransfer, reason string) {
+	ps.publish(EventTransferFailed, TransferFailed{Source: pt.S.Num, Destination: pt.D.Num, Amount: pt.Amount, PendingId: pt.Id, Reason: reason})
+}
+
+/*
+This func registers the subscriber under a durable name.
+A name known from a loaded snapshot resumes after its last received event,
+a new one receives the events published from now on.
+It takes the store lock, so it must not be called while holding it.
+*/
+func (ps *PaymentSystem) Subscribe(name string, s Subscriber) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	if _, ok := ps.eventOffsets[name]; !ok {
+		ps.eventOffsets[name] = ps.eventSeq
+	}
+
+	ps.subscribers[name] = s
+}
+
+// Unsubscribe removes the subscriber and forgets its position in the outbox.
+func (ps *PaymentSystem) Unsubscribe(name string) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	delete(ps.subscribers, name)
+	delete(ps.eventOffsets, name)
+
+	ps.trimOutbox()
+}
+
+// Outbox returns the events not yet received by every subscriber.
+func (ps *PaymentSystem) Outbox() []Event {
+	res := make([]Event, len(ps.outbox))
+	copy(res, ps.outbox)
+
+	return res
+}
+
+/*
+This func delivers the outbox to every subscriber in order and returns
+the number of delivered events. Delivery to a subscriber stops at its
+first error and is retried by the next call, so events are delivered
+at least once. Subscribers are called without the store lock held.
+*/
+func (ps *PaymentSystem) DispatchEvents() int {
+	ps.dispatchMu.Lock()
+	defer ps.dispatchMu.Unlock()
+
+	ps.mu.Lock()
+
+	names := sortedKeys(ps.subscribers)
+	subs := make(map[string]Subscriber, len(names))
+	batches := make(map[string][]Event, len(names))
+
+	for _, name := range names {
+		subs[name] = ps.subscribers[name]
+
+		for _, e := range ps.outbox {
+			if e.Seq > ps.eventOffsets[name] {
+				batches[name] = append(batches[name], e)
+			}
+		}
+	}
+
+	ps.mu.Unlock()
+
+	delivered := 0
+	acked := make(map[string]int)
+
+	for _, name := range names {
+		for _, e := range batches[name] {
+			if err := subs[name].HandleEvent(e); err != nil {
+				ps.logger.Warn("event delivery failed", "subscriber", name, "event_id", e.Id, "type", e.Type, "error", err)
+
+				break
+			}
+
+			acked[name] = e.Seq
+			delivered++
+		}
+	}
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	for name, seq := range acked {
+		if _, ok := ps.eventOffsets[name]; ok && seq > ps.eventOffsets[name] {
+			ps.eventOffsets[name] = seq
+		}
+	}
+
+	ps.trimOutbox()
+
+	return delivered
+}
+
+// trimOutbox drops the events received by all known subscribers.
+func (ps *PaymentSystem) trimOutbox() {
+	low := ps.eventSeq
+
+	for _, seq := range ps.eventOffsets {
+		low = min(low, seq)
+	}
+
+	i := sort.Search(len(ps.outbox), func(i int) bool { return ps.outbox[i].Seq > low })
+	ps.outbox = append([]Event(nil), ps.outbox[i:]...)
+}
+
+/*
+This func dispatches events as soon as they are published
+and retries failed deliveries every retry interval until the context is done.
+*/
+func (ps *PaymentSystem) RunDispatcher(ctx context.Context, retry time.Duration) {
+	t := time.NewTicker(retry)
+	defer t.Stop()
+
+	for {
+		ps.DispatchEvents()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ps.eventNotify:
+		case <-t.C:
+		}
+	}
+}
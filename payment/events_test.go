@@ -0,0 +1,141 @@
+package payment_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type eventRecorder struct {
+	events []payment.Event
+	fail   int
+}
+
+func (r *eventRecorder) HandleEvent(e payment.Event) error {
+	if r.fail > 0 {
+		r.fail--
+
+		return errors.New("receiver is down")
+	}
+
+	r.events = append(r.events, e)
+
+	return nil
+}
+
+func (r *eventRecorder) types() []string {
+	res := make([]string, 0, len(r.events))
+
+	for _, e := range r.events {
+		res = append(res, e.Type)
+	}
+
+	return res
+}
+
+func newEventSystem(t *testing.T) (*payment.PaymentSystem, payment.Account, payment.Account) {
+	t.Helper()
+
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithApprovalThreshold(500), payment.WithApprovers("checker"))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+
+	return ps, s, d
+}
+
+func TestPaymentSystem_DispatchEvents(t *testing.T) {
+	ps, s, d := newEventSystem(t)
+
+	first, second := &eventRecorder{}, &eventRecorder{}
+	ps.Subscribe("first", first)
+	ps.Subscribe("second", second)
+
+	require.NoError(t, ps.Transfer(s, d, 100))
+	require.Error(t, ps.Transfer(s, d, 5000))
+
+	id, err := ps.SubmitTransfer("maker", s, d, 600)
+	require.NoError(t, err)
+	require.NoError(t, ps.RejectTransfer(id, "checker"))
+
+	require.NoError(t, ps.CloseAccount(d))
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("3", "Customer Three", payment.AccountPrefix), payment.AccountPrefix, payment.BYN, 0))
+
+	assert.Equal(t, 10, ps.DispatchEvents())
+	assert.Empty(t, ps.Outbox())
+
+	want := []string{
+		payment.EventTransferCompleted,
+		payment.EventTransferFailed,
+		payment.EventTransferFailed,
+		payment.EventAccountBlocked,
+		payment.EventAccountCreated,
+	}
+	assert.Equal(t, want, first.types())
+	assert.Equal(t, first.events, second.events)
+
+	done, ok := first.events[0].Payload.(payment.TransferCompleted)
+	require.True(t, ok)
+	assert.Equal(t, float32(900), done.Source.Balance)
+	assert.Equal(t, float32(100), done.Destination.Balance)
+	assert.NotEmpty(t, done.TransactionId)
+
+	rejected, ok := first.events[2].Payload.(payment.TransferFailed)
+	require.True(t, ok)
+	assert.Equal(t, id, rejected.PendingId)
+	assert.Equal(t, "rejected by checker", rejected.Reason)
+}
+
+func TestPaymentSystem_DispatchEventsRetry(t *testing.T) {
+	ps, s, d := newEventSystem(t)
+
+	healthy, flaky := &eventRecorder{}, &eventRecorder{fail: 2}
+	ps.Subscribe("healthy", healthy)
+	ps.Subscribe("flaky", flaky)
+
+	require.NoError(t, ps.Transfer(s, d, 10))
+	require.NoError(t, ps.Transfer(s, d, 20))
+
+	assert.Equal(t, 2, ps.DispatchEvents())
+	assert.Len(t, ps.Outbox(), 2)
+
+	assert.Equal(t, 0, ps.DispatchEvents())
+	assert.Equal(t, 2, ps.DispatchEvents())
+	assert.Empty(t, ps.Outbox())
+
+	assert.Equal(t, healthy.events, flaky.events)
+	assert.Equal(t, healthy.events[0].Seq+1, healthy.events[1].Seq)
+}
+
+func TestPaymentSystem_OutboxSnapshot(t *testing.T) {
+	ps, s, d := newEventSystem(t)
+
+	ps.Subscribe("ledger", &eventRecorder{fail: 1})
+
+	require.NoError(t, ps.Transfer(s, d, 10))
+	assert.Equal(t, 0, ps.DispatchEvents())
+
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem()
+	require.NoError(t, restored.LoadSnapshot(data))
+
+	ledger := &eventRecorder{}
+	restored.Subscribe("ledger", ledger)
+
+	assert.Equal(t, 1, restored.DispatchEvents())
+	require.Len(t, ledger.events, 1)
+
+	done, ok := ledger.events[0].Payload.(payment.TransferCompleted)
+	require.True(t, ok)
+	assert.Equal(t, sourceNum, done.Source.Num)
+	assert.Equal(t, float32(10), done.Amount)
+}
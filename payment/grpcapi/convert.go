@@ -0,0 +1,141 @@
+package grpcapi
+
+import (
+	"github.com/soundrise/go-payment-system/payment"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func toAccount(a payment.Account) *pb.Account {
+	res := &pb.Account{
+		CustomerId:   a.CustomerId,
+		Number:       a.Num,
+		CurrencyCode: a.CurrencyCode,
+		Status:       toAccountStatus(a.Status),
+		Balance:      a.Balance,
+		Reserved:     a.Reserved,
+		Description:  a.Description,
+		Version:      int64(a.Version),
+	}
+
+	if !a.CreatedAt.IsZero() {
+		res.CreatedAt = timestamppb.New(a.CreatedAt)
+	}
+
+	return res
+}
+
+func toAccountStatus(s string) pb.AccountStatus {
+	switch s {
+	case payment.Active:
+		return pb.AccountStatus_ACCOUNT_STATUS_ACTIVE
+	case payment.Blocked:
+		return pb.AccountStatus_ACCOUNT_STATUS_BLOCKED
+	}
+
+	return pb.AccountStatus_ACCOUNT_STATUS_UNSPECIFIED
+}
+
+func toMoneySupply(ms payment.MoneySupply) *pb.MoneySupply {
+	return &pb.MoneySupply{
+		CurrencyCode: ms.CurrencyCode,
+		Emitted:      ms.Emitted,
+		Terminated:   ms.Terminated,
+		Supply:       ms.Supply,
+		Circulation:  ms.Circulation,
+		Difference:   ms.Difference,
+		Ceiling:      ms.Ceiling,
+		Balanced:     ms.Balanced,
+	}
+}
+
+// FromMoneySupply converts the money supply of one currency received from the server.
+func FromMoneySupply(ms *pb.MoneySupply) payment.MoneySupply {
+	return payment.MoneySupply{
+		CurrencyCode: ms.GetCurrencyCode(),
+		Emitted:      ms.GetEmitted(),
+		Terminated:   ms.GetTerminated(),
+		Supply:       ms.GetSupply(),
+		Circulation:  ms.GetCirculation(),
+		Difference:   ms.GetDifference(),
+		Ceiling:      ms.GetCeiling(),
+		Balanced:     ms.GetBalanced(),
+	}
+}
+
+func toStatement(st payment.Statement) *pb.Statement {
+	res := &pb.Statement{
+		Account:        toAccount(st.Account),
+		OpeningBalance: st.Opening,
+		ClosingBalance: st.Closing,
+	}
+
+	for _, l := range st.Lines {
+		res.Lines = append(res.Lines, &pb.StatementLine{
+			TransactionId: l.Id,
+			Type:          l.Type,
+			Source:        l.Source,
+			Destination:   l.Dest,
+			Debit:         l.Debit,
+			Credit:        l.Credit,
+			Balance:       l.Balance,
+			Time:          timestamppb.New(l.Time),
+		})
+	}
+
+	return res
+}
+
+// FromAccount converts the account received from the PaymentService.
+func FromAccount(a *pb.Account) payment.Account {
+	res := payment.Account{
+		CustomerId:   a.GetCustomerId(),
+		Num:          a.GetNumber(),
+		CurrencyCode: a.GetCurrencyCode(),
+		Balance:      a.GetBalance(),
+		Reserved:     a.GetReserved(),
+		Description:  a.GetDescription(),
+		Version:      int(a.GetVersion()),
+	}
+
+	switch a.GetStatus() {
+	case pb.AccountStatus_ACCOUNT_STATUS_ACTIVE:
+		res.Status = payment.Active
+	case pb.AccountStatus_ACCOUNT_STATUS_BLOCKED:
+		res.Status = payment.Blocked
+	}
+
+	if a.GetCreatedAt() != nil {
+		res.CreatedAt = a.GetCreatedAt().AsTime()
+	}
+
+	return res
+}
+
+// FromStatement converts the statement received from the PaymentService.
+func FromStatement(st *pb.Statement) payment.Statement {
+	res := payment.Statement{
+		Account: FromAccount(st.GetAccount()),
+		Opening: st.GetOpeningBalance(),
+		Closing: st.GetClosingBalance(),
+	}
+
+	for _, l := range st.GetLines() {
+		res.Lines = append(res.Lines, payment.StatementLine{
+			Transaction: payment.Transaction{
+				Id:       l.GetTransactionId(),
+				Type:     l.GetType(),
+				Source:   l.GetSource(),
+				Dest:     l.GetDestination(),
+				Currency: res.Account.CurrencyCode,
+				Amount:   l.GetDebit() + l.GetCredit(),
+				Time:     l.GetTime().AsTime(),
+			},
+			Debit:   l.GetDebit(),
+			Credit:  l.GetCredit(),
+			Balance: l.GetBalance(),
+		})
+	}
+
+	return res
+}
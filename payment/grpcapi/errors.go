@@ -0,0 +1,51 @@
+package grpcapi
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorCodes maps the payment errors to gRPC status codes.
+var errorCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{payment.ErrInsufficientFunds, codes.FailedPrecondition},
+	{payment.ErrLimitExceeded, codes.ResourceExhausted},
+	{payment.ErrTransferDenied, codes.PermissionDenied},
+	{payment.ErrCustomerBlocked, codes.PermissionDenied},
+	{payment.ErrApproverNotAllowed, codes.PermissionDenied},
+	{payment.ErrApproverIsInitiator, codes.PermissionDenied},
+	{payment.ErrReviewerNotAllowed, codes.PermissionDenied},
+	{payment.ErrTransferNotFound, codes.NotFound},
+	{payment.ErrCustomerNotFound, codes.NotFound},
+	{payment.ErrTransferNotPending, codes.FailedPrecondition},
+	{payment.ErrTransferNotInReview, codes.FailedPrecondition},
+	{payment.ErrCustomerNotInReview, codes.FailedPrecondition},
+	{payment.ErrConflict, codes.Aborted},
+	{payment.ErrEmissionNotAuthorized, codes.PermissionDenied},
+	{payment.ErrEmissionCeiling, codes.ResourceExhausted},
+	{payment.ErrDuplicateReference, codes.AlreadyExists},
+}
+
+/*
+This func converts the error of the store to a gRPC status.
+Arguments are validated by the server before calling the store,
+so the remaining errors reject the operation in the current state
+of the accounts (e.g. blocked account) and become FailedPrecondition.
+*/
+func toStatus(err error) error {
+	msg := strings.TrimSpace(err.Error())
+
+	for _, ec := range errorCodes {
+		if errors.Is(err, ec.err) {
+			return status.Error(ec.code, msg)
+		}
+	}
+
+	return status.Error(codes.FailedPrecondition, msg)
+}
@@ -0,0 +1,90 @@
+package grpcapi
+
+import (
+	"slices"
+
+	"github.com/soundrise/go-payment-system/payment"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type subscriber struct {
+	id       int
+	customer string
+	accounts []string
+	events   chan *pb.AccountEvent
+}
+
+func (sub *subscriber) match(a payment.Account) bool {
+	if sub.customer != "" && sub.customer != a.CustomerId {
+		return false
+	}
+
+	return len(sub.accounts) == 0 || slices.Contains(sub.accounts, a.Num)
+}
+
+func (s *Server) subscribe(req *pb.WatchAccountEventsRequest) *subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+
+	sub := &subscriber{
+		id:       s.seq,
+		customer: req.GetCustomerId(),
+		accounts: req.GetAccountNumbers(),
+		events:   make(chan *pb.AccountEvent, SubscriberBuffer),
+	}
+
+	s.subscribers[sub.id] = sub
+
+	return sub
+}
+
+func (s *Server) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.subscribers, sub.id)
+}
+
+/*
+This func sends the event to all matching subscribers without blocking.
+A subscriber whose buffer is full is dropped and its stream is closed.
+*/
+func (s *Server) publish(t pb.AccountEventType, a payment.Account, amount float32) {
+	e := &pb.AccountEvent{
+		Type:    t,
+		Account: toAccount(a),
+		Amount:  amount,
+		Time:    timestamppb.New(s.now()),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, sub := range s.subscribers {
+		if !sub.match(a) {
+			continue
+		}
+
+		select {
+		case sub.events <- e:
+		default:
+			s.logger.Warn("account event subscriber is too slow", "subscriber", id, "account", a.Num)
+
+			delete(s.subscribers, id)
+			close(sub.events)
+		}
+	}
+}
+
+// publishChange publishes the change of the balance between two states of the account.
+func (s *Server) publishChange(before payment.Account, after payment.Account) {
+	switch delta := after.Balance - before.Balance; {
+	case delta > 0:
+		s.publish(pb.AccountEventType_ACCOUNT_EVENT_TYPE_CREDITED, after, delta)
+	case delta < 0:
+		s.publish(pb.AccountEventType_ACCOUNT_EVENT_TYPE_DEBITED, after, -delta)
+	}
+}
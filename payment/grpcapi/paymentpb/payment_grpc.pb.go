@@ -0,0 +1,826 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.4.0
+// - protoc             v5.27.0
+// source: payment.proto
+
+package paymentpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.62.0 or later.
+const _ = grpc.SupportPackageIsVersion8
+
+const (
+	PaymentService_CreateAccount_FullMethodName      = "/payment.v1.PaymentService/CreateAccount"
+	PaymentService_GetAccount_FullMethodName         = "/payment.v1.PaymentService/GetAccount"
+	PaymentService_FindAccount_FullMethodName        = "/payment.v1.PaymentService/FindAccount"
+	PaymentService_GetSpecialAccount_FullMethodName  = "/payment.v1.PaymentService/GetSpecialAccount"
+	PaymentService_CloseAccount_FullMethodName       = "/payment.v1.PaymentService/CloseAccount"
+	PaymentService_ActivateAccount_FullMethodName    = "/payment.v1.PaymentService/ActivateAccount"
+	PaymentService_ListAccounts_FullMethodName       = "/payment.v1.PaymentService/ListAccounts"
+	PaymentService_CreateCustomer_FullMethodName     = "/payment.v1.PaymentService/CreateCustomer"
+	PaymentService_GetCustomer_FullMethodName        = "/payment.v1.PaymentService/GetCustomer"
+	PaymentService_Emit_FullMethodName               = "/payment.v1.PaymentService/Emit"
+	PaymentService_Terminate_FullMethodName          = "/payment.v1.PaymentService/Terminate"
+	PaymentService_Disburse_FullMethodName           = "/payment.v1.PaymentService/Disburse"
+	PaymentService_Transfer_FullMethodName           = "/payment.v1.PaymentService/Transfer"
+	PaymentService_GetStatement_FullMethodName       = "/payment.v1.PaymentService/GetStatement"
+	PaymentService_GetMoneySupply_FullMethodName     = "/payment.v1.PaymentService/GetMoneySupply"
+	PaymentService_DumpStore_FullMethodName          = "/payment.v1.PaymentService/DumpStore"
+	PaymentService_RestoreStore_FullMethodName       = "/payment.v1.PaymentService/RestoreStore"
+	PaymentService_WatchAccountEvents_FullMethodName = "/payment.v1.PaymentService/WatchAccountEvents"
+)
+
+// PaymentServiceClient is the client API for PaymentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PaymentService exposes the payment store: accounts, customers,
+// transfers, emission and termination of money.
+type PaymentServiceClient interface {
+	// CreateAccount opens an account for the customer and returns it.
+	CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	// GetAccount returns the account of the customer by its number.
+	GetAccount(ctx context.Context, in *AccountRef, opts ...grpc.CallOption) (*Account, error)
+	// FindAccount returns the account of the customer in the currency.
+	FindAccount(ctx context.Context, in *FindAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	// GetSpecialAccount returns the state emission or termination account.
+	GetSpecialAccount(ctx context.Context, in *GetSpecialAccountRequest, opts ...grpc.CallOption) (*Account, error)
+	// CloseAccount blocks the account.
+	CloseAccount(ctx context.Context, in *AccountRef, opts ...grpc.CallOption) (*Account, error)
+	// ActivateAccount unblocks the account.
+	ActivateAccount(ctx context.Context, in *AccountRef, opts ...grpc.CallOption) (*Account, error)
+	// ListAccounts returns the accounts of a customer or of all customers.
+	ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error)
+	// CreateCustomer registers a customer without accounts.
+	CreateCustomer(ctx context.Context, in *Customer, opts ...grpc.CallOption) (*Customer, error)
+	// GetCustomer returns a customer known to the store.
+	GetCustomer(ctx context.Context, in *GetCustomerRequest, opts ...grpc.CallOption) (*Customer, error)
+	// Emit adds money to the emission account of the currency.
+	Emit(ctx context.Context, in *EmitRequest, opts ...grpc.CallOption) (*Account, error)
+	// Terminate moves money from the account to the termination account.
+	Terminate(ctx context.Context, in *TerminateRequest, opts ...grpc.CallOption) (*Account, error)
+	// Disburse moves emitted money from the emission account to a customer account.
+	Disburse(ctx context.Context, in *DisburseRequest, opts ...grpc.CallOption) (*Account, error)
+	// Transfer moves money between two accounts in the same currency.
+	Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error)
+	// GetStatement returns the movements of the account in the period.
+	GetStatement(ctx context.Context, in *GetStatementRequest, opts ...grpc.CallOption) (*Statement, error)
+	// GetMoneySupply returns emitted, terminated and circulating money per currency.
+	GetMoneySupply(ctx context.Context, in *GetMoneySupplyRequest, opts ...grpc.CallOption) (*GetMoneySupplyResponse, error)
+	// DumpStore returns the accounts of the store encoded to JSON.
+	DumpStore(ctx context.Context, in *DumpStoreRequest, opts ...grpc.CallOption) (*DumpStoreResponse, error)
+	// RestoreStore replaces the accounts of the store with a dump.
+	RestoreStore(ctx context.Context, in *RestoreStoreRequest, opts ...grpc.CallOption) (*RestoreStoreResponse, error)
+	// WatchAccountEvents streams changes of accounts until the client cancels.
+	WatchAccountEvents(ctx context.Context, in *WatchAccountEventsRequest, opts ...grpc.CallOption) (PaymentService_WatchAccountEventsClient, error)
+}
+
+type paymentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPaymentServiceClient(cc grpc.ClientConnInterface) PaymentServiceClient {
+	return &paymentServiceClient{cc}
+}
+
+func (c *paymentServiceClient) CreateAccount(ctx context.Context, in *CreateAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_CreateAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetAccount(ctx context.Context, in *AccountRef, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) FindAccount(ctx context.Context, in *FindAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_FindAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetSpecialAccount(ctx context.Context, in *GetSpecialAccountRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_GetSpecialAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) CloseAccount(ctx context.Context, in *AccountRef, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_CloseAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ActivateAccount(ctx context.Context, in *AccountRef, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_ActivateAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) ListAccounts(ctx context.Context, in *ListAccountsRequest, opts ...grpc.CallOption) (*ListAccountsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAccountsResponse)
+	err := c.cc.Invoke(ctx, PaymentService_ListAccounts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) CreateCustomer(ctx context.Context, in *Customer, opts ...grpc.CallOption) (*Customer, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Customer)
+	err := c.cc.Invoke(ctx, PaymentService_CreateCustomer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetCustomer(ctx context.Context, in *GetCustomerRequest, opts ...grpc.CallOption) (*Customer, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Customer)
+	err := c.cc.Invoke(ctx, PaymentService_GetCustomer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Emit(ctx context.Context, in *EmitRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_Emit_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Terminate(ctx context.Context, in *TerminateRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_Terminate_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Disburse(ctx context.Context, in *DisburseRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, PaymentService_Disburse_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) Transfer(ctx context.Context, in *TransferRequest, opts ...grpc.CallOption) (*TransferResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransferResponse)
+	err := c.cc.Invoke(ctx, PaymentService_Transfer_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetStatement(ctx context.Context, in *GetStatementRequest, opts ...grpc.CallOption) (*Statement, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Statement)
+	err := c.cc.Invoke(ctx, PaymentService_GetStatement_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) GetMoneySupply(ctx context.Context, in *GetMoneySupplyRequest, opts ...grpc.CallOption) (*GetMoneySupplyResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetMoneySupplyResponse)
+	err := c.cc.Invoke(ctx, PaymentService_GetMoneySupply_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) DumpStore(ctx context.Context, in *DumpStoreRequest, opts ...grpc.CallOption) (*DumpStoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DumpStoreResponse)
+	err := c.cc.Invoke(ctx, PaymentService_DumpStore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) RestoreStore(ctx context.Context, in *RestoreStoreRequest, opts ...grpc.CallOption) (*RestoreStoreResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RestoreStoreResponse)
+	err := c.cc.Invoke(ctx, PaymentService_RestoreStore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *paymentServiceClient) WatchAccountEvents(ctx context.Context, in *WatchAccountEventsRequest, opts ...grpc.CallOption) (PaymentService_WatchAccountEventsClient, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PaymentService_ServiceDesc.Streams[0], PaymentService_WatchAccountEvents_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &paymentServiceWatchAccountEventsClient{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type PaymentService_WatchAccountEventsClient interface {
+	Recv() (*AccountEvent, error)
+	grpc.ClientStream
+}
+
+type paymentServiceWatchAccountEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *paymentServiceWatchAccountEventsClient) Recv() (*AccountEvent, error) {
+	m := new(AccountEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// PaymentServiceServer is the server API for PaymentService service.
+// All implementations must embed UnimplementedPaymentServiceServer
+// for forward compatibility
+//
+// PaymentService exposes the payment store: accounts, customers,
+// transfers, emission and termination of money.
+type PaymentServiceServer interface {
+	// CreateAccount opens an account for the customer and returns it.
+	CreateAccount(context.Context, *CreateAccountRequest) (*Account, error)
+	// GetAccount returns the account of the customer by its number.
+	GetAccount(context.Context, *AccountRef) (*Account, error)
+	// FindAccount returns the account of the customer in the currency.
+	FindAccount(context.Context, *FindAccountRequest) (*Account, error)
+	// GetSpecialAccount returns the state emission or termination account.
+	GetSpecialAccount(context.Context, *GetSpecialAccountRequest) (*Account, error)
+	// CloseAccount blocks the account.
+	CloseAccount(context.Context, *AccountRef) (*Account, error)
+	// ActivateAccount unblocks the account.
+	ActivateAccount(context.Context, *AccountRef) (*Account, error)
+	// ListAccounts returns the accounts of a customer or of all customers.
+	ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error)
+	// CreateCustomer registers a customer without accounts.
+	CreateCustomer(context.Context, *Customer) (*Customer, error)
+	// GetCustomer returns a customer known to the store.
+	GetCustomer(context.Context, *GetCustomerRequest) (*Customer, error)
+	// Emit adds money to the emission account of the currency.
+	Emit(context.Context, *EmitRequest) (*Account, error)
+	// Terminate moves money from the account to the termination account.
+	Terminate(context.Context, *TerminateRequest) (*Account, error)
+	// Disburse moves emitted money from the emission account to a customer account.
+	Disburse(context.Context, *DisburseRequest) (*Account, error)
+	// Transfer moves money between two accounts in the same currency.
+	Transfer(context.Context, *TransferRequest) (*TransferResponse, error)
+	// GetStatement returns the movements of the account in the period.
+	GetStatement(context.Context, *GetStatementRequest) (*Statement, error)
+	// GetMoneySupply returns emitted, terminated and circulating money per currency.
+	GetMoneySupply(context.Context, *GetMoneySupplyRequest) (*GetMoneySupplyResponse, error)
+	// DumpStore returns the accounts of the store encoded to JSON.
+	DumpStore(context.Context, *DumpStoreRequest) (*DumpStoreResponse, error)
+	// RestoreStore replaces the accounts of the store with a dump.
+	RestoreStore(context.Context, *RestoreStoreRequest) (*RestoreStoreResponse, error)
+	// WatchAccountEvents streams changes of accounts until the client cancels.
+	WatchAccountEvents(*WatchAccountEventsRequest, PaymentService_WatchAccountEventsServer) error
+	mustEmbedUnimplementedPaymentServiceServer()
+}
+
+// UnimplementedPaymentServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedPaymentServiceServer struct {
+}
+
+func (UnimplementedPaymentServiceServer) CreateAccount(context.Context, *CreateAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetAccount(context.Context, *AccountRef) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) FindAccount(context.Context, *FindAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetSpecialAccount(context.Context, *GetSpecialAccountRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSpecialAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) CloseAccount(context.Context, *AccountRef) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CloseAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) ActivateAccount(context.Context, *AccountRef) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActivateAccount not implemented")
+}
+func (UnimplementedPaymentServiceServer) ListAccounts(context.Context, *ListAccountsRequest) (*ListAccountsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAccounts not implemented")
+}
+func (UnimplementedPaymentServiceServer) CreateCustomer(context.Context, *Customer) (*Customer, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCustomer not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetCustomer(context.Context, *GetCustomerRequest) (*Customer, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCustomer not implemented")
+}
+func (UnimplementedPaymentServiceServer) Emit(context.Context, *EmitRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Emit not implemented")
+}
+func (UnimplementedPaymentServiceServer) Terminate(context.Context, *TerminateRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Terminate not implemented")
+}
+func (UnimplementedPaymentServiceServer) Disburse(context.Context, *DisburseRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Disburse not implemented")
+}
+func (UnimplementedPaymentServiceServer) Transfer(context.Context, *TransferRequest) (*TransferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Transfer not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetStatement(context.Context, *GetStatementRequest) (*Statement, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatement not implemented")
+}
+func (UnimplementedPaymentServiceServer) GetMoneySupply(context.Context, *GetMoneySupplyRequest) (*GetMoneySupplyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMoneySupply not implemented")
+}
+func (UnimplementedPaymentServiceServer) DumpStore(context.Context, *DumpStoreRequest) (*DumpStoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DumpStore not implemented")
+}
+func (UnimplementedPaymentServiceServer) RestoreStore(context.Context, *RestoreStoreRequest) (*RestoreStoreResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestoreStore not implemented")
+}
+func (UnimplementedPaymentServiceServer) WatchAccountEvents(*WatchAccountEventsRequest, PaymentService_WatchAccountEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchAccountEvents not implemented")
+}
+func (UnimplementedPaymentServiceServer) mustEmbedUnimplementedPaymentServiceServer() {}
+
+// UnsafePaymentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PaymentServiceServer will
+// result in compilation errors.
+type UnsafePaymentServiceServer interface {
+	mustEmbedUnimplementedPaymentServiceServer()
+}
+
+func RegisterPaymentServiceServer(s grpc.ServiceRegistrar, srv PaymentServiceServer) {
+	s.RegisterService(&PaymentService_ServiceDesc, srv)
+}
+
+func _PaymentService_CreateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CreateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_CreateAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CreateAccount(ctx, req.(*CreateAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetAccount(ctx, req.(*AccountRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_FindAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).FindAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_FindAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).FindAccount(ctx, req.(*FindAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetSpecialAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSpecialAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetSpecialAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetSpecialAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetSpecialAccount(ctx, req.(*GetSpecialAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_CloseAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CloseAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_CloseAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CloseAccount(ctx, req.(*AccountRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ActivateAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AccountRef)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ActivateAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_ActivateAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ActivateAccount(ctx, req.(*AccountRef))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_ListAccounts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAccountsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).ListAccounts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_ListAccounts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).ListAccounts(ctx, req.(*ListAccountsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_CreateCustomer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Customer)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).CreateCustomer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_CreateCustomer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).CreateCustomer(ctx, req.(*Customer))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetCustomer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCustomerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetCustomer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetCustomer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetCustomer(ctx, req.(*GetCustomerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Emit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmitRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Emit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_Emit_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Emit(ctx, req.(*EmitRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Terminate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TerminateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Terminate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_Terminate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Terminate(ctx, req.(*TerminateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Disburse_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DisburseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Disburse(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_Disburse_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Disburse(ctx, req.(*DisburseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_Transfer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).Transfer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_Transfer_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).Transfer(ctx, req.(*TransferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetStatement_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatementRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetStatement(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetStatement_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetStatement(ctx, req.(*GetStatementRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_GetMoneySupply_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMoneySupplyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).GetMoneySupply(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_GetMoneySupply_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).GetMoneySupply(ctx, req.(*GetMoneySupplyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_DumpStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DumpStoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).DumpStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_DumpStore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).DumpStore(ctx, req.(*DumpStoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_RestoreStore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RestoreStoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PaymentServiceServer).RestoreStore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PaymentService_RestoreStore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PaymentServiceServer).RestoreStore(ctx, req.(*RestoreStoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PaymentService_WatchAccountEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchAccountEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PaymentServiceServer).WatchAccountEvents(m, &paymentServiceWatchAccountEventsServer{ServerStream: stream})
+}
+
+type PaymentService_WatchAccountEventsServer interface {
+	Send(*AccountEvent) error
+	grpc.ServerStream
+}
+
+type paymentServiceWatchAccountEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *paymentServiceWatchAccountEventsServer) Send(m *AccountEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// PaymentService_ServiceDesc is the grpc.ServiceDesc for PaymentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PaymentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "payment.v1.PaymentService",
+	HandlerType: (*PaymentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateAccount",
+			Handler:    _PaymentService_CreateAccount_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _PaymentService_GetAccount_Handler,
+		},
+		{
+			MethodName: "FindAccount",
+			Handler:    _PaymentService_FindAccount_Handler,
+		},
+		{
+			MethodName: "GetSpecialAccount",
+			Handler:    _PaymentService_GetSpecialAccount_Handler,
+		},
+		{
+			MethodName: "CloseAccount",
+			Handler:    _PaymentService_CloseAccount_Handler,
+		},
+		{
+			MethodName: "ActivateAccount",
+			Handler:    _PaymentService_ActivateAccount_Handler,
+		},
+		{
+			MethodName: "ListAccounts",
+			Handler:    _PaymentService_ListAccounts_Handler,
+		},
+		{
+			MethodName: "CreateCustomer",
+			Handler:    _PaymentService_CreateCustomer_Handler,
+		},
+		{
+			MethodName: "GetCustomer",
+			Handler:    _PaymentService_GetCustomer_Handler,
+		},
+		{
+			MethodName: "Emit",
+			Handler:    _PaymentService_Emit_Handler,
+		},
+		{
+			MethodName: "Terminate",
+			Handler:    _PaymentService_Terminate_Handler,
+		},
+		{
+			MethodName: "Disburse",
+			Handler:    _PaymentService_Disburse_Handler,
+		},
+		{
+			MethodName: "Transfer",
+			Handler:    _PaymentService_Transfer_Handler,
+		},
+		{
+			MethodName: "GetStatement",
+			Handler:    _PaymentService_GetStatement_Handler,
+		},
+		{
+			MethodName: "GetMoneySupply",
+			Handler:    _PaymentService_GetMoneySupply_Handler,
+		},
+		{
+			MethodName: "DumpStore",
+			Handler:    _PaymentService_DumpStore_Handler,
+		},
+		{
+			MethodName: "RestoreStore",
+			Handler:    _PaymentService_RestoreStore_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchAccountEvents",
+			Handler:       _PaymentService_WatchAccountEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "payment.proto",
+}
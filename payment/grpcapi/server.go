@@ -0,0 +1,516 @@
+// Package grpcapi serves the payment store over gRPC.
+package grpcapi
+
+//go:generate protoc -I paymentpb --go_out=paymentpb --go_opt=paths=source_relative --go-grpc_out=paymentpb --go-grpc_opt=paths=source_relative payment.proto
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// SubscriberBuffer is the number of events kept for a slow subscriber
+// before its stream is closed.
+const SubscriberBuffer = 64
+
+/*
+This struct implements the PaymentService on top of the Store.
+Every call holds the store lock, so calls are serialized with the PaymentController.
+*/
+type Server struct {
+	pb.UnimplementedPaymentServiceServer
+
+	store  payment.Store
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu          sync.Mutex
+	seq         int
+	subscribers map[int]*subscriber
+}
+
+// ServerOption configures optional behaviour of the Server.
+type ServerOption func(s *Server)
+
+// WithLogger sets the logger of the Server. slog.Default() is used otherwise.
+func WithLogger(l *slog.Logger) ServerOption {
+	return func(s *Server) {
+		s.logger = l
+	}
+}
+
+// WithClock replaces the time source of event timestamps.
+func WithClock(now func() time.Time) ServerOption {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+func NewServer(store payment.Store, opts ...ServerOption) *Server {
+	s := &Server{
+		store:       store,
+		now:         time.Now,
+		logger:      slog.Default(),
+		subscribers: make(map[int]*subscriber),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+func (s *Server) lock() func() {
+	_ = s.store.Lock()
+
+	return func() { _ = s.store.Unlock() }
+}
+
+func (s *Server) CreateAccount(_ context.Context, req *pb.CreateAccountRequest) (*pb.Account, error) {
+	defer s.lock()()
+
+	if req.GetCustomer().GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "customer id is required")
+	}
+
+	if req.GetCurrencyCode() == "" {
+		return nil, status.Error(codes.InvalidArgument, "currency code is required")
+	}
+
+	if req.GetAmount() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must not be negative")
+	}
+
+	prefix, err := accountPrefix(req.GetType(), true)
+	if err != nil {
+		return nil, err
+	}
+
+	c := payment.NewCustomer(req.GetCustomer().GetId(), req.GetCustomer().GetName(), prefix)
+
+	if err := s.store.CreateAccount(c, prefix, req.GetCurrencyCode(), req.GetAmount()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	var a payment.Account
+
+	if prefix == payment.AccountPrefix {
+		a, err = s.store.FindAccount(c, req.GetCurrencyCode())
+	} else {
+		a, err = s.store.GetSpecialAccountIn(prefix, req.GetCurrencyCode())
+	}
+
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.publish(pb.AccountEventType_ACCOUNT_EVENT_TYPE_CREATED, a, a.Balance)
+
+	return toAccount(a), nil
+}
+
+func (s *Server) GetAccount(_ context.Context, ref *pb.AccountRef) (*pb.Account, error) {
+	defer s.lock()()
+
+	a, err := s.account(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccount(a), nil
+}
+
+func (s *Server) FindAccount(_ context.Context, req *pb.FindAccountRequest) (*pb.Account, error) {
+	defer s.lock()()
+
+	a, err := s.store.FindAccount(payment.NewCustomer(req.GetCustomerId(), "", ""), req.GetCurrencyCode())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "account of customer %s in %s not found", req.GetCustomerId(), req.GetCurrencyCode())
+	}
+
+	return toAccount(a), nil
+}
+
+func (s *Server) GetSpecialAccount(_ context.Context, req *pb.GetSpecialAccountRequest) (*pb.Account, error) {
+	defer s.lock()()
+
+	prefix, err := accountPrefix(req.GetType(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	a, err := s.store.GetSpecialAccountIn(prefix, currency(req.GetCurrencyCode()))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "special account %s not found", prefix)
+	}
+
+	return toAccount(a), nil
+}
+
+func (s *Server) CloseAccount(_ context.Context, ref *pb.AccountRef) (*pb.Account, error) {
+	defer s.lock()()
+
+	a, err := s.account(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.CloseAccount(a); err != nil {
+		return nil, toStatus(err)
+	}
+
+	a, err = s.account(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(pb.AccountEventType_ACCOUNT_EVENT_TYPE_BLOCKED, a, 0)
+
+	return toAccount(a), nil
+}
+
+func (s *Server) ActivateAccount(_ context.Context, ref *pb.AccountRef) (*pb.Account, error) {
+	defer s.lock()()
+
+	a, err := s.account(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.store.ActivateAccount(a); err != nil {
+		return nil, toStatus(err)
+	}
+
+	a, err = s.account(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	s.publish(pb.AccountEventType_ACCOUNT_EVENT_TYPE_ACTIVATED, a, 0)
+
+	return toAccount(a), nil
+}
+
+func (s *Server) ListAccounts(_ context.Context, req *pb.ListAccountsRequest) (*pb.ListAccountsResponse, error) {
+	defer s.lock()()
+
+	res := &pb.ListAccountsResponse{}
+
+	for _, a := range s.store.Accounts(req.GetCustomerId()) {
+		res.Accounts = append(res.Accounts, toAccount(a))
+	}
+
+	return res, nil
+}
+
+func (s *Server) CreateCustomer(_ context.Context, req *pb.Customer) (*pb.Customer, error) {
+	defer s.lock()()
+
+	if req.GetId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "customer id is required")
+	}
+
+	if err := s.store.CreateCustomer(payment.NewCustomer(req.GetId(), req.GetName(), payment.AccountPrefix)); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.Customer{Id: req.GetId(), Name: req.GetName()}, nil
+}
+
+func (s *Server) GetCustomer(_ context.Context, req *pb.GetCustomerRequest) (*pb.Customer, error) {
+	defer s.lock()()
+
+	c, err := s.store.GetCustomer(req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &pb.Customer{Id: c.Id, Name: c.Name}, nil
+}
+
+func (s *Server) Emit(_ context.Context, req *pb.EmitRequest) (*pb.Account, error) {
+	defer s.lock()()
+
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+
+	cur := currency(req.GetCurrencyCode())
+
+	before, err := s.store.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, cur)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "emission account in %s is not created", cur)
+	}
+
+	if err := s.store.EmitIn(cur, req.GetAmount()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	after, err := s.store.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, cur)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	s.publishChange(before, after)
+
+	return toAccount(after), nil
+}
+
+func (s *Server) Terminate(_ context.Context, req *pb.TerminateRequest) (*pb.Account, error) {
+	defer s.lock()()
+
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+
+	a, err := s.account(req.GetAccount())
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.store.GetSpecialAccountIn(payment.AccountStateTerminatePrefix, a.CurrencyCode)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "termination account in %s is not created", a.CurrencyCode)
+	}
+
+	if err := s.store.Terminate(a, req.GetAmount()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	a, _, err = s.afterMovement(a, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccount(a), nil
+}
+
+func (s *Server) Disburse(_ context.Context, req *pb.DisburseRequest) (*pb.Account, error) {
+	defer s.lock()()
+
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+
+	if req.GetReference() == "" {
+		return nil, status.Error(codes.InvalidArgument, "reference is required")
+	}
+
+	a, err := s.account(req.GetAccount())
+	if err != nil {
+		return nil, err
+	}
+
+	se, err := s.store.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, a.CurrencyCode)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "emission account in %s is not created", a.CurrencyCode)
+	}
+
+	if err := s.store.Disburse(a, req.GetAmount(), req.GetReference()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	_, a, err = s.afterMovement(se, a)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAccount(a), nil
+}
+
+func (s *Server) Transfer(_ context.Context, req *pb.TransferRequest) (*pb.TransferResponse, error) {
+	defer s.lock()()
+
+	if req.GetAmount() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "amount must be positive")
+	}
+
+	src, err := s.account(req.GetSource())
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := s.account(req.GetDestination())
+	if err != nil {
+		return nil, err
+	}
+
+	if src.CurrencyCode != dst.CurrencyCode {
+		return nil, status.Errorf(codes.InvalidArgument, "currency of source %s differs from destination %s", src.CurrencyCode, dst.CurrencyCode)
+	}
+
+	if err := s.store.Transfer(src, dst, req.GetAmount()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	src, dst, err = s.afterMovement(src, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.TransferResponse{Source: toAccount(src), Destination: toAccount(dst)}, nil
+}
+
+func (s *Server) GetStatement(_ context.Context, req *pb.GetStatementRequest) (*pb.Statement, error) {
+	defer s.lock()()
+
+	a, err := s.account(req.GetAccount())
+	if err != nil {
+		return nil, err
+	}
+
+	var from, to time.Time
+
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	st, err := s.store.Statement(payment.NewCustomer(a.CustomerId, "", ""), a.Num, from, to)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toStatement(st), nil
+}
+
+func (s *Server) GetMoneySupply(context.Context, *pb.GetMoneySupplyRequest) (*pb.GetMoneySupplyResponse, error) {
+	defer s.lock()()
+
+	res := &pb.GetMoneySupplyResponse{}
+
+	for _, ms := range s.store.MoneySupply() {
+		res.Currencies = append(res.Currencies, toMoneySupply(ms))
+	}
+
+	return res, nil
+}
+
+func (s *Server) DumpStore(context.Context, *pb.DumpStoreRequest) (*pb.DumpStoreResponse, error) {
+	defer s.lock()()
+
+	data, err := s.store.DumpStore()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pb.DumpStoreResponse{Data: data}, nil
+}
+
+func (s *Server) RestoreStore(_ context.Context, req *pb.RestoreStoreRequest) (*pb.RestoreStoreResponse, error) {
+	defer s.lock()()
+
+	if err := s.store.Restore(req.GetData()); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "cannot restore store: %v", err)
+	}
+
+	return &pb.RestoreStoreResponse{}, nil
+}
+
+// afterMovement reads both accounts again and publishes the changes of their balances.
+func (s *Server) afterMovement(src payment.Account, dst payment.Account) (payment.Account, payment.Account, error) {
+	var after [2]payment.Account
+
+	for i, a := range []payment.Account{src, dst} {
+		res, err := s.store.GetAccountByNumber(payment.NewCustomer(a.CustomerId, "", ""), a.Num)
+		if err != nil {
+			return src, dst, status.Error(codes.Internal, err.Error())
+		}
+
+		s.publishChange(a, res)
+		after[i] = res
+	}
+
+	return after[0], after[1], nil
+}
+
+// account resolves the reference to the current state of the account.
+// A reference without customer id, e.g. of a payment, is resolved by the number.
+func (s *Server) account(ref *pb.AccountRef) (payment.Account, error) {
+	if ref.GetNumber() == "" {
+		return payment.Account{}, status.Error(codes.InvalidArgument, "account number is required")
+	}
+
+	var a payment.Account
+	var err error
+
+	if ref.GetCustomerId() == "" {
+		a, err = s.store.AccountByNumber(ref.GetNumber())
+	} else {
+		a, err = s.store.GetAccountByNumber(payment.NewCustomer(ref.GetCustomerId(), "", ""), ref.GetNumber())
+	}
+
+	if err != nil || a.Num == "" {
+		return a, status.Errorf(codes.NotFound, "account %s of customer %q not found", ref.GetNumber(), ref.GetCustomerId())
+	}
+
+	if v := ref.GetExpectedVersion(); v != 0 && v != int64(a.Version) {
+		return a, status.Errorf(codes.Aborted, "account %s has version %d, expected %d", a.Num, a.Version, v)
+	}
+
+	return a, nil
+}
+
+// currency returns the currency of the request, the default currency when it is empty.
+func currency(code string) string {
+	if code == "" {
+		return payment.DefaultCurrency
+	}
+
+	return code
+}
+
+func accountPrefix(t pb.AccountType, customer bool) (string, error) {
+	switch t {
+	case pb.AccountType_ACCOUNT_TYPE_EMISSION:
+		return payment.AccountStateEmissionPrefix, nil
+	case pb.AccountType_ACCOUNT_TYPE_TERMINATION:
+		return payment.AccountStateTerminatePrefix, nil
+	case pb.AccountType_ACCOUNT_TYPE_UNSPECIFIED, pb.AccountType_ACCOUNT_TYPE_CUSTOMER:
+		if customer {
+			return payment.AccountPrefix, nil
+		}
+	}
+
+	return "", status.Errorf(codes.InvalidArgument, "unsupported account type %s", t)
+}
+
+/*
+This func streams account events matching the request until the client cancels.
+Response headers are sent once the subscription is registered,
+so a client waiting for them does not miss the following events.
+*/
+func (s *Server) WatchAccountEvents(req *pb.WatchAccountEventsRequest, stream pb.PaymentService_WatchAccountEventsServer) error {
+	sub := s.subscribe(req)
+	defer s.unsubscribe(sub)
+
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-sub.events:
+			if !ok {
+				return status.Error(codes.ResourceExhausted, "subscriber is too slow, events were dropped")
+			}
+
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package grpcapi_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/grpcapi"
+	pb "github.com/soundrise/go-payment-system/payment/grpcapi/paymentpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const (
+	sourceNum = "BY11ABCD00000000000000000001"
+	destNum   = "BY22ABCD00000000000000000002"
+	usdNum    = "BY33ABCD00000000000000000003"
+)
+
+const seedStore = `{
+	"0": {
+		"SE": {"customer_id": "0", "num": "` + payment.AccountStateEmissionNumber + `", "currency_code": "BYN", "status": "active", "balance": 5000},
+		"ST": {"customer_id": "0", "num": "` + payment.AccountStateTerminateNumber + `", "currency_code": "BYN", "status": "active", "balance": 0}
+	},
+	"1": {"K1": {"customer_id": "1", "num": "` + sourceNum + `", "currency_code": "BYN", "status": "active", "balance": 1000}},
+	"2": {
+		"K2": {"customer_id": "2", "num": "` + destNum + `", "currency_code": "BYN", "status": "active", "balance": 0},
+		"K3": {"customer_id": "2", "num": "` + usdNum + `", "currency_code": "USD", "status": "active", "balance": 50}
+	}
+}`
+
+func newClient(t *testing.T, ps *payment.PaymentSystem) pb.PaymentServiceClient {
+	t.Helper()
+
+	if ps == nil {
+		ps = payment.NewPaymentSystem()
+		require.NoError(t, ps.Restore([]byte(seedStore)))
+	}
+
+	lis := bufconn.Listen(1024 * 1024)
+
+	gs := grpc.NewServer()
+	pb.RegisterPaymentServiceServer(gs, grpcapi.NewServer(ps))
+
+	go func() { _ = gs.Serve(lis) }()
+
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewPaymentServiceClient(conn)
+}
+
+func ref(cid string, num string) *pb.AccountRef {
+	return &pb.AccountRef{CustomerId: cid, Number: num}
+}
+
+func TestServer_Transfer(t *testing.T) {
+	client := newClient(t, nil)
+	ctx := context.Background()
+
+	res, err := client.Transfer(ctx, &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", destNum), Amount: 300})
+	require.NoError(t, err)
+	assert.Equal(t, float32(700), res.GetSource().GetBalance())
+	assert.Equal(t, float32(300), res.GetDestination().GetBalance())
+	assert.Equal(t, pb.AccountStatus_ACCOUNT_STATUS_ACTIVE, res.GetSource().GetStatus())
+
+	// the destination of a payment is given only by its number
+	res, err = client.Transfer(ctx, &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("", destNum), Amount: 100})
+	require.NoError(t, err)
+	assert.Equal(t, "2", res.GetDestination().GetCustomerId())
+	assert.Equal(t, float32(400), res.GetDestination().GetBalance())
+
+	testCases := []struct {
+		desc string
+		req  *pb.TransferRequest
+		code codes.Code
+	}{
+		{
+			desc: "non positive amount",
+			req:  &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", destNum), Amount: 0},
+			code: codes.InvalidArgument,
+		},
+		{
+			desc: "missing source",
+			req:  &pb.TransferRequest{Destination: ref("2", destNum), Amount: 10},
+			code: codes.InvalidArgument,
+		},
+		{
+			desc: "unknown destination",
+			req:  &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("9", destNum), Amount: 10},
+			code: codes.NotFound,
+		},
+		{
+			desc: "unknown number",
+			req:  &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("", "BY99ABCD00000000000000000099"), Amount: 10},
+			code: codes.NotFound,
+		},
+		{
+			desc: "different currency",
+			req:  &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", usdNum), Amount: 10},
+			code: codes.InvalidArgument,
+		},
+		{
+			desc: "insufficient funds",
+			req:  &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", destNum), Amount: 5000},
+			code: codes.FailedPrecondition,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := client.Transfer(ctx, tt.req)
+			assert.Equal(t, tt.code, status.Code(err), err)
+		})
+	}
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	ps := payment.NewPaymentSystem()
+	require.NoError(t, ps.Restore([]byte(seedStore)))
+	ps.SetAccountLimit(sourceNum, payment.Limit{MaxAmount: 100})
+
+	client := newClient(t, ps)
+	ctx := context.Background()
+
+	_, err := client.Transfer(ctx, &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", destNum), Amount: 200})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err), err)
+
+	_, err = client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: "42"})
+	assert.Equal(t, codes.NotFound, status.Code(err), err)
+
+	_, err = client.GetSpecialAccount(ctx, &pb.GetSpecialAccountRequest{Type: pb.AccountType_ACCOUNT_TYPE_CUSTOMER})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+
+	_, err = client.FindAccount(ctx, &pb.FindAccountRequest{CustomerId: "1", CurrencyCode: payment.EUR})
+	assert.Equal(t, codes.NotFound, status.Code(err), err)
+
+	_, err = client.CloseAccount(ctx, ref("1", sourceNum))
+	require.NoError(t, err)
+
+	_, err = client.Transfer(ctx, &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", destNum), Amount: 10})
+	st := status.Convert(err)
+	assert.Equal(t, codes.FailedPrecondition, st.Code())
+	assert.Equal(t, "Transfer is imposible: account "+sourceNum+" is not valid or blocked", st.Message())
+}
+
+func TestServer_EmitTerminate(t *testing.T) {
+	client := newClient(t, nil)
+	ctx := context.Background()
+
+	e, err := client.Emit(ctx, &pb.EmitRequest{Amount: 1000})
+	require.NoError(t, err)
+	assert.Equal(t, payment.AccountStateEmissionNumber, e.GetNumber())
+	assert.Equal(t, float32(6000), e.GetBalance())
+
+	a, err := client.Terminate(ctx, &pb.TerminateRequest{Account: ref("1", sourceNum), Amount: 400})
+	require.NoError(t, err)
+	assert.Equal(t, float32(600), a.GetBalance())
+
+	st, err := client.GetSpecialAccount(ctx, &pb.GetSpecialAccountRequest{Type: pb.AccountType_ACCOUNT_TYPE_TERMINATION})
+	require.NoError(t, err)
+	assert.Equal(t, float32(400), st.GetBalance())
+
+	_, err = client.Emit(ctx, &pb.EmitRequest{Amount: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+}
+
+func TestServer_CloseActivateAccount(t *testing.T) {
+	client := newClient(t, nil)
+	ctx := context.Background()
+
+	a, err := client.CloseAccount(ctx, ref("2", destNum))
+	require.NoError(t, err)
+	assert.Equal(t, pb.AccountStatus_ACCOUNT_STATUS_BLOCKED, a.GetStatus())
+
+	a, err = client.ActivateAccount(ctx, ref("2", destNum))
+	require.NoError(t, err)
+	assert.Equal(t, pb.AccountStatus_ACCOUNT_STATUS_ACTIVE, a.GetStatus())
+
+	a, err = client.GetAccount(ctx, ref("2", destNum))
+	require.NoError(t, err)
+	assert.Equal(t, pb.AccountStatus_ACCOUNT_STATUS_ACTIVE, a.GetStatus())
+	assert.Equal(t, payment.BYN, a.GetCurrencyCode())
+}
+
+func TestServer_CreateAccount(t *testing.T) {
+	client := newClient(t, nil)
+	ctx := context.Background()
+
+	a, err := client.CreateAccount(ctx, &pb.CreateAccountRequest{
+		Customer:     &pb.Customer{Id: "3", Name: "Customer Three"},
+		CurrencyCode: payment.EUR,
+		Amount:       25,
+	})
+	require.NoError(t, err)
+	assert.NoError(t, payment.VerifyAccountNumber(a.GetNumber()))
+	assert.Equal(t, float32(25), a.GetBalance())
+	assert.NotNil(t, a.GetCreatedAt())
+
+	c, err := client.GetCustomer(ctx, &pb.GetCustomerRequest{Id: "3"})
+	require.NoError(t, err)
+	assert.Equal(t, "Customer Three", c.GetName())
+
+	_, err = client.CreateAccount(ctx, &pb.CreateAccountRequest{CurrencyCode: payment.EUR})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+}
+
+func TestServer_WatchAccountEvents(t *testing.T) {
+	client := newClient(t, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := client.WatchAccountEvents(ctx, &pb.WatchAccountEventsRequest{CustomerId: "2"})
+	require.NoError(t, err)
+
+	// headers are sent once the subscription is registered
+	_, err = stream.Header()
+	require.NoError(t, err)
+
+	_, err = client.Transfer(ctx, &pb.TransferRequest{Source: ref("1", sourceNum), Destination: ref("2", destNum), Amount: 150})
+	require.NoError(t, err)
+
+	_, err = client.CloseAccount(ctx, ref("2", usdNum))
+	require.NoError(t, err)
+
+	e, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.AccountEventType_ACCOUNT_EVENT_TYPE_CREDITED, e.GetType())
+	assert.Equal(t, destNum, e.GetAccount().GetNumber())
+	assert.Equal(t, float32(150), e.GetAmount())
+	assert.Equal(t, float32(150), e.GetAccount().GetBalance())
+
+	// the debit of customer 1 is filtered out
+	e, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.AccountEventType_ACCOUNT_EVENT_TYPE_BLOCKED, e.GetType())
+	assert.Equal(t, usdNum, e.GetAccount().GetNumber())
+}
@@ -0,0 +1,156 @@
+// Package httpapi serves the account queries of the payment store over HTTP.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+// AccountsPath is where the handler serves the account query.
+const AccountsPath = "/accounts"
+
+// Orders of the query parameter order.
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+/*
+This func returns the http.Handler serving GET AccountsPath. The query
+parameters customer_id, currency, status, type, min_balance, max_balance,
+created_from, created_to, sort, order, limit and cursor are the fields
+of payment.AccountQuery, the dates are 2006-01-02 or RFC 3339.
+The response is the payment.AccountPage as JSON.
+*/
+func NewHandler(store payment.Store) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(AccountsPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+
+			return
+		}
+
+		q, err := ParseQuery(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+
+			return
+		}
+
+		_ = store.Lock()
+		page, err := store.QueryAccounts(q)
+		_ = store.Unlock()
+
+		switch {
+		case errors.Is(err, payment.ErrInvalidQuery):
+			writeError(w, http.StatusBadRequest, err)
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusOK, page)
+		}
+	})
+
+	return mux
+}
+
+// ParseQuery reads the account query from the URL query parameters.
+func ParseQuery(v url.Values) (payment.AccountQuery, error) {
+	q := payment.AccountQuery{
+		CustomerId:   v.Get("customer_id"),
+		CurrencyCode: v.Get("currency"),
+		Status:       v.Get("status"),
+		Type:         v.Get("type"),
+		SortBy:       v.Get("sort"),
+		Cursor:       v.Get("cursor"),
+	}
+
+	switch v.Get("order") {
+	case "", OrderAsc:
+	case OrderDesc:
+		q.Desc = true
+	default:
+		return q, fmt.Errorf("%w: order must be %s or %s", payment.ErrInvalidQuery, OrderAsc, OrderDesc)
+	}
+
+	var err error
+
+	if q.MinBalance, err = parseAmount(v, "min_balance"); err != nil {
+		return q, err
+	}
+
+	if q.MaxBalance, err = parseAmount(v, "max_balance"); err != nil {
+		return q, err
+	}
+
+	if q.CreatedFrom, err = parseTime(v, "created_from"); err != nil {
+		return q, err
+	}
+
+	if q.CreatedTo, err = parseTime(v, "created_to"); err != nil {
+		return q, err
+	}
+
+	if s := v.Get("limit"); s != "" {
+		if q.Limit, err = strconv.Atoi(s); err != nil {
+			return q, fmt.Errorf("%w: invalid limit %q", payment.ErrInvalidQuery, s)
+		}
+	}
+
+	return q, nil
+}
+
+func parseAmount(v url.Values, name string) (*float32, error) {
+	s := v.Get(name)
+	if s == "" {
+		return nil, nil
+	}
+
+	f, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid %s %q", payment.ErrInvalidQuery, name, s)
+	}
+
+	amount := float32(f)
+
+	return &amount, nil
+}
+
+func parseTime(v url.Values, name string) (time.Time, error) {
+	s := v.Get(name)
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return t, fmt.Errorf("%w: invalid %s %q", payment.ErrInvalidQuery, name, s)
+	}
+
+	return t, nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,83 @@
+package httpapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/httpapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {
+			"K1": {"customer_id": "1", "num": "BY11ABCD00000000000000000001", "currency_code": "BYN", "status": "active", "balance": 100, "created_at": "2024-01-02T00:00:00Z"},
+			"K2": {"customer_id": "1", "num": "BY11ABCD00000000000000000002", "currency_code": "USD", "status": "blocked", "balance": 20, "created_at": "2024-01-03T00:00:00Z"}
+		},
+		"2": {
+			"K3": {"customer_id": "2", "num": "BY22ABCD00000000000000000003", "currency_code": "BYN", "status": "active", "balance": 300, "created_at": "2024-01-04T00:00:00Z"}
+		}
+	}`)))
+
+	srv := httptest.NewServer(httpapi.NewHandler(ps))
+	defer srv.Close()
+
+	get := func(query string) (int, payment.AccountPage, map[string]string) {
+		t.Helper()
+
+		res, err := http.Get(srv.URL + httpapi.AccountsPath + "?" + query)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		var page payment.AccountPage
+		var body map[string]string
+
+		if res.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&page))
+		} else {
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		}
+
+		return res.StatusCode, page, body
+	}
+
+	code, page, _ := get("currency=BYN&min_balance=150")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, page.Accounts, 1)
+	assert.Equal(t, "BY22ABCD00000000000000000003", page.Accounts[0].Num)
+
+	code, page, _ = get("sort=balance&order=desc&limit=2")
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 3, page.Total)
+	require.Len(t, page.Accounts, 2)
+	assert.Equal(t, float32(300), page.Accounts[0].Balance)
+
+	code, page, _ = get("sort=balance&order=desc&limit=2&cursor=" + page.NextCursor)
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, page.Accounts, 1)
+	assert.Equal(t, float32(20), page.Accounts[0].Balance)
+	assert.Empty(t, page.NextCursor)
+
+	code, page, _ = get("created_from=2024-01-03&customer_id=1")
+	require.Equal(t, http.StatusOK, code)
+	require.Len(t, page.Accounts, 1)
+	assert.Equal(t, payment.Blocked, page.Accounts[0].Status)
+
+	code, _, body := get("min_balance=abc")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body["error"], "invalid min_balance")
+
+	code, _, body = get("sort=name")
+	assert.Equal(t, http.StatusBadRequest, code)
+	assert.Contains(t, body["error"], "unknown sort")
+
+	res, err := http.Post(srv.URL+httpapi.AccountsPath, "application/json", nil)
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+}
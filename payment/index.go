@@ -0,0 +1,86 @@
+package payment
+
+// accountRef is where the account is kept in the store.
+type accountRef struct {
+	customerId string
+	key        string
+	currency   string
+	status     string
+}
+
+/*
+This struct indexes the accounts of the store by number, currency,
+status and type, the first two letters of the number. It is kept
+up to date by every write to the store, so queries and lookups by
+number do not scan the accounts of every customer.
+*/
+type accountIndex struct {
+	byNumber   map[string]accountRef
+	byCurrency map[string]map[string]bool
+	byStatus   map[string]map[string]bool
+	byType     map[string]map[string]bool
+}
+
+func newAccountIndex() *accountIndex {
+	return &accountIndex{
+		byNumber:   make(map[string]accountRef),
+		byCurrency: make(map[string]map[string]bool),
+		byStatus:   make(map[string]map[string]bool),
+		byType:     make(map[string]map[string]bool),
+	}
+}
+
+// accountType returns the type of the account, e.g. BY, SE or ST.
+func accountType(num string) string {
+	if len(num) < 2 {
+		return num
+	}
+
+	return num[:2]
+}
+
+func addTo(sets map[string]map[string]bool, value string, num string) {
+	if _, ok := sets[value]; !ok {
+		sets[value] = make(map[string]bool)
+	}
+
+	sets[value][num] = true
+}
+
+func removeFrom(sets map[string]map[string]bool, value string, num string) {
+	delete(sets[value], num)
+
+	if len(sets[value]) == 0 {
+		delete(sets, value)
+	}
+}
+
+// add indexes the account written to the store under the key.
+func (ix *accountIndex) add(key string, a Account) {
+	if old, ok := ix.byNumber[a.Num]; ok {
+		removeFrom(ix.byCurrency, old.currency, a.Num)
+		removeFrom(ix.byStatus, old.status, a.Num)
+	}
+
+	ix.byNumber[a.Num] = accountRef{customerId: a.CustomerId, key: key, currency: a.CurrencyCode, status: a.Status}
+
+	addTo(ix.byCurrency, a.CurrencyCode, a.Num)
+	addTo(ix.byStatus, a.Status, a.Num)
+	addTo(ix.byType, accountType(a.Num), a.Num)
+}
+
+// index adds the account written to the store under the key to the index.
+func (ps *PaymentSystem) index(key string, a Account) {
+	ps.accounts.add(key, a)
+}
+
+// reindex rebuilds the index after the store is replaced.
+func (ps *PaymentSystem) reindex() {
+	ps.accounts = newAccountIndex()
+
+	for _, cid := range sortedKeys(ps.store) {
+		for _, key := range sortedKeys(ps.store[cid]) {
+			ps.index(key, ps.store[cid][key])
+		}
+	}
+}
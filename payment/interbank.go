@@ -0,0 +1,433 @@
+package payment
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	// ClearingPrefix is the key prefix of the clearing account of a currency and of the ids of interbank transfers.
+	ClearingPrefix = "CL"
+	// VostroPrefix is the key prefix of the accounts other banks hold with us.
+	VostroPrefix = "VO"
+
+	// TxOutbound moves money of a customer to the clearing account for another bank.
+	TxOutbound = "outbound"
+	// TxInbound credits a customer with money from another bank.
+	TxInbound = "inbound"
+	// TxReturn gives an interbank transfer rejected by the other bank back to the customer.
+	TxReturn = "return"
+	// TxSettlement settles the net position between banks.
+	TxSettlement = "settlement"
+)
+
+/*
+This struct is a transfer between customers of two banks.
+It waits in the outbound queue of the sending bank until the clearing house
+collects it and delivers it to the receiving bank.
+*/
+type InterbankTransfer struct {
+	Id          string    `json:"id"`
+	FromBank    string    `json:"from_bank"`
+	ToBank      string    `json:"to_bank"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Currency    string    `json:"currency_code"`
+	Amount      float32   `json:"amount"`
+	Time        time.Time `json:"time"`
+}
+
+// WithBankCode sets the bank code: the four letters of the account numbers of the bank.
+func WithBankCode(code string) Option {
+	return func(ps *PaymentSystem) {
+		ps.bankCode = code
+	}
+}
+
+// BankCode returns the bank code of the PaymentSystem, empty when it is a single bank.
+func (ps *PaymentSystem) BankCode() string {
+	return ps.bankCode
+}
+
+// BankCodeOf returns the bank code of the account number (BY11ABCD...), empty for malformed numbers.
+func BankCodeOf(accountNum string) string {
+	if len(accountNum) < 8 {
+		return ""
+	}
+
+	return accountNum[4:8]
+}
+
+/*
+This func returns a new account number. The numbers of a bank with
+a bank code carry it, otherwise the bank code is random.
+*/
+func (ps *PaymentSystem) newAccountNumber() string {
+	if ps.bankCode == "" {
+		return GenerateAccountNumber()
+	}
+
+	var b strings.Builder
+
+	b.WriteString(AccountPrefix)
+
+	for i := 0; i < AccountLength-4; i++ {
+		if i == 2 {
+			b.WriteString(ps.bankCode)
+		}
+
+		b.WriteByte(AccountNumbers[rand.Intn(len(AccountNumbers))])
+	}
+
+	return b.String()
+}
+
+/*
+This func tells whether the account belongs to another bank:
+the bank has a bank code, the account is not in the store
+and its number carries another bank code.
+*/
+func (ps *PaymentSystem) foreign(a Account) bool {
+	if ps.bankCode == "" || BankCodeOf(a.Num) == ps.bankCode {
+		return false
+	}
+
+	_, ok := ps.findByNumber(a.Num)
+
+	return !ok
+}
+
+// vostro tells whether the account is held with us by a correspondent bank.
+func (ps *PaymentSystem) vostro(a Account) bool {
+	ref, ok := ps.accounts.byNumber[a.Num]
+
+	return ok && strings.HasPrefix(ref.key, VostroPrefix)
+}
+
+// destination returns the current state of the destination account or the foreign account as is.
+func (ps *PaymentSystem) destination(d Account) (Account, bool) {
+	if ps.foreign(d) {
+		return d, true
+	}
+
+	_, a, ok := ps.lookup(d.CustomerId, d.Num)
+
+	return a, ok && accountAvailable(a)
+}
+
+// VostroAccountNumber returns the number of the account the bank holds with us in the currency.
+func (ps *PaymentSystem) VostroAccountNumber(bank string, currencyCode string) string {
+	return VostroPrefix + "00" + ps.bankCode + bank + currencyCode
+}
+
+/*
+This func returns the clearing account of the currency and creates it on first use.
+The clearing account holds the money sent to other banks until the settlement;
+it is negative while the money received from other banks is not settled.
+*/
+func (ps *PaymentSystem) clearingAccount(currencyCode string) (string, Account) {
+	return ps.internalAccount(ClearingPrefix, currencyCode)
+}
+
+// ClearingAccount returns the clearing account of the currency.
+func (ps *PaymentSystem) ClearingAccount(currencyCode string) Account {
+	_, a := ps.clearingAccount(currencyCode)
+
+	return a
+}
+
+/*
+This func moves the amount from the customer to the clearing account
+and queues the transfer for the bank of the destination.
+*/
+func (ps *PaymentSystem) sendOutbound(s Account, d Account, amount float32) error {
+	key, src, ok := ps.lookup(s.CustomerId, s.Num)
+	if !ok {
+		return fmt.Errorf("Transfer is imposible: account not found! %s\n", s.Num)
+	}
+
+	if !accountAvailable(src) {
+		return fmt.Errorf("Transfer is imposible: account %s is not valid or blocked", src.Num)
+	}
+
+	if d.CurrencyCode != "" && d.CurrencyCode != src.CurrencyCode {
+		return fmt.Errorf("Transfer is imposible: different currency! \n")
+	}
+
+	if src.Balance-src.Reserved < amount {
+		return fmt.Errorf("Transfer is imposible: insufficient funds on account %s: %w", s.Num, ErrInsufficientFunds)
+	}
+
+	clKey, cl := ps.clearingAccount(src.CurrencyCode)
+
+	src.Balance -= amount
+	cl.Balance += amount
+
+	src, cl, err := ps.putPair(key, src, clKey, cl)
+	if err != nil {
+		return err
+	}
+
+	it := InterbankTransfer{
+		Id:          ps.nextID(ClearingPrefix),
+		FromBank:    ps.bankCode,
+		ToBank:      BankCodeOf(d.Num),
+		Source:      src.Num,
+		Destination: d.Num,
+		Currency:    src.CurrencyCode,
+		Amount:      amount,
+	}
+
+	tx := ps.recordTx(Transaction{Type: TxOutbound, Source: src.Num, Dest: cl.Num, Currency: it.Currency, Amount: amount, Reference: it.Id})
+	it.Time = tx.Time
+
+	ps.outbound = append(ps.outbound, it)
+
+	ps.logger.Info("transfer is queued for clearing", "transfer_id", it.Id, "bank", it.ToBank, "destination", d.Num, "amount", amount)
+
+	ps.publish(EventTransferCompleted, TransferCompleted{Source: src, Destination: Account{Num: d.Num, CurrencyCode: it.Currency}, Amount: amount, TransactionId: tx.Id})
+
+	return nil
+}
+
+// Outbound returns the transfers to other banks waiting for clearing.
+func (ps *PaymentSystem) Outbound() []InterbankTransfer {
+	return append([]InterbankTransfer(nil), ps.outbound...)
+}
+
+// TakeOutbound returns the transfers waiting for clearing and empties the queue.
+func (ps *PaymentSystem) TakeOutbound() []InterbankTransfer {
+	res := ps.outbound
+	ps.outbound = nil
+
+	return res
+}
+
+/*
+This func credits the destination customer with the transfer of another bank.
+The money comes from the clearing account, which is settled later.
+*/
+func (ps *PaymentSystem) ReceiveInbound(it InterbankTransfer) error {
+	d, ok := ps.findByNumber(it.Destination)
+	if !ok || !accountAvailable(d) || d.CustomerId == SpecialCustomerId {
+		return fmt.Errorf("Inbound transfer is imposible: account %s is not valid or blocked", it.Destination)
+	}
+
+	if d.CurrencyCode != it.Currency {
+		return fmt.Errorf("Inbound transfer is imposible: account %s is in %s, the transfer is in %s", d.Num, d.CurrencyCode, it.Currency)
+	}
+
+	if err := ps.screenParty(d); err != nil {
+		return err
+	}
+
+	key, d, _ := ps.lookup(d.CustomerId, d.Num)
+	clKey, cl := ps.clearingAccount(it.Currency)
+
+	cl.Balance -= it.Amount
+	d.Balance += it.Amount
+
+	cl, d, err := ps.putPair(clKey, cl, key, d)
+	if err != nil {
+		return err
+	}
+
+	tx := ps.recordTx(Transaction{Type: TxInbound, Source: cl.Num, Dest: d.Num, Currency: it.Currency, Amount: it.Amount, Reference: it.Id})
+
+	ps.publish(EventTransferCompleted, TransferCompleted{Source: Account{Num: it.Source, CurrencyCode: it.Currency}, Destination: d, Amount: it.Amount, TransactionId: tx.Id})
+
+	return nil
+}
+
+/*
+This func credits the destination customer with the transfer a correspondent
+bank sent to us directly, e.g. as an MT103. The money comes from the vostro
+account of the bank with us. The reference of the sender identifies
+the transfer: it is received from the vostro account only once.
+*/
+func (ps *PaymentSystem) ReceiveCorrespondent(settlement string, dest string, currencyCode string, amount float32, reference string) error {
+	if !positive(amount) {
+		return fmt.Errorf("Inbound transfer is imposible: amount <= 0 \n")
+	}
+
+	if reference == "" {
+		return fmt.Errorf("Inbound transfer is imposible: without reference\n")
+	}
+
+	v, ok := ps.findByNumber(settlement)
+	if !ok {
+		return fmt.Errorf("Inbound transfer is imposible: %w: %s", ErrAccountNotFound, settlement)
+	}
+
+	vKey, v, _ := ps.lookup(v.CustomerId, v.Num)
+	if vKey != VostroPrefix+v.CurrencyCode {
+		return fmt.Errorf("Inbound transfer is imposible: %s is not a vostro account", settlement)
+	}
+
+	if prev, ok := ps.received(v.Num, reference); ok {
+		return fmt.Errorf("%w: %s was received by %s", ErrDuplicateReference, reference, prev.Id)
+	}
+
+	d, ok := ps.findByNumber(dest)
+	if !ok || !accountAvailable(d) || d.CustomerId == SpecialCustomerId {
+		return fmt.Errorf("Inbound transfer is imposible: account %s is not valid or blocked", dest)
+	}
+
+	for _, a := range []Account{v, d} {
+		if a.CurrencyCode != currencyCode {
+			return fmt.Errorf("Inbound transfer is imposible: account %s is in %s, the transfer is in %s", a.Num, a.CurrencyCode, currencyCode)
+		}
+	}
+
+	if err := ps.screenParty(d); err != nil {
+		return err
+	}
+
+	if v.Balance < amount {
+		return fmt.Errorf("Inbound transfer is imposible: insufficient funds on vostro account %s: %w", v.Num, ErrInsufficientFunds)
+	}
+
+	key, d, _ := ps.lookup(d.CustomerId, d.Num)
+
+	v.Balance -= amount
+	d.Balance += amount
+
+	v, d, err := ps.putPair(vKey, v, key, d)
+	if err != nil {
+		return err
+	}
+
+	tx := ps.recordTx(Transaction{Type: TxInbound, Source: v.Num, Dest: d.Num, Currency: currencyCode, Amount: amount, Reference: reference})
+
+	ps.publish(EventTransferCompleted, TransferCompleted{Source: Account{Num: v.Num, CurrencyCode: currencyCode}, Destination: d, Amount: amount, TransactionId: tx.Id})
+
+	return nil
+}
+
+// received returns the inbound transfer with the reference paid from the account.
+func (ps *PaymentSystem) received(source string, reference string) (Transaction, bool) {
+	for _, tx := range ps.journal {
+		if tx.Type == TxInbound && tx.Source == source && tx.Reference == reference {
+			return tx, true
+		}
+	}
+
+	return Transaction{}, false
+}
+
+// ReturnOutbound gives the transfer rejected by the other bank back to the sender.
+func (ps *PaymentSystem) ReturnOutbound(it InterbankTransfer, reason string) error {
+	s, ok := ps.findByNumber(it.Source)
+	if !ok {
+		return fmt.Errorf("Return is imposible: account not found! %s\n", it.Source)
+	}
+
+	key, s, _ := ps.lookup(s.CustomerId, s.Num)
+	clKey, cl := ps.clearingAccount(it.Currency)
+
+	cl.Balance -= it.Amount
+	s.Balance += it.Amount
+
+	cl, _, err := ps.putPair(clKey, cl, key, s)
+	if err != nil {
+		return err
+	}
+
+	ps.recordTx(Transaction{Type: TxReturn, Source: cl.Num, Dest: s.Num, Currency: it.Currency, Amount: it.Amount, Reference: it.Id})
+
+	ps.publish(EventTransferFailed, TransferFailed{Source: it.Source, Destination: it.Destination, Amount: it.Amount, Reason: reason})
+
+	return nil
+}
+
+/*
+This func opens the vostro account of the bank in the currency with
+the opening balance: the money the bank deposits with us to settle
+its debts. It returns the existing account when it is open already.
+*/
+func (ps *PaymentSystem) OpenVostro(bank string, currencyCode string, amount float32) (Account, error) {
+	if ps.bankCode == "" {
+		return Account{}, fmt.Errorf("Opening vostro account is imposible: the bank has no bank code")
+	}
+
+	key := VostroPrefix + currencyCode
+
+	if a, ok := ps.store[bank][key]; ok {
+		return a, nil
+	}
+
+	a := NewAccount(bank, currencyCode, ps.VostroAccountNumber(bank, currencyCode), amount)
+	a.CreatedAt = ps.now()
+	a.Version = 1
+	a.Description = "vostro of " + bank
+
+	if _, ok := ps.store[bank]; !ok {
+		ps.store[bank] = make(map[string]Account)
+	}
+
+	ps.store[bank][key] = a
+	ps.index(key, a)
+
+	ps.publish(EventAccountCreated, AccountCreated{Account: a})
+
+	return a, nil
+}
+
+// Vostro returns the vostro account of the bank in the currency.
+func (ps *PaymentSystem) Vostro(bank string, currencyCode string) (Account, bool) {
+	a, ok := ps.store[bank][VostroPrefix+currencyCode]
+
+	return a, ok
+}
+
+/*
+This func settles the net debt of the bank to us: its vostro account
+is debited and the clearing account, which paid our customers, is credited.
+*/
+func (ps *PaymentSystem) SettleCredit(bank string, currencyCode string, amount float32, reference string) error {
+	v, ok := ps.Vostro(bank, currencyCode)
+	if !ok {
+		return fmt.Errorf("Settlement is imposible: %w: vostro of %s in %s", ErrAccountNotFound, bank, currencyCode)
+	}
+
+	if v.Balance < amount {
+		return fmt.Errorf("Settlement is imposible: insufficient funds on vostro account %s: %w", v.Num, ErrInsufficientFunds)
+	}
+
+	clKey, cl := ps.clearingAccount(currencyCode)
+
+	v.Balance -= amount
+	cl.Balance += amount
+
+	if _, _, err := ps.putPair(VostroPrefix+currencyCode, v, clKey, cl); err != nil {
+		return err
+	}
+
+	ps.recordTx(Transaction{Type: TxSettlement, Source: v.Num, Dest: cl.Num, Currency: currencyCode, Amount: amount, Reference: reference})
+
+	return nil
+}
+
+/*
+This func settles our net debt to the bank: the money of our customers
+leaves the clearing account to our nostro account with the bank,
+which is the vostro account of its books.
+*/
+func (ps *PaymentSystem) SettleDebit(bank string, currencyCode string, amount float32, nostro string, reference string) error {
+	clKey, cl := ps.clearingAccount(currencyCode)
+
+	cl.Balance -= amount
+
+	cl, err := ps.put(clKey, cl)
+	if err != nil {
+		return err
+	}
+
+	ps.recordTx(Transaction{Type: TxSettlement, Source: cl.Num, Dest: nostro, Currency: currencyCode, Amount: amount, Reference: reference})
+
+	ps.logger.Info("position is settled", "bank", bank, "currency", currencyCode, "amount", amount, "nostro", nostro)
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package payment_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_Outbound(t *testing.T) {
+	const foreign = "BY00ZZZZ00000000000000000001"
+
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	clock := payment.WithClock(func() time.Time { return now })
+
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithBankCode("ABCD"), clock)
+	c := payment.NewCustomer("1", "Customer One", payment.AccountPrefix)
+
+	require.NoError(t, ps.CreateAccount(c, payment.AccountPrefix, payment.BYN, 100))
+
+	s, err := ps.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, "ABCD", payment.BankCodeOf(s.Num))
+
+	assert.Error(t, ps.TransferByNumber(s.Num, foreign, 150), "insufficient funds")
+	require.NoError(t, ps.TransferByNumber(s.Num, foreign, 40))
+
+	out := ps.Outbound()
+	require.Len(t, out, 1)
+	assert.Equal(t, payment.InterbankTransfer{Id: out[0].Id, FromBank: "ABCD", ToBank: "ZZZZ", Source: s.Num, Destination: foreign, Currency: payment.BYN, Amount: 40, Time: now}, out[0])
+	assert.Equal(t, float32(40), ps.ClearingAccount(payment.BYN).Balance)
+
+	// the queue survives the snapshot
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithBankCode("ABCD"), clock)
+	require.NoError(t, restored.LoadSnapshot(data))
+	assert.Equal(t, out, restored.Outbound())
+
+	// the returned transfer is credited back
+	require.Equal(t, out, restored.TakeOutbound())
+	require.NoError(t, restored.ReturnOutbound(out[0], "account closed"))
+	assert.Empty(t, restored.Outbound())
+	assert.Zero(t, restored.ClearingAccount(payment.BYN).Balance)
+
+	s, err = restored.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(100), s.Balance)
+}
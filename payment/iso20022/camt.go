@@ -0,0 +1,208 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"io"
+	"math"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+const (
+	Camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+	// DateLayout is the ISODate of the messages.
+	DateLayout = "2006-01-02"
+)
+
+// Balance types, credit/debit indicators and entry status of camt.053.
+const (
+	BalanceOpeningBooked = "OPBD"
+	BalanceClosingBooked = "CLBD"
+
+	Credit = "CRDT"
+	Debit  = "DBIT"
+
+	EntryBooked = "BOOK"
+)
+
+// Camt053 is the bank to customer statement.
+type Camt053 struct {
+	XMLName xml.Name             `xml:"Document"`
+	Xmlns   string               `xml:"xmlns,attr"`
+	GrpHdr  StatementGroupHeader `xml:"BkToCstmrStmt>GrpHdr"`
+	Stmt    []AccountStatement   `xml:"BkToCstmrStmt>Stmt"`
+}
+
+type StatementGroupHeader struct {
+	MsgId   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+// AccountStatement is the statement of one account, elements are in the order of the schema.
+type AccountStatement struct {
+	Id        string      `xml:"Id"`
+	CreDtTm   string      `xml:"CreDtTm"`
+	FrToDt    Period      `xml:"FrToDt"`
+	Acct      CashAccount `xml:"Acct"`
+	Bal       []Balance   `xml:"Bal"`
+	TxsSummry *Summary    `xml:"TxsSummry,omitempty"`
+	Ntry      []Entry     `xml:"Ntry"`
+}
+
+type Period struct {
+	FrDtTm string `xml:"FrDtTm"`
+	ToDtTm string `xml:"ToDtTm"`
+}
+
+type Balance struct {
+	Cd        string `xml:"Tp>CdOrPrtry>Cd"`
+	Amt       Amount `xml:"Amt"`
+	CdtDbtInd string `xml:"CdtDbtInd"`
+	Dt        string `xml:"Dt>Dt"`
+}
+
+type Summary struct {
+	NbOfNtries   int    `xml:"TtlNtries>NbOfNtries"`
+	Sum          string `xml:"TtlNtries>Sum"`
+	TtlCdtNtries Totals `xml:"TtlCdtNtries"`
+	TtlDbtNtries Totals `xml:"TtlDbtNtries"`
+}
+
+type Totals struct {
+	NbOfNtries int    `xml:"NbOfNtries"`
+	Sum        string `xml:"Sum"`
+}
+
+// Entry is one movement of the account.
+type Entry struct {
+	NtryRef     string       `xml:"NtryRef"`
+	Amt         Amount       `xml:"Amt"`
+	CdtDbtInd   string       `xml:"CdtDbtInd"`
+	Sts         string       `xml:"Sts"`
+	BookgDt     string       `xml:"BookgDt>Dt"`
+	ValDt       string       `xml:"ValDt>Dt"`
+	AcctSvcrRef string       `xml:"AcctSvcrRef"`
+	BkTxCd      BankTxCode   `xml:"BkTxCd"`
+	TxDtls      EntryDetails `xml:"NtryDtls>TxDtls"`
+}
+
+// BankTxCode is the proprietary code of the entry, the type of the transaction of the store.
+type BankTxCode struct {
+	Cd   string `xml:"Prtry>Cd"`
+	Issr string `xml:"Prtry>Issr"`
+}
+
+type EntryDetails struct {
+	EndToEndId string      `xml:"Refs>EndToEndId,omitempty"`
+	TxId       string      `xml:"Refs>TxId"`
+	DbtrAcct   CashAccount `xml:"RltdPties>DbtrAcct"`
+	CdtrAcct   CashAccount `xml:"RltdPties>CdtrAcct"`
+}
+
+// TxCodeIssuer issues the proprietary bank transaction codes of the entries.
+const TxCodeIssuer = "PAYMENT"
+
+/*
+This func converts the statement of the store to camt.053.
+Open bounds of the period of the statement are replaced
+by the creation time of the account and the time of the message.
+*/
+func NewCamt053(msgId string, created time.Time, st payment.Statement) *Camt053 {
+	from, to := st.From, st.To
+	if from.IsZero() {
+		from = st.Account.CreatedAt
+	}
+
+	if to.IsZero() {
+		to = created
+	}
+
+	cur := st.Account.CurrencyCode
+
+	as := AccountStatement{
+		Id:      msgId + "-" + st.Account.Num,
+		CreDtTm: created.Format(DateTimeLayout),
+		FrToDt:  Period{FrDtTm: from.Format(DateTimeLayout), ToDtTm: to.Format(DateTimeLayout)},
+		Acct:    CashAccount{IBAN: st.Account.Num, Ccy: cur},
+		Bal: []Balance{
+			newBalance(BalanceOpeningBooked, cur, st.Opening, from),
+			newBalance(BalanceClosingBooked, cur, st.Closing, to),
+		},
+	}
+
+	sum := &Summary{}
+
+	var total, credits, debits float64
+
+	for _, l := range st.Lines {
+		e := Entry{
+			NtryRef:     l.Id,
+			CdtDbtInd:   Credit,
+			Sts:         EntryBooked,
+			BookgDt:     l.BookingDate.Format(DateLayout),
+			ValDt:       l.ValueDate.Format(DateLayout),
+			AcctSvcrRef: l.Id,
+			BkTxCd:      BankTxCode{Cd: l.Type, Issr: TxCodeIssuer},
+			TxDtls: EntryDetails{
+				EndToEndId: l.Reference,
+				TxId:       l.Id,
+				DbtrAcct:   CashAccount{IBAN: l.Source},
+				CdtrAcct:   CashAccount{IBAN: l.Dest},
+			},
+		}
+
+		amount := float64(l.Credit)
+		if l.Debit > 0 {
+			amount = float64(l.Debit)
+			e.CdtDbtInd = Debit
+			sum.TtlDbtNtries.NbOfNtries++
+			debits += amount
+		} else {
+			sum.TtlCdtNtries.NbOfNtries++
+			credits += amount
+		}
+
+		e.Amt = NewAmount(cur, amount)
+		total += amount
+
+		as.Ntry = append(as.Ntry, e)
+	}
+
+	sum.NbOfNtries = len(st.Lines)
+	sum.Sum = decimal(total)
+	sum.TtlCdtNtries.Sum = decimal(credits)
+	sum.TtlDbtNtries.Sum = decimal(debits)
+	as.TxsSummry = sum
+
+	return &Camt053{
+		Xmlns:  Camt053Namespace,
+		GrpHdr: StatementGroupHeader{MsgId: msgId, CreDtTm: created.Format(DateTimeLayout)},
+		Stmt:   []AccountStatement{as},
+	}
+}
+
+// newBalance returns the balance with the sign moved to the credit/debit indicator.
+func newBalance(cd string, currencyCode string, v float32, at time.Time) Balance {
+	ind := Credit
+	if v < 0 {
+		ind = Debit
+	}
+
+	return Balance{
+		Cd:        cd,
+		Amt:       NewAmount(currencyCode, math.Abs(float64(v))),
+		CdtDbtInd: ind,
+		Dt:        at.Format(DateLayout),
+	}
+}
+
+func decimal(v float64) string {
+	return NewAmount("", v).Value
+}
+
+// Write encodes the statement as an indented XML document.
+func (c *Camt053) Write(w io.Writer) error {
+	return writeDocument(w, c)
+}
@@ -0,0 +1,187 @@
+package iso20022_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/iso20022"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sourceNum = "BY11ABCD00000000000000000001"
+	destNum   = "BY22ABCD00000000000000000002"
+)
+
+const store = `{
+	"1": {"K1": {"customer_id": "1", "num": "` + sourceNum + `", "currency_code": "BYN", "status": "active", "balance": 1000}},
+	"2": {"K2": {"customer_id": "2", "num": "` + destNum + `", "currency_code": "BYN", "status": "active", "balance": 0}}
+}`
+
+/*
+camt053Sequences are the child elements of camt.053.001.02 in the order
+of the sequences of the schema. Optional elements the exporter does not
+write are left out, the order of the written ones must match.
+*/
+var camt053Sequences = map[string][]string{
+	"Document":      {"BkToCstmrStmt"},
+	"BkToCstmrStmt": {"GrpHdr", "Stmt"},
+	"GrpHdr":        {"MsgId", "CreDtTm"},
+	"Stmt":          {"Id", "CreDtTm", "FrToDt", "Acct", "Bal", "TxsSummry", "Ntry"},
+	"FrToDt":        {"FrDtTm", "ToDtTm"},
+	"Acct":          {"Id", "Ccy"},
+	"Bal":           {"Tp", "Amt", "CdtDbtInd", "Dt"},
+	"TxsSummry":     {"TtlNtries", "TtlCdtNtries", "TtlDbtNtries"},
+	"TtlNtries":     {"NbOfNtries", "Sum"},
+	"TtlCdtNtries":  {"NbOfNtries", "Sum"},
+	"TtlDbtNtries":  {"NbOfNtries", "Sum"},
+	"Ntry":          {"NtryRef", "Amt", "CdtDbtInd", "Sts", "BookgDt", "ValDt", "AcctSvcrRef", "BkTxCd", "NtryDtls"},
+	"BkTxCd":        {"Prtry"},
+	"Prtry":         {"Cd", "Issr"},
+	"NtryDtls":      {"TxDtls"},
+	"TxDtls":        {"Refs", "RltdPties"},
+	"Refs":          {"EndToEndId", "TxId"},
+	"RltdPties":     {"DbtrAcct", "CdtrAcct"},
+}
+
+// required are the elements the schema requires in their parents.
+var camt053Required = map[string][]string{
+	"GrpHdr":    {"MsgId", "CreDtTm"},
+	"Stmt":      {"Id", "CreDtTm", "Acct", "Bal"},
+	"Bal":       {"Tp", "Amt", "CdtDbtInd", "Dt"},
+	"Ntry":      {"Amt", "CdtDbtInd", "Sts", "BkTxCd"},
+	"Refs":      {"TxId"},
+	"Prtry":     {"Cd"},
+	"TtlNtries": {"NbOfNtries"},
+}
+
+// checkSequences walks the document and checks the order and presence of the child elements.
+func checkSequences(t *testing.T, data []byte) {
+	t.Helper()
+
+	type frame struct {
+		name     string
+		children []string
+	}
+
+	var stack []frame
+
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+
+		require.NoError(t, err)
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if len(stack) > 0 {
+				stack[len(stack)-1].children = append(stack[len(stack)-1].children, el.Name.Local)
+			}
+
+			stack = append(stack, frame{name: el.Name.Local})
+		case xml.EndElement:
+			f := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+
+			if seq, ok := camt053Sequences[f.name]; ok {
+				pos := 0
+
+				for _, c := range f.children {
+					for pos < len(seq) && seq[pos] != c {
+						pos++
+					}
+
+					assert.Less(t, pos, len(seq), "%s: element %s is out of order or unknown in %v", f.name, c, f.children)
+				}
+			}
+
+			for _, r := range camt053Required[f.name] {
+				assert.Contains(t, f.children, r, "%s: required element %s is missing", f.name, r)
+			}
+		}
+	}
+}
+
+func TestNewCamt053(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithClock(func() time.Time { return now }))
+	require.NoError(t, ps.Restore([]byte(store)))
+
+	require.NoError(t, ps.TransferByNumber(sourceNum, destNum, 100))
+
+	_, err := ps.CloseDay()
+	require.NoError(t, err)
+
+	now = now.AddDate(0, 0, 1)
+	require.NoError(t, ps.TransferByNumber(destNum, sourceNum, 30.5))
+
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)
+
+	st, err := ps.Statement(payment.NewCustomer("1", "", ""), sourceNum, from, to)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, iso20022.NewCamt053("STMT-1", now, st).Write(&buf))
+
+	checkSequences(t, buf.Bytes())
+
+	var doc iso20022.Camt053
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+
+	assert.Equal(t, iso20022.Camt053Namespace, doc.XMLName.Space)
+	assert.Equal(t, "STMT-1", doc.GrpHdr.MsgId)
+	require.Len(t, doc.Stmt, 1)
+
+	s := doc.Stmt[0]
+	assert.Equal(t, sourceNum, s.Acct.IBAN)
+	assert.Equal(t, iso20022.Period{FrDtTm: "2024-03-01T00:00:00", ToDtTm: "2024-03-03T00:00:00"}, s.FrToDt)
+
+	require.Len(t, s.Bal, 2)
+	assert.Equal(t, iso20022.Balance{Cd: iso20022.BalanceOpeningBooked, Amt: iso20022.Amount{Ccy: payment.BYN, Value: "1000.00"}, CdtDbtInd: iso20022.Credit, Dt: "2024-03-01"}, s.Bal[0])
+	assert.Equal(t, iso20022.Balance{Cd: iso20022.BalanceClosingBooked, Amt: iso20022.Amount{Ccy: payment.BYN, Value: "930.50"}, CdtDbtInd: iso20022.Credit, Dt: "2024-03-03"}, s.Bal[1])
+
+	require.Len(t, s.Ntry, 2)
+	assert.Equal(t, iso20022.Debit, s.Ntry[0].CdtDbtInd)
+	assert.Equal(t, "100.00", s.Ntry[0].Amt.Value)
+	assert.Equal(t, iso20022.Credit, s.Ntry[1].CdtDbtInd)
+	assert.Equal(t, "30.50", s.Ntry[1].Amt.Value)
+	assert.Equal(t, "2024-03-01", s.Ntry[0].BookgDt)
+	assert.Equal(t, "2024-03-02", s.Ntry[1].BookgDt)
+	assert.Equal(t, "2024-03-02", s.Ntry[1].ValDt)
+	assert.Equal(t, payment.TxTransfer, s.Ntry[1].BkTxCd.Cd)
+	assert.Equal(t, destNum, s.Ntry[1].TxDtls.DbtrAcct.IBAN)
+
+	require.NotNil(t, s.TxsSummry)
+	assert.Equal(t, 2, s.TxsSummry.NbOfNtries)
+	assert.Equal(t, iso20022.Totals{NbOfNtries: 1, Sum: "30.50"}, s.TxsSummry.TtlCdtNtries)
+	assert.Equal(t, iso20022.Totals{NbOfNtries: 1, Sum: "100.00"}, s.TxsSummry.TtlDbtNtries)
+}
+
+func TestNewCamt053_Empty(t *testing.T) {
+	created := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	st := payment.Statement{
+		Account: payment.Account{Num: sourceNum, CurrencyCode: payment.BYN, CreatedAt: created.AddDate(0, -1, 0)},
+		Opening: -5,
+		Closing: -5,
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, iso20022.NewCamt053("STMT-2", created, st).Write(&buf))
+
+	checkSequences(t, buf.Bytes())
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+	assert.Contains(t, buf.String(), "<CdtDbtInd>DBIT</CdtDbtInd>", "negative balance is a debit")
+	assert.Contains(t, buf.String(), "<FrDtTm>2024-02-01T10:00:00</FrDtTm>", "open period starts at the creation of the account")
+	assert.NotContains(t, buf.String(), "<Ntry>")
+}
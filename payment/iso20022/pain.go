@@ -0,0 +1,239 @@
+// Package iso20022 reads and writes the ISO 20022 messages of the payment system:
+// pain.001 transfer initiations, pain.002 status reports and camt.053 statements.
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+const (
+	Pain001Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.001.001.03"
+	Pain002Namespace = "urn:iso:std:iso:20022:tech:xsd:pain.002.001.03"
+	Pain001Name      = "pain.001.001.03"
+
+	// DateTimeLayout is the ISODateTime of the messages.
+	DateTimeLayout = "2006-01-02T15:04:05"
+)
+
+// Statuses of a transaction and of a group of transactions in pain.002.
+const (
+	StatusAccepted = "ACCP"
+	StatusPartial  = "PART"
+	StatusRejected = "RJCT"
+)
+
+// Reasons of the rejection of a transaction in pain.002 (ExternalStatusReason1Code).
+const (
+	ReasonIncorrectAccount   = "AC01"
+	ReasonClosedAccount      = "AC04"
+	ReasonZeroAmount         = "AM01"
+	ReasonNotAllowedAmount   = "AM02"
+	ReasonNotAllowedCurrency = "AM03"
+	ReasonInsufficientFunds  = "AM04"
+	ReasonDuplication        = "AM05"
+	ReasonNarrative          = "NARR"
+)
+
+// Pain001 is the customer credit transfer initiation.
+type Pain001 struct {
+	XMLName xml.Name      `xml:"Document"`
+	Xmlns   string        `xml:"xmlns,attr,omitempty"`
+	GrpHdr  GroupHeader   `xml:"CstmrCdtTrfInitn>GrpHdr"`
+	PmtInf  []PaymentInfo `xml:"CstmrCdtTrfInitn>PmtInf"`
+}
+
+type GroupHeader struct {
+	MsgId    string `xml:"MsgId"`
+	CreDtTm  string `xml:"CreDtTm"`
+	NbOfTxs  string `xml:"NbOfTxs,omitempty"`
+	CtrlSum  string `xml:"CtrlSum,omitempty"`
+	InitgPty Party  `xml:"InitgPty"`
+}
+
+type Party struct {
+	Nm string `xml:"Nm,omitempty"`
+}
+
+// CashAccount is an account identified by IBAN.
+type CashAccount struct {
+	IBAN string `xml:"Id>IBAN"`
+	Ccy  string `xml:"Ccy,omitempty"`
+}
+
+// PaymentInfo is a group of transfers from one debtor account.
+type PaymentInfo struct {
+	PmtInfId    string           `xml:"PmtInfId"`
+	PmtMtd      string           `xml:"PmtMtd"`
+	ReqdExctnDt string           `xml:"ReqdExctnDt,omitempty"`
+	Dbtr        Party            `xml:"Dbtr"`
+	DbtrAcct    CashAccount      `xml:"DbtrAcct"`
+	CdtTrfTxInf []CreditTransfer `xml:"CdtTrfTxInf"`
+}
+
+type CreditTransfer struct {
+	InstrId    string      `xml:"PmtId>InstrId,omitempty"`
+	EndToEndId string      `xml:"PmtId>EndToEndId"`
+	Amount     Amount      `xml:"Amt>InstdAmt"`
+	Cdtr       Party       `xml:"Cdtr"`
+	CdtrAcct   CashAccount `xml:"CdtrAcct"`
+	Ustrd      string      `xml:"RmtInf>Ustrd,omitempty"`
+}
+
+// Amount is an amount with its currency, e.g. <InstdAmt Ccy="BYN">10.50</InstdAmt>.
+type Amount struct {
+	Ccy   string `xml:"Ccy,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Float returns the value of the amount.
+func (a Amount) Float() (float32, error) {
+	v, err := strconv.ParseFloat(a.Value, 32)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("invalid amount %q", a.Value)
+	}
+
+	return float32(v), nil
+}
+
+// NewAmount formats the amount with two decimals.
+func NewAmount(currencyCode string, v float64) Amount {
+	return Amount{Ccy: currencyCode, Value: strconv.FormatFloat(v, 'f', 2, 64)}
+}
+
+// ParsePain001 decodes the credit transfer initiation and checks it has transfers.
+func ParsePain001(r io.Reader) (*Pain001, error) {
+	var doc Pain001
+
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("pain.001: %w", err)
+	}
+
+	if doc.XMLName.Space != "" && doc.XMLName.Space != Pain001Namespace {
+		return nil, fmt.Errorf("pain.001: unsupported namespace %s", doc.XMLName.Space)
+	}
+
+	if doc.GrpHdr.MsgId == "" {
+		return nil, fmt.Errorf("pain.001: message without MsgId")
+	}
+
+	if len(doc.PmtInf) == 0 {
+		return nil, fmt.Errorf("pain.001: message %s has no payment information", doc.GrpHdr.MsgId)
+	}
+
+	return &doc, nil
+}
+
+// Pain002 is the customer payment status report.
+type Pain002 struct {
+	XMLName  xml.Name          `xml:"Document"`
+	Xmlns    string            `xml:"xmlns,attr"`
+	GrpHdr   StatusGroupHeader `xml:"CstmrPmtStsRpt>GrpHdr"`
+	OrgnlGrp OriginalGroup     `xml:"CstmrPmtStsRpt>OrgnlGrpInfAndSts"`
+	OrgnlPmt []OriginalPayment `xml:"CstmrPmtStsRpt>OrgnlPmtInfAndSts"`
+
+	// n and accepted count the transfers for the group status.
+	n        int
+	accepted int
+}
+
+type StatusGroupHeader struct {
+	MsgId   string `xml:"MsgId"`
+	CreDtTm string `xml:"CreDtTm"`
+}
+
+type OriginalGroup struct {
+	OrgnlMsgId   string `xml:"OrgnlMsgId"`
+	OrgnlMsgNmId string `xml:"OrgnlMsgNmId"`
+	OrgnlNbOfTxs string `xml:"OrgnlNbOfTxs"`
+	GrpSts       string `xml:"GrpSts"`
+}
+
+type OriginalPayment struct {
+	OrgnlPmtInfId string              `xml:"OrgnlPmtInfId"`
+	TxInfAndSts   []TransactionStatus `xml:"TxInfAndSts"`
+}
+
+// TransactionStatus is the status of one transfer of the original message.
+type TransactionStatus struct {
+	StsId           string        `xml:"StsId,omitempty"`
+	OrgnlEndToEndId string        `xml:"OrgnlEndToEndId"`
+	TxSts           string        `xml:"TxSts"`
+	StsRsnInf       *StatusReason `xml:"StsRsnInf,omitempty"`
+}
+
+type StatusReason struct {
+	Cd       string `xml:"Rsn>Cd"`
+	AddtlInf string `xml:"AddtlInf,omitempty"`
+}
+
+// NewPain002 returns an empty status report of the original message.
+func NewPain002(msgId string, created time.Time, originalMsgId string) *Pain002 {
+	return &Pain002{
+		Xmlns:  Pain002Namespace,
+		GrpHdr: StatusGroupHeader{MsgId: msgId, CreDtTm: created.Format(DateTimeLayout)},
+		OrgnlGrp: OriginalGroup{
+			OrgnlMsgId:   originalMsgId,
+			OrgnlMsgNmId: Pain001Name,
+		},
+	}
+}
+
+/*
+This func adds the status of the transfer to the payment information
+of the report and updates the group status: ACCP when all transfers
+are accepted, RJCT when all are rejected and PART otherwise.
+*/
+func (p *Pain002) Add(pmtInfId string, ts TransactionStatus) {
+	i := len(p.OrgnlPmt) - 1
+	if i < 0 || p.OrgnlPmt[i].OrgnlPmtInfId != pmtInfId {
+		p.OrgnlPmt = append(p.OrgnlPmt, OriginalPayment{OrgnlPmtInfId: pmtInfId})
+		i++
+	}
+
+	p.OrgnlPmt[i].TxInfAndSts = append(p.OrgnlPmt[i].TxInfAndSts, ts)
+
+	p.n++
+
+	if ts.TxSts == StatusAccepted {
+		p.accepted++
+	}
+
+	p.OrgnlGrp.OrgnlNbOfTxs = strconv.Itoa(p.n)
+
+	switch p.accepted {
+	case p.n:
+		p.OrgnlGrp.GrpSts = StatusAccepted
+	case 0:
+		p.OrgnlGrp.GrpSts = StatusRejected
+	default:
+		p.OrgnlGrp.GrpSts = StatusPartial
+	}
+}
+
+// Write encodes the report as an indented XML document.
+func (p *Pain002) Write(w io.Writer) error {
+	return writeDocument(w, p)
+}
+
+// writeDocument encodes the message with the XML header and indentation.
+func writeDocument(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+
+	return err
+}
@@ -0,0 +1,39 @@
+package iso20022_test
+
+import (
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment/iso20022"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAmount_Float(t *testing.T) {
+	testCases := []struct {
+		desc  string
+		value string
+		want  float32
+		fails bool
+	}{
+		{desc: "decimal", value: "10.50", want: 10.5},
+		{desc: "not a number", value: "ten", fails: true},
+		{desc: "NaN", value: "NaN", fails: true},
+		{desc: "infinite", value: "Inf", fails: true},
+		{desc: "negative infinite", value: "-Inf", fails: true},
+		{desc: "out of range", value: "1e39", fails: true},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			got, err := iso20022.Amount{Ccy: "BYN", Value: tt.value}.Float()
+			if tt.fails {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
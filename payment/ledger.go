@@ -0,0 +1,68 @@
+package payment
+
+import "time"
+
+const (
+	TxTransfer    = "transfer"
+	TxEmission    = "emission"
+	TxTermination = "termination"
+	// TxDisbursement moves emitted money from the emission account to a customer.
+	TxDisbursement = "disbursement"
+)
+
+const TransactionPrefix = "TX"
+
+// Transaction is an entry of the journal of executed money movements.
+type Transaction struct {
+	Id       string    `json:"id"`
+	Type     string    `json:"type"`
+	Source   string    `json:"source"`
+	Dest     string    `json:"destination"`
+	Currency string    `json:"currency_code"`
+	Amount   float32   `json:"amount"`
+	Time     time.Time `json:"time"`
+	// Reference is the reference given by the initiator, e.g. of a disbursement.
+	Reference string `json:"reference,omitempty"`
+	// BookingDate is the business date the movement is booked on.
+	BookingDate time.Time `json:"booking_date"`
+	// ValueDate is the business date the movement counts for the balance and the interest.
+	ValueDate time.Time `json:"value_date"`
+}
+
+// record appends an executed movement to the journal.
+func (ps *PaymentSystem) record(txType string, source string, dest string, currency string, amount float32) Transaction {
+	return ps.recordTx(Transaction{
+		Type:     txType,
+		Source:   source,
+		Dest:     dest,
+		Currency: currency,
+		Amount:   amount,
+	})
+}
+
+// recordTx assigns the id, time, booking and value date to the movement and appends it to the journal.
+func (ps *PaymentSystem) recordTx(tx Transaction) Transaction {
+	tx.Id = ps.nextID(TransactionPrefix)
+	tx.Time = ps.now()
+	tx.BookingDate = ps.bookingDate()
+	tx.ValueDate = ps.valueDate()
+
+	ps.journal = append(ps.journal, tx)
+
+	ps.logger.Info("transaction posted", "tx_id", tx.Id, "type", tx.Type, "account", tx.Source, "destination", tx.Dest, "currency", tx.Currency, "amount", tx.Amount)
+
+	return tx
+}
+
+// Transactions returns the journal entries where the account is source or destination.
+func (ps *PaymentSystem) Transactions(accountNum string) []Transaction {
+	var res []Transaction
+
+	for _, tx := range ps.journal {
+		if tx.Source == accountNum || tx.Dest == accountNum {
+			res = append(res, tx)
+		}
+	}
+
+	return res
+}
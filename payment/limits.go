@@ -0,0 +1,189 @@
+package payment
+
+import "fmt"
+
+// Unlimited marks an allowance field that has no limit configured.
+const Unlimited = -1
+
+// Limit caps the outgoing money of an account. Zero fields are not limited.
+type Limit struct {
+	MaxAmount     float32 `json:"max_amount"`
+	DailyAmount   float32 `json:"daily_amount"`
+	MonthlyAmount float32 `json:"monthly_amount"`
+	DailyCount    int     `json:"daily_count"`
+	MonthlyCount  int     `json:"monthly_count"`
+}
+
+// Allowance is what is left of the limits of an account at the moment.
+type Allowance struct {
+	MaxAmount     float32 `json:"max_amount"`
+	DailyAmount   float32 `json:"daily_amount"`
+	MonthlyAmount float32 `json:"monthly_amount"`
+	DailyCount    int     `json:"daily_count"`
+	MonthlyCount  int     `json:"monthly_count"`
+}
+
+// usage is the outgoing money and number of outgoing transactions in the current periods.
+type usage struct {
+	daily        float32
+	monthly      float32
+	dailyCount   int
+	monthlyCount int
+}
+
+// SetAccountLimit sets the limit for the account with the given number.
+func (ps *PaymentSystem) SetAccountLimit(accountNum string, l Limit) {
+	ps.accountLimits[accountNum] = l
+}
+
+// SetCustomerLimit sets the limit for all accounts of the customer in the same currency together.
+func (ps *PaymentSystem) SetCustomerLimit(customerId string, l Limit) {
+	ps.customerLimits[customerId] = l
+}
+
+// SetCurrencyLimit sets the default limit for every account in the currency.
+func (ps *PaymentSystem) SetCurrencyLimit(currencyCode string, l Limit) {
+	ps.currencyLimits[currencyCode] = l
+}
+
+/*
+This func returns the remaining allowance of the account
+taking into account the account, customer and currency limits.
+*/
+func (ps *PaymentSystem) RemainingAllowance(a Account) (Allowance, error) {
+	_, acc, ok := ps.lookup(a.CustomerId, a.Num)
+	if !ok {
+		return Allowance{}, fmt.Errorf("account %s not found", a.Num)
+	}
+
+	return ps.allowance(acc), nil
+}
+
+func (ps *PaymentSystem) allowance(a Account) Allowance {
+	own := ps.usage(func(tx Transaction) bool {
+		return tx.Source == a.Num
+	})
+
+	nums := make(map[string]bool)
+
+	for _, v := range ps.store[a.CustomerId] {
+		if v.CurrencyCode == a.CurrencyCode {
+			nums[v.Num] = true
+		}
+	}
+
+	customer := ps.usage(func(tx Transaction) bool {
+		return nums[tx.Source]
+	})
+
+	res := remaining(ps.accountLimits[a.Num], own)
+	res = minAllowance(res, remaining(ps.currencyLimits[a.CurrencyCode], own))
+	res = minAllowance(res, remaining(ps.customerLimits[a.CustomerId], customer))
+
+	return res
+}
+
+// usage sums the outgoing transactions of the current day and month matched by the filter.
+func (ps *PaymentSystem) usage(match func(tx Transaction) bool) usage {
+	var u usage
+
+	now := ps.now()
+	year, month, day := now.Date()
+
+	for _, tx := range ps.journal {
+		if tx.Type != TxTransfer && tx.Type != TxTermination && tx.Type != TxDisbursement && tx.Type != TxOutbound || !match(tx) {
+			continue
+		}
+
+		y, m, d := tx.Time.In(now.Location()).Date()
+		if y != year || m != month {
+			continue
+		}
+
+		u.monthly += tx.Amount
+		u.monthlyCount++
+
+		if d == day {
+			u.daily += tx.Amount
+			u.dailyCount++
+		}
+	}
+
+	return u
+}
+
+func remaining(l Limit, u usage) Allowance {
+	res := Allowance{
+		MaxAmount:     Unlimited,
+		DailyAmount:   Unlimited,
+		MonthlyAmount: Unlimited,
+		DailyCount:    Unlimited,
+		MonthlyCount:  Unlimited,
+	}
+
+	if l.MaxAmount > 0 {
+		res.MaxAmount = l.MaxAmount
+	}
+
+	if l.DailyAmount > 0 {
+		res.DailyAmount = max(l.DailyAmount-u.daily, 0)
+	}
+
+	if l.MonthlyAmount > 0 {
+		res.MonthlyAmount = max(l.MonthlyAmount-u.monthly, 0)
+	}
+
+	if l.DailyCount > 0 {
+		res.DailyCount = max(l.DailyCount-u.dailyCount, 0)
+	}
+
+	if l.MonthlyCount > 0 {
+		res.MonthlyCount = max(l.MonthlyCount-u.monthlyCount, 0)
+	}
+
+	return res
+}
+
+func minAllowance(a Allowance, b Allowance) Allowance {
+	return Allowance{
+		MaxAmount:     minLimited(a.MaxAmount, b.MaxAmount),
+		DailyAmount:   minLimited(a.DailyAmount, b.DailyAmount),
+		MonthlyAmount: minLimited(a.MonthlyAmount, b.MonthlyAmount),
+		DailyCount:    minLimited(a.DailyCount, b.DailyCount),
+		MonthlyCount:  minLimited(a.MonthlyCount, b.MonthlyCount),
+	}
+}
+
+func minLimited[T float32 | int](a T, b T) T {
+	if a == Unlimited {
+		return b
+	}
+
+	if b == Unlimited {
+		return a
+	}
+
+	return min(a, b)
+}
+
+/*
+This func checks that the outgoing amount fits into the limits of the account.
+*/
+func (ps *PaymentSystem) checkLimits(a Account, amount float32) error {
+	al := ps.allowance(a)
+
+	switch {
+	case al.MaxAmount != Unlimited && amount > al.MaxAmount:
+		return fmt.Errorf("%w: single transaction limit %.2f on account %s", ErrLimitExceeded, al.MaxAmount, a.Num)
+	case al.DailyAmount != Unlimited && amount > al.DailyAmount:
+		return fmt.Errorf("%w: daily amount remaining %.2f on account %s", ErrLimitExceeded, al.DailyAmount, a.Num)
+	case al.MonthlyAmount != Unlimited && amount > al.MonthlyAmount:
+		return fmt.Errorf("%w: monthly amount remaining %.2f on account %s", ErrLimitExceeded, al.MonthlyAmount, a.Num)
+	case al.DailyCount == 0:
+		return fmt.Errorf("%w: daily number of transactions on account %s", ErrLimitExceeded, a.Num)
+	case al.MonthlyCount == 0:
+		return fmt.Errorf("%w: monthly number of transactions on account %s", ErrLimitExceeded, a.Num)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package payment_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_Limits(t *testing.T) {
+	testCases := []struct {
+		desc        string
+		setLimit    func(ps *payment.PaymentSystem)
+		amounts     []float32
+		expectedErr error
+	}{
+		{
+			desc: "single transaction above account limit is rejected",
+			setLimit: func(ps *payment.PaymentSystem) {
+				ps.SetAccountLimit(sourceNum, payment.Limit{MaxAmount: 100})
+			},
+			amounts:     []float32{150},
+			expectedErr: payment.ErrLimitExceeded,
+		},
+		{
+			desc: "daily customer total is enforced",
+			setLimit: func(ps *payment.PaymentSystem) {
+				ps.SetCustomerLimit("1", payment.Limit{DailyAmount: 250})
+			},
+			amounts:     []float32{100, 100, 100},
+			expectedErr: payment.ErrLimitExceeded,
+		},
+		{
+			desc: "currency velocity is enforced",
+			setLimit: func(ps *payment.PaymentSystem) {
+				ps.SetCurrencyLimit(payment.BYN, payment.Limit{MonthlyCount: 2})
+			},
+			amounts:     []float32{1, 1, 1},
+			expectedErr: payment.ErrLimitExceeded,
+		},
+		{
+			desc: "limits of other currencies are ignored",
+			setLimit: func(ps *payment.PaymentSystem) {
+				ps.SetCurrencyLimit(payment.USD, payment.Limit{MaxAmount: 1})
+			},
+			amounts: []float32{100, 100},
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			ps := payment.NewPaymentSystem()
+			require.NoError(t, ps.Restore([]byte(pendingStore)))
+			tt.setLimit(ps)
+
+			s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+			d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+			var gotError error
+
+			for _, a := range tt.amounts {
+				gotError = ps.Transfer(s, d, a)
+			}
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, gotError, tt.expectedErr)
+			} else {
+				assert.NoError(t, gotError)
+			}
+		})
+	}
+}
+
+func TestPaymentSystem_RemainingAllowance(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+
+	ps := payment.NewPaymentSystem(payment.WithClock(func() time.Time { return now }))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	ps.SetAccountLimit(sourceNum, payment.Limit{DailyAmount: 300, MonthlyAmount: 500})
+	ps.SetCurrencyLimit(payment.BYN, payment.Limit{DailyCount: 5})
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	require.NoError(t, ps.Transfer(s, d, 200))
+
+	al, err := ps.RemainingAllowance(s)
+	require.NoError(t, err)
+	assert.Equal(t, payment.Allowance{
+		MaxAmount:     payment.Unlimited,
+		DailyAmount:   100,
+		MonthlyAmount: 300,
+		DailyCount:    4,
+		MonthlyCount:  payment.Unlimited,
+	}, al)
+
+	now = now.Add(24 * time.Hour)
+
+	al, err = ps.RemainingAllowance(s)
+	require.NoError(t, err)
+	assert.Equal(t, float32(300), al.DailyAmount, "daily allowance is restored on the next day")
+	assert.Equal(t, float32(500), al.MonthlyAmount, "monthly allowance is restored in the next month")
+}
@@ -0,0 +1,42 @@
+package payment
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithLogger sets the logger of the PaymentSystem. slog.Default() is used otherwise.
+func WithLogger(l *slog.Logger) Option {
+	return func(ps *PaymentSystem) {
+		ps.logger = l
+	}
+}
+
+/*
+This func logs the outcome of the operation with its input fields
+and duration, records it in the audit log and in the metrics.
+*/
+func (ps *PaymentSystem) finish(op string, actor string, start time.Time, input auditInput, result string, err error) {
+	elapsed := time.Since(start)
+	attrs := []any{"op", op, "duration", elapsed}
+
+	for _, k := range sortedKeys(input) {
+		attrs = append(attrs, k, input[k])
+	}
+
+	if result != "" {
+		attrs = append(attrs, "result", result)
+	}
+
+	if err != nil {
+		ps.logger.Warn("operation failed", append(attrs, "error", err)...)
+	} else {
+		ps.logger.Info("operation completed", attrs...)
+	}
+
+	ps.audit(op, actor, input, result, err)
+
+	if ps.metrics != nil {
+		ps.metrics.observeOp(op, err, elapsed)
+	}
+}
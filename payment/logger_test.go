@@ -0,0 +1,86 @@
+package payment_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// captureLogs returns a logger writing JSON records and a func decoding them.
+func captureLogs(level slog.Level) (*slog.Logger, func() []map[string]any) {
+	var buf bytes.Buffer
+
+	l := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: level}))
+
+	return l, func() []map[string]any {
+		var res []map[string]any
+
+		dec := json.NewDecoder(&buf)
+		for dec.More() {
+			var rec map[string]any
+			if dec.Decode(&rec) != nil {
+				break
+			}
+
+			res = append(res, rec)
+		}
+
+		return res
+	}
+}
+
+func TestPaymentSystem_Logger(t *testing.T) {
+	l, records := captureLogs(slog.LevelInfo)
+
+	ps := payment.NewPaymentSystem(payment.WithLogger(l))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	require.NoError(t, ps.Transfer(s, d, 100))
+	require.Error(t, ps.Transfer(s, d, -1))
+
+	recs := records()
+	require.Len(t, recs, 3)
+
+	assert.Equal(t, "transaction posted", recs[0]["msg"])
+	assert.Equal(t, "TX00000001", recs[0]["tx_id"])
+	assert.Equal(t, sourceNum, recs[0]["account"])
+
+	assert.Equal(t, "INFO", recs[1]["level"])
+	assert.Equal(t, payment.OpTransfer, recs[1]["op"])
+	assert.Equal(t, float64(100), recs[1]["amount"])
+	assert.Contains(t, recs[1], "duration")
+
+	assert.Equal(t, "WARN", recs[2]["level"])
+	assert.Equal(t, payment.OpTransfer, recs[2]["op"])
+	assert.Contains(t, recs[2]["error"], "Transfer is imposible")
+}
+
+func TestPaymentController_Logger(t *testing.T) {
+	l, records := captureLogs(slog.LevelDebug)
+
+	ps := payment.NewPaymentSystem(payment.WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	pc := payment.NewPaymentController(ps, payment.WithControllerLogger(l))
+
+	end := make(chan bool)
+
+	pc.Add(func() error {
+		return nil
+	})
+
+	pc.Run(end)
+	<-end
+
+	recs := records()
+	require.Len(t, recs, 1)
+	assert.Equal(t, "task is done", recs[0]["msg"])
+	assert.Equal(t, "DEBUG", recs[0]["level"])
+}
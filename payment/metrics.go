@@ -0,0 +1,250 @@
+package payment
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	MetricsPath = "/metrics"
+
+	OutcomeSuccess = "success"
+	OutcomeError   = "error"
+)
+
+// DefaultBuckets are the upper bounds in seconds of latency histograms.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	h := &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+
+	return h
+}
+
+func (h *histogram) observe(v float64) {
+	for i, b := range h.buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+
+	h.sum += v
+	h.count++
+}
+
+// Metrics collects counters and histograms of the payment engine
+// and renders them in the Prometheus text exposition format.
+type Metrics struct {
+	mu       sync.Mutex
+	ops      map[[2]string]uint64
+	latency  map[string]*histogram
+	lockWait *histogram
+	queues   []func() int
+	balances []func() map[string]float64
+}
+
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		ops:      make(map[[2]string]uint64),
+		latency:  make(map[string]*histogram),
+		lockWait: newHistogram(DefaultBuckets),
+	}
+
+	return m
+}
+
+// WithMetrics makes the PaymentSystem report operations, lock wait time and balances.
+func WithMetrics(m *Metrics) Option {
+	return func(ps *PaymentSystem) {
+		ps.metrics = m
+
+		m.mu.Lock()
+		m.balances = append(m.balances, ps.balancesByCurrency)
+		m.mu.Unlock()
+	}
+}
+
+// WithControllerMetrics makes the PaymentController report the depth of its queue.
+func WithControllerMetrics(m *Metrics) ControllerOption {
+	return func(pc *PaymentController) {
+		workers := pc.workers
+
+		m.mu.Lock()
+		m.queues = append(m.queues, func() int { return len(workers) })
+		m.mu.Unlock()
+	}
+}
+
+func (m *Metrics) observeOp(op string, err error, d time.Duration) {
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ops[[2]string{op, outcome}]++
+
+	h, ok := m.latency[op]
+	if !ok {
+		h = newHistogram(DefaultBuckets)
+		m.latency[op] = h
+	}
+
+	h.observe(d.Seconds())
+}
+
+func (m *Metrics) observeLockWait(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lockWait.observe(d.Seconds())
+}
+
+// balancesByCurrency sums the balances of all accounts by currency.
+func (ps *PaymentSystem) balancesByCurrency() map[string]float64 {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	res := make(map[string]float64)
+
+	for _, accounts := range ps.store {
+		for _, a := range accounts {
+			res[a.CurrencyCode] += float64(a.Balance)
+		}
+	}
+
+	return res
+}
+
+// Handler returns the http.Handler serving the metrics, usually at MetricsPath.
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		if err := m.WritePrometheus(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+/*
+This func writes all metrics in the Prometheus text exposition format.
+*/
+func (m *Metrics) WritePrometheus(out io.Writer) error {
+	m.mu.Lock()
+	collectors := slices.Clone(m.balances)
+	m.mu.Unlock()
+
+	// balances are collected without holding m.mu as they take the store lock
+	balances := make(map[string]float64)
+
+	for _, f := range collectors {
+		for c, v := range f() {
+			balances[c] += v
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := bufio.NewWriter(out)
+
+	header(w, "payment_operations_total", "counter", "Number of store operations by type and outcome.")
+
+	keys := make([][2]string, 0, len(m.ops))
+	for k := range m.ops {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1]
+	})
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "payment_operations_total{op=%q,outcome=%q} %d\n", k[0], k[1], m.ops[k])
+	}
+
+	header(w, "payment_operation_duration_seconds", "histogram", "Latency of store operations.")
+
+	for _, op := range sortedKeys(m.latency) {
+		writeHistogram(w, "payment_operation_duration_seconds", fmt.Sprintf("op=%q", op), m.latency[op])
+	}
+
+	header(w, "payment_lock_wait_seconds", "histogram", "Time spent waiting for the store lock.")
+	writeHistogram(w, "payment_lock_wait_seconds", "", m.lockWait)
+
+	depth := 0
+	for _, q := range m.queues {
+		depth += q()
+	}
+
+	header(w, "payment_controller_queue_depth", "gauge", "Number of tasks waiting in the controller queue.")
+	fmt.Fprintf(w, "payment_controller_queue_depth %d\n", depth)
+
+	header(w, "payment_balance_total", "gauge", "Sum of account balances by currency.")
+
+	for _, c := range sortedKeys(balances) {
+		fmt.Fprintf(w, "payment_balance_total{currency=%q} %s\n", c, formatFloat(balances[c]))
+	}
+
+	return w.Flush()
+}
+
+func header(w io.Writer, name string, typ string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+func writeHistogram(w io.Writer, name string, labels string, h *histogram) {
+	sep := ""
+	if labels != "" {
+		sep = ","
+	}
+
+	for i, b := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{%s%sle=%q} %d\n", name, labels, sep, formatFloat(b), h.counts[i])
+	}
+
+	fmt.Fprintf(w, "%s_bucket{%s%sle=\"+Inf\"} %d\n", name, labels, sep, h.count)
+
+	suffix := ""
+	if labels != "" {
+		suffix = "{" + labels + "}"
+	}
+
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, suffix, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, suffix, h.count)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
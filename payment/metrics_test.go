@@ -0,0 +1,54 @@
+package payment_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := payment.NewMetrics()
+
+	ps := payment.NewPaymentSystem(payment.WithMetrics(m))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	pc := payment.NewPaymentController(ps, payment.WithControllerMetrics(m))
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	require.NoError(t, ps.Lock())
+	require.NoError(t, ps.Transfer(s, d, 100))
+	require.Error(t, ps.Transfer(s, d, -1))
+	require.NoError(t, ps.Unlock())
+
+	pc.Add(func() error { return nil })
+
+	srv := httptest.NewServer(m.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + payment.MetricsPath)
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	text := string(body)
+
+	assert.Contains(t, resp.Header.Get("Content-Type"), "text/plain")
+	assert.Contains(t, text, "# TYPE payment_operations_total counter\n")
+	assert.Contains(t, text, `payment_operations_total{op="transfer",outcome="error"} 1`)
+	assert.Contains(t, text, `payment_operations_total{op="transfer",outcome="success"} 1`)
+	assert.Contains(t, text, `payment_operation_duration_seconds_bucket{op="transfer",le="+Inf"} 2`)
+	assert.Contains(t, text, `payment_operation_duration_seconds_count{op="transfer"} 2`)
+	assert.Contains(t, text, `payment_lock_wait_seconds_count 1`)
+	assert.Contains(t, text, `payment_controller_queue_depth 1`)
+	assert.Contains(t, text, `payment_balance_total{currency="BYN"} 1000`)
+}
@@ -6,6 +6,7 @@ package payment
 
 import (
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	payment "github.com/soundrise/go-payment-system/payment"
@@ -34,6 +35,49 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// AccountByNumber mocks base method.
+func (m *MockStore) AccountByNumber(accountNum string) (payment.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AccountByNumber", accountNum)
+	ret0, _ := ret[0].(payment.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AccountByNumber indicates an expected call of AccountByNumber.
+func (mr *MockStoreMockRecorder) AccountByNumber(accountNum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AccountByNumber", reflect.TypeOf((*MockStore)(nil).AccountByNumber), accountNum)
+}
+
+// Accounts mocks base method.
+func (m *MockStore) Accounts(customerId string) []payment.Account {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Accounts", customerId)
+	ret0, _ := ret[0].([]payment.Account)
+	return ret0
+}
+
+// Accounts indicates an expected call of Accounts.
+func (mr *MockStoreMockRecorder) Accounts(customerId interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Accounts", reflect.TypeOf((*MockStore)(nil).Accounts), customerId)
+}
+
+// ActivateAccount mocks base method.
+func (m *MockStore) ActivateAccount(ac payment.Account) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ActivateAccount", ac)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ActivateAccount indicates an expected call of ActivateAccount.
+func (mr *MockStoreMockRecorder) ActivateAccount(ac interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ActivateAccount", reflect.TypeOf((*MockStore)(nil).ActivateAccount), ac)
+}
+
 // CloseAccount mocks base method.
 func (m *MockStore) CloseAccount(ac payment.Account) error {
 	m.ctrl.T.Helper()
@@ -62,6 +106,34 @@ func (mr *MockStoreMockRecorder) CreateAccount(c, accType, currencyCode, amount
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateAccount", reflect.TypeOf((*MockStore)(nil).CreateAccount), c, accType, currencyCode, amount)
 }
 
+// CreateCustomer mocks base method.
+func (m *MockStore) CreateCustomer(c payment.Customer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateCustomer", c)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateCustomer indicates an expected call of CreateCustomer.
+func (mr *MockStoreMockRecorder) CreateCustomer(c interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateCustomer", reflect.TypeOf((*MockStore)(nil).CreateCustomer), c)
+}
+
+// Disburse mocks base method.
+func (m *MockStore) Disburse(d payment.Account, amount float32, reference string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Disburse", d, amount, reference)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Disburse indicates an expected call of Disburse.
+func (mr *MockStoreMockRecorder) Disburse(d, amount, reference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Disburse", reflect.TypeOf((*MockStore)(nil).Disburse), d, amount, reference)
+}
+
 // DumpStore mocks base method.
 func (m *MockStore) DumpStore() ([]byte, error) {
 	m.ctrl.T.Helper()
@@ -91,6 +163,20 @@ func (mr *MockStoreMockRecorder) Emit(amount interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Emit", reflect.TypeOf((*MockStore)(nil).Emit), amount)
 }
 
+// EmitIn mocks base method.
+func (m *MockStore) EmitIn(currencyCode string, amount float32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EmitIn", currencyCode, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EmitIn indicates an expected call of EmitIn.
+func (mr *MockStoreMockRecorder) EmitIn(currencyCode, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EmitIn", reflect.TypeOf((*MockStore)(nil).EmitIn), currencyCode, amount)
+}
+
 // FindAccount mocks base method.
 func (m *MockStore) FindAccount(c payment.Customer, currencyCode string) (payment.Account, error) {
 	m.ctrl.T.Helper()
@@ -106,6 +192,36 @@ func (mr *MockStoreMockRecorder) FindAccount(c, currencyCode interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindAccount", reflect.TypeOf((*MockStore)(nil).FindAccount), c, currencyCode)
 }
 
+// GetAccountByNumber mocks base method.
+func (m *MockStore) GetAccountByNumber(c payment.Customer, accountNum string) (payment.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAccountByNumber", c, accountNum)
+	ret0, _ := ret[0].(payment.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAccountByNumber indicates an expected call of GetAccountByNumber.
+func (mr *MockStoreMockRecorder) GetAccountByNumber(c, accountNum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAccountByNumber", reflect.TypeOf((*MockStore)(nil).GetAccountByNumber), c, accountNum)
+}
+
+// GetCustomer mocks base method.
+func (m *MockStore) GetCustomer(id string) (payment.Customer, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCustomer", id)
+	ret0, _ := ret[0].(payment.Customer)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCustomer indicates an expected call of GetCustomer.
+func (mr *MockStoreMockRecorder) GetCustomer(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomer", reflect.TypeOf((*MockStore)(nil).GetCustomer), id)
+}
+
 // GetSpecialAccount mocks base method.
 func (m *MockStore) GetSpecialAccount(accountPrefix string) (payment.Account, error) {
 	m.ctrl.T.Helper()
@@ -121,6 +237,21 @@ func (mr *MockStoreMockRecorder) GetSpecialAccount(accountPrefix interface{}) *g
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpecialAccount", reflect.TypeOf((*MockStore)(nil).GetSpecialAccount), accountPrefix)
 }
 
+// GetSpecialAccountIn mocks base method.
+func (m *MockStore) GetSpecialAccountIn(accountPrefix, currencyCode string) (payment.Account, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSpecialAccountIn", accountPrefix, currencyCode)
+	ret0, _ := ret[0].(payment.Account)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSpecialAccountIn indicates an expected call of GetSpecialAccountIn.
+func (mr *MockStoreMockRecorder) GetSpecialAccountIn(accountPrefix, currencyCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSpecialAccountIn", reflect.TypeOf((*MockStore)(nil).GetSpecialAccountIn), accountPrefix, currencyCode)
+}
+
 // Lock mocks base method.
 func (m *MockStore) Lock() error {
 	m.ctrl.T.Helper()
@@ -135,6 +266,20 @@ func (mr *MockStoreMockRecorder) Lock() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockStore)(nil).Lock))
 }
 
+// MoneySupply mocks base method.
+func (m *MockStore) MoneySupply() []payment.MoneySupply {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MoneySupply")
+	ret0, _ := ret[0].([]payment.MoneySupply)
+	return ret0
+}
+
+// MoneySupply indicates an expected call of MoneySupply.
+func (mr *MockStoreMockRecorder) MoneySupply() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MoneySupply", reflect.TypeOf((*MockStore)(nil).MoneySupply))
+}
+
 // PrintStore mocks base method.
 func (m *MockStore) PrintStore() error {
 	m.ctrl.T.Helper()
@@ -163,32 +308,76 @@ func (mr *MockStoreMockRecorder) PrintStoreJson() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PrintStoreJson", reflect.TypeOf((*MockStore)(nil).PrintStoreJson))
 }
 
-// Rollback mocks base method.
-func (m *MockStore) Rollback(arg0 []byte) error {
+// QueryAccounts mocks base method.
+func (m *MockStore) QueryAccounts(q payment.AccountQuery) (payment.AccountPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryAccounts", q)
+	ret0, _ := ret[0].(payment.AccountPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryAccounts indicates an expected call of QueryAccounts.
+func (mr *MockStoreMockRecorder) QueryAccounts(q interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryAccounts", reflect.TypeOf((*MockStore)(nil).QueryAccounts), q)
+}
+
+// ReceiveCorrespondent mocks base method.
+func (m *MockStore) ReceiveCorrespondent(settlement, dest, currencyCode string, amount float32, reference string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReceiveCorrespondent", settlement, dest, currencyCode, amount, reference)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ReceiveCorrespondent indicates an expected call of ReceiveCorrespondent.
+func (mr *MockStoreMockRecorder) ReceiveCorrespondent(settlement, dest, currencyCode, amount, reference interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReceiveCorrespondent", reflect.TypeOf((*MockStore)(nil).ReceiveCorrespondent), settlement, dest, currencyCode, amount, reference)
+}
+
+// Restore mocks base method.
+func (m *MockStore) Restore(json []byte) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Rollback", arg0)
+	ret := m.ctrl.Call(m, "Restore", json)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Rollback indicates an expected call of Rollback.
-func (mr *MockStoreMockRecorder) Rollback(arg0 interface{}) *gomock.Call {
+// Restore indicates an expected call of Restore.
+func (mr *MockStoreMockRecorder) Restore(json interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockStore)(nil).Restore), json)
+}
+
+// Statement mocks base method.
+func (m *MockStore) Statement(c payment.Customer, accountNum string, from, to time.Time) (payment.Statement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Statement", c, accountNum, from, to)
+	ret0, _ := ret[0].(payment.Statement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Statement indicates an expected call of Statement.
+func (mr *MockStoreMockRecorder) Statement(c, accountNum, from, to interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockStore)(nil).Rollback), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Statement", reflect.TypeOf((*MockStore)(nil).Statement), c, accountNum, from, to)
 }
 
 // Terminate mocks base method.
-func (m *MockStore) Terminate(arg0 payment.Account, arg1 float32) error {
+func (m *MockStore) Terminate(acc payment.Account, a float32) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Terminate", arg0, arg1)
+	ret := m.ctrl.Call(m, "Terminate", acc, a)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Terminate indicates an expected call of Terminate.
-func (mr *MockStoreMockRecorder) Terminate(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) Terminate(acc, a interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Terminate", reflect.TypeOf((*MockStore)(nil).Terminate), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Terminate", reflect.TypeOf((*MockStore)(nil).Terminate), acc, a)
 }
 
 // Transfer mocks base method.
@@ -205,18 +394,32 @@ func (mr *MockStoreMockRecorder) Transfer(s, d, amount interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockStore)(nil).Transfer), s, d, amount)
 }
 
+// TransferByNumber mocks base method.
+func (m *MockStore) TransferByNumber(source, dest string, amount float32) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferByNumber", source, dest, amount)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferByNumber indicates an expected call of TransferByNumber.
+func (mr *MockStoreMockRecorder) TransferByNumber(source, dest, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferByNumber", reflect.TypeOf((*MockStore)(nil).TransferByNumber), source, dest, amount)
+}
+
 // TransferJson mocks base method.
-func (m *MockStore) TransferJson(arg0 []byte) error {
+func (m *MockStore) TransferJson(json []byte) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TransferJson", arg0)
+	ret := m.ctrl.Call(m, "TransferJson", json)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // TransferJson indicates an expected call of TransferJson.
-func (mr *MockStoreMockRecorder) TransferJson(arg0 interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) TransferJson(json interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferJson", reflect.TypeOf((*MockStore)(nil).TransferJson), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferJson", reflect.TypeOf((*MockStore)(nil).TransferJson), json)
 }
 
 // Unlock mocks base method.
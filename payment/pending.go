@@ -0,0 +1,328 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	TransferPending  = "pending"
+	TransferInReview = "review"
+	TransferApproved = "approved"
+	TransferRejected = "rejected"
+	TransferExpired  = "expired"
+)
+
+const (
+	PendingTransferPrefix = "PT"
+	DefaultPendingTimeout = 24 * time.Hour
+	DefaultExpiryInterval = time.Minute
+)
+
+// PendingTransfer is a transfer waiting for approval (maker-checker)
+// or for a review after fraud screening.
+// The amount is reserved on the source account until it is resolved.
+type PendingTransfer struct {
+	Id        string    `json:"id"`
+	S         Account   `json:"source"`
+	D         Account   `json:"destination"`
+	Amount    float32   `json:"amount"`
+	Maker     string    `json:"maker"`
+	Checker   string    `json:"checker"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+	// ValueDate is the past value date of a back-valued correction, zero for a transfer.
+	ValueDate time.Time `json:"value_date"`
+}
+
+// WithApprovalThreshold makes transfers above the amount wait for approval.
+// Zero disables the approval workflow.
+func WithApprovalThreshold(amount float32) Option {
+	return func(ps *PaymentSystem) {
+		ps.approvalThreshold = amount
+	}
+}
+
+// WithApprovers sets the names allowed to approve or reject pending transfers.
+func WithApprovers(names ...string) Option {
+	return func(ps *PaymentSystem) {
+		for _, n := range names {
+			ps.approvers[n] = true
+		}
+	}
+}
+
+// WithPendingTimeout sets how long a transfer can wait for approval or review before it expires.
+func WithPendingTimeout(d time.Duration) Option {
+	return func(ps *PaymentSystem) {
+		ps.pendingTimeout = d
+	}
+}
+
+func (ps *PaymentSystem) requiresApproval(amount float32) bool {
+	return ps.approvalThreshold > 0 && amount > ps.approvalThreshold
+}
+
+/*
+This func reserves funds on the source account and registers a transfer
+waiting for approval or review depending on status.
+The maker is the actor set by SetActor when it is not given, a transfer
+without an initiator is refused, as nobody could be kept from checking it.
+*/
+func (ps *PaymentSystem) holdTransfer(maker string, s Account, d Account, amount float32, status string, reason string) (string, error) {
+	ps.ExpirePendingTransfers()
+
+	if maker == "" && ps.actor != DefaultActor {
+		maker = ps.actor
+	}
+
+	if maker == "" {
+		return "", fmt.Errorf("Transfer is imposible: %w", ErrMakerRequired)
+	}
+
+	_, src, ok := ps.lookup(s.CustomerId, s.Num)
+	if !ok {
+		return "", fmt.Errorf("Transfer is imposible: account not found! %s\n", s.Num)
+	}
+
+	if _, _, ok := ps.lookup(d.CustomerId, d.Num); !ok {
+		return "", fmt.Errorf("Transfer is imposible: account not found! %s\n", d.Num)
+	}
+
+	if src.Balance-src.Reserved < amount {
+		return "", fmt.Errorf("Transfer is imposible: insufficient funds on account %s: %w", s.Num, ErrInsufficientFunds)
+	}
+
+	if err := ps.reserve(src, amount); err != nil {
+		return "", err
+	}
+
+	now := ps.now()
+	pt := &PendingTransfer{
+		Id:        ps.nextID(PendingTransferPrefix),
+		S:         s,
+		D:         d,
+		Amount:    amount,
+		Maker:     maker,
+		Status:    status,
+		Reason:    reason,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ps.pendingTimeout),
+	}
+	ps.pending[pt.Id] = pt
+
+	ps.logger.Info("transfer is held", "op", OpTransfer, "tx_id", pt.Id, "status", status, "account", s.Num, "destination", d.Num, "amount", amount)
+
+	return pt.Id, nil
+}
+
+// execute moves the funds of the held transfer, a correction keeps its value date.
+func (ps *PaymentSystem) execute(pt *PendingTransfer, d Account) error {
+	if pt.ValueDate.IsZero() {
+		return ps.transfer(pt.S, d, pt.Amount)
+	}
+
+	c, err := ps.correct(pt.S, d, pt.Amount, pt.ValueDate)
+	if err != nil && c.TransactionId != "" {
+		ps.logger.Error("cannot post interest adjustment of correction", "tx_id", c.TransactionId, "pending_id", pt.Id, "error", err)
+
+		return nil
+	}
+
+	return err
+}
+
+// reserve changes the amount of funds held on the account by delta.
+func (ps *PaymentSystem) reserve(a Account, delta float32) error {
+	key, acc, ok := ps.lookup(a.CustomerId, a.Num)
+	if !ok {
+		return fmt.Errorf("account %s not found", a.Num)
+	}
+
+	acc.Reserved += delta
+
+	_, err := ps.put(key, acc)
+
+	return err
+}
+
+// checkApprover verifies that the approver may resolve the pending transfer.
+func (ps *PaymentSystem) checkApprover(id string, approver string) (*PendingTransfer, error) {
+	ps.ExpirePendingTransfers()
+
+	pt, ok := ps.pending[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+	}
+
+	if pt.Status != TransferPending {
+		return nil, fmt.Errorf("%w: %s is %s", ErrTransferNotPending, id, pt.Status)
+	}
+
+	if !ps.approvers[approver] {
+		return nil, fmt.Errorf("%w: %s", ErrApproverNotAllowed, approver)
+	}
+
+	if pt.Maker != "" && pt.Maker == approver {
+		return nil, fmt.Errorf("%w: %s", ErrApproverIsInitiator, approver)
+	}
+
+	return pt, nil
+}
+
+/*
+This func approves the pending transfer and moves the reserved funds.
+The limits are checked again, the transfers executed since the transfer
+was held count against them.
+*/
+func (ps *PaymentSystem) ApproveTransfer(id string, approver string) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpApproveTransfer, approver, start, auditInput{"tx_id": id}, "", err)
+	}()
+
+	pt, err := ps.checkApprover(id, approver)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.checkLimits(pt.S, pt.Amount); err != nil {
+		return err
+	}
+
+	d, ok := ps.destination(pt.D)
+	if !ok {
+		return fmt.Errorf("Approval is imposible: account %s is not valid or blocked", pt.D.Num)
+	}
+
+	if err := ps.reserve(pt.S, -pt.Amount); err != nil {
+		return err
+	}
+
+	if err := ps.execute(pt, d); err != nil {
+		_ = ps.reserve(pt.S, pt.Amount)
+
+		return err
+	}
+
+	pt.Status = TransferApproved
+	pt.Checker = approver
+
+	return nil
+}
+
+/*
+This func rejects the pending transfer and releases the reserved funds.
+*/
+func (ps *PaymentSystem) RejectTransfer(id string, approver string) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpRejectTransfer, approver, start, auditInput{"tx_id": id}, "", err)
+	}()
+
+	pt, err := ps.checkApprover(id, approver)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.reserve(pt.S, -pt.Amount); err != nil {
+		return err
+	}
+
+	pt.Status = TransferRejected
+	pt.Checker = approver
+
+	ps.publishFailed(pt, "rejected by "+approver)
+
+	return nil
+}
+
+/*
+This func expires the transfers waiting for approval or review
+whose timeout has passed and returns the number of expired transfers.
+*/
+func (ps *PaymentSystem) ExpirePendingTransfers() int {
+	now := ps.now()
+	n := 0
+
+	for _, pt := range ps.pending {
+		if pt.Status != TransferPending && pt.Status != TransferInReview || now.Before(pt.ExpiresAt) {
+			continue
+		}
+
+		if err := ps.reserve(pt.S, -pt.Amount); err != nil {
+			ps.logger.Error("cannot release funds of expired transfer", "tx_id", pt.Id, "account", pt.S.Num, "error", err)
+		}
+
+		pt.Status = TransferExpired
+		n++
+
+		ps.publishFailed(pt, "expired")
+
+		ps.logger.Info("transfer has expired", "tx_id", pt.Id, "account", pt.S.Num, "amount", pt.Amount)
+	}
+
+	return n
+}
+
+// GetPendingTransfer returns the transfer with the given id in any status.
+func (ps *PaymentSystem) GetPendingTransfer(id string) (PendingTransfer, error) {
+	ps.ExpirePendingTransfers()
+
+	pt, ok := ps.pending[id]
+	if !ok {
+		return PendingTransfer{}, fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+	}
+
+	return *pt, nil
+}
+
+/*
+This func expires the overdue transfers every interval until the context is done,
+so their funds are released even when nobody approves or lists the transfers.
+*/
+func (ps *PaymentSystem) RunExpiry(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		_ = ps.Lock()
+		ps.ExpirePendingTransfers()
+		_ = ps.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// PendingTransfers returns the transfers waiting for approval ordered by id.
+func (ps *PaymentSystem) PendingTransfers() []PendingTransfer {
+	return ps.transfersWithStatus(TransferPending)
+}
+
+func (ps *PaymentSystem) transfersWithStatus(status string) []PendingTransfer {
+	ps.ExpirePendingTransfers()
+
+	res := make([]PendingTransfer, 0, len(ps.pending))
+
+	for _, pt := range ps.pending {
+		if pt.Status == status {
+			res = append(res, *pt)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Id < res[j].Id
+	})
+
+	return res
+}
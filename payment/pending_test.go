@@ -0,0 +1,220 @@
+package payment_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sourceNum = "BY11ABCD00000000000000000001"
+	destNum   = "BY22ABCD00000000000000000002"
+)
+
+const pendingStore = `{
+	"1": {"K1": {"customer_id": "1", "num": "` + sourceNum + `", "currency_code": "BYN", "status": "active", "balance": 1000}},
+	"2": {"K2": {"customer_id": "2", "num": "` + destNum + `", "currency_code": "BYN", "status": "active", "balance": 0}}
+}`
+
+func newPendingSystem(t *testing.T, now *time.Time) (*payment.PaymentSystem, payment.Account, payment.Account) {
+	t.Helper()
+
+	ps := payment.NewPaymentSystem(
+		payment.WithApprovalThreshold(500),
+		payment.WithApprovers("checker"),
+		payment.WithPendingTimeout(time.Hour),
+		payment.WithClock(func() time.Time { return *now }),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "Customer One", payment.AccountPrefix), payment.BYN)
+	require.NoError(t, err)
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "Customer Two", payment.AccountPrefix), payment.BYN)
+	require.NoError(t, err)
+
+	return ps, s, d
+}
+
+func TestPaymentSystem_SubmitTransfer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ps, s, d := newPendingSystem(t, &now)
+
+	id, err := ps.SubmitTransfer("maker", s, d, 100)
+	require.NoError(t, err)
+	assert.Empty(t, id, "transfer below threshold is executed immediately")
+
+	id, err = ps.SubmitTransfer("maker", s, d, 600)
+	require.NoError(t, err)
+	require.NotEmpty(t, id)
+
+	s, _ = ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	assert.Equal(t, float32(900), s.Balance)
+	assert.Equal(t, float32(600), s.Reserved)
+
+	_, err = ps.SubmitTransfer("maker", s, d, 400)
+	assert.ErrorIs(t, err, payment.ErrInsufficientFunds, "reserved funds cannot be spent twice")
+
+	assert.Len(t, ps.PendingTransfers(), 1)
+}
+
+func TestPaymentSystem_ApproveTransfer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		desc          string
+		approver      string
+		reject        bool
+		advance       time.Duration
+		expectedErr   error
+		expectedState string
+		expectedDest  float32
+	}{
+		{
+			desc:          "authorized approver moves the reserved funds",
+			approver:      "checker",
+			expectedState: payment.TransferApproved,
+			expectedDest:  600,
+		},
+		{
+			desc:          "authorized approver rejects and releases the funds",
+			approver:      "checker",
+			reject:        true,
+			expectedState: payment.TransferRejected,
+		},
+		{
+			desc:          "unauthorized person cannot approve",
+			approver:      "maker",
+			expectedErr:   payment.ErrApproverNotAllowed,
+			expectedState: payment.TransferPending,
+		},
+		{
+			desc:          "expired transfer cannot be approved",
+			approver:      "checker",
+			advance:       2 * time.Hour,
+			expectedErr:   payment.ErrTransferNotPending,
+			expectedState: payment.TransferExpired,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			clock := now
+			ps, s, d := newPendingSystem(t, &clock)
+
+			id, err := ps.SubmitTransfer("maker", s, d, 600)
+			require.NoError(t, err)
+
+			clock = clock.Add(tt.advance)
+
+			if tt.reject {
+				err = ps.RejectTransfer(id, tt.approver)
+			} else {
+				err = ps.ApproveTransfer(id, tt.approver)
+			}
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			pt, err := ps.GetPendingTransfer(id)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedState, pt.Status)
+
+			d, _ = ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+			assert.Equal(t, tt.expectedDest, d.Balance)
+		})
+	}
+}
+
+func TestPaymentSystem_ApproveTransfer_SameMaker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ps, s, d := newPendingSystem(t, &now)
+
+	id, err := ps.SubmitTransfer("checker", s, d, 600)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, ps.ApproveTransfer(id, "checker"), payment.ErrApproverIsInitiator)
+}
+
+func TestPaymentSystem_ApproveTransfer_Limits(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ps, s, d := newPendingSystem(t, &now)
+	ps.SetAccountLimit(sourceNum, payment.Limit{DailyAmount: 700})
+
+	id, err := ps.SubmitTransfer("maker", s, d, 600)
+	require.NoError(t, err)
+
+	// the held amount is not used yet, the limit allows the next transfer
+	_, err = ps.SubmitTransfer("maker", s, d, 200)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, ps.ApproveTransfer(id, "checker"), payment.ErrLimitExceeded)
+
+	pt, err := ps.GetPendingTransfer(id)
+	require.NoError(t, err)
+	assert.Equal(t, payment.TransferPending, pt.Status)
+
+	s, err = ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+	assert.Equal(t, float32(800), s.Balance)
+	assert.Equal(t, float32(600), s.Reserved)
+
+	require.NoError(t, ps.RejectTransfer(id, "checker"))
+}
+
+func TestPaymentSystem_RunExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ps, s, d := newPendingSystem(t, &now)
+
+	id, err := ps.SubmitTransfer("maker", s, d, 600)
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go ps.RunExpiry(ctx, time.Millisecond)
+
+	// the funds are released without anybody looking at the transfer
+	assert.Eventually(t, func() bool {
+		_ = ps.Lock()
+		defer func() { _ = ps.Unlock() }()
+
+		a, err := ps.AccountByNumber(sourceNum)
+
+		return err == nil && a.Reserved == 0
+	}, time.Second, time.Millisecond)
+
+	_ = ps.Lock()
+	defer func() { _ = ps.Unlock() }()
+
+	pt, err := ps.GetPendingTransfer(id)
+	require.NoError(t, err)
+	assert.Equal(t, payment.TransferExpired, pt.Status)
+}
+
+func TestPaymentSystem_Transfer_Maker(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	ps, s, d := newPendingSystem(t, &now)
+
+	assert.ErrorIs(t, ps.Transfer(s, d, 600), payment.ErrMakerRequired, "nobody could be kept from approving it")
+	assert.Empty(t, ps.PendingTransfers())
+
+	// the actor is the initiator of the transfer
+	ps.SetActor("checker")
+	require.NoError(t, ps.Transfer(s, d, 600))
+
+	pending := ps.PendingTransfers()
+	require.Len(t, pending, 1)
+	assert.Equal(t, "checker", pending[0].Maker)
+	assert.ErrorIs(t, ps.ApproveTransfer(pending[0].Id, "checker"), payment.ErrApproverIsInitiator)
+}
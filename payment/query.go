@@ -0,0 +1,287 @@
+package payment
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Orders of the accounts returned by QueryAccounts, ties are ordered by number.
+// A descending query reverses the whole order, ties included.
+const (
+	SortByNumber   = "number"
+	SortByBalance  = "balance"
+	SortByCreated  = "created_at"
+	SortByCustomer = "customer_id"
+)
+
+const (
+	// DefaultPageSize is the number of accounts of a page when the query has no limit.
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page a query may ask for.
+	MaxPageSize = 1000
+)
+
+/*
+This struct selects accounts. Empty fields do not filter, the type is
+the first two letters of the number, e.g. BY, SE or ST. The creation
+date range includes From and excludes To. The cursor continues
+the query after the last account of the previous page.
+*/
+type AccountQuery struct {
+	CustomerId   string    `json:"customer_id,omitempty"`
+	CurrencyCode string    `json:"currency_code,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Type         string    `json:"type,omitempty"`
+	MinBalance   *float32  `json:"min_balance,omitempty"`
+	MaxBalance   *float32  `json:"max_balance,omitempty"`
+	CreatedFrom  time.Time `json:"created_from,omitempty"`
+	CreatedTo    time.Time `json:"created_to,omitempty"`
+	SortBy       string    `json:"sort_by,omitempty"`
+	Desc         bool      `json:"desc,omitempty"`
+	Limit        int       `json:"limit,omitempty"`
+	Cursor       string    `json:"cursor,omitempty"`
+}
+
+// AccountPage is a page of the accounts matching the query.
+type AccountPage struct {
+	Accounts []Account `json:"accounts"`
+	// Total is the number of the accounts matching the query on all pages.
+	Total int `json:"total"`
+	// NextCursor continues the query, it is empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the position of the last account of a page.
+type cursor struct {
+	SortBy     string    `json:"s"`
+	Desc       bool      `json:"d,omitempty"`
+	Num        string    `json:"n"`
+	Balance    float32   `json:"b,omitempty"`
+	CreatedAt  time.Time `json:"c,omitempty"`
+	CustomerId string    `json:"u,omitempty"`
+}
+
+/*
+This func returns a page of the accounts matching the query. The accounts
+are selected by the index of the store: the accounts of the customer or
+the smallest set of the accounts with the currency, status and type,
+so a selective query does not read every account. The caller holds the lock.
+*/
+func (ps *PaymentSystem) QueryAccounts(q AccountQuery) (AccountPage, error) {
+	if err := q.validate(); err != nil {
+		return AccountPage{}, err
+	}
+
+	var candidates []Account
+
+	if q.CustomerId != "" {
+		for _, a := range ps.store[q.CustomerId] {
+			candidates = append(candidates, a)
+		}
+
+		return FilterAccounts(candidates, q)
+	}
+
+	var smallest map[string]bool
+
+	for _, set := range []struct {
+		value string
+		sets  map[string]map[string]bool
+	}{
+		{q.CurrencyCode, ps.accounts.byCurrency},
+		{q.Status, ps.accounts.byStatus},
+		{q.Type, ps.accounts.byType},
+	} {
+		if set.value == "" {
+			continue
+		}
+
+		nums := set.sets[set.value]
+		if smallest == nil || len(nums) < len(smallest) {
+			smallest = nums
+		}
+
+		if len(smallest) == 0 {
+			return AccountPage{Accounts: []Account{}}, nil
+		}
+	}
+
+	if smallest == nil {
+		for num := range ps.accounts.byNumber {
+			candidates = append(candidates, ps.indexed(num))
+		}
+	}
+
+	for num := range smallest {
+		candidates = append(candidates, ps.indexed(num))
+	}
+
+	return FilterAccounts(candidates, q)
+}
+
+// indexed returns the account with the number from the store.
+func (ps *PaymentSystem) indexed(num string) Account {
+	ref := ps.accounts.byNumber[num]
+
+	return ps.store[ref.customerId][ref.key]
+}
+
+/*
+This func returns a page of the accounts of the list matching the query.
+It is the query without an index, e.g. for the accounts read from
+a remote server.
+*/
+func FilterAccounts(list []Account, q AccountQuery) (AccountPage, error) {
+	if err := q.validate(); err != nil {
+		return AccountPage{}, err
+	}
+
+	var after *cursor
+
+	if q.Cursor != "" {
+		c, err := decodeCursor(q)
+		if err != nil {
+			return AccountPage{}, err
+		}
+
+		after = &c
+	}
+
+	matched := make([]Account, 0, len(list))
+
+	for _, a := range list {
+		if q.matches(a) {
+			matched = append(matched, a)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool { return q.less(matched[i], matched[j]) })
+
+	page := AccountPage{Accounts: []Account{}, Total: len(matched)}
+
+	start := 0
+	if after != nil {
+		last := Account{Num: after.Num, Balance: after.Balance, CreatedAt: after.CreatedAt, CustomerId: after.CustomerId}
+		start = sort.Search(len(matched), func(i int) bool { return q.less(last, matched[i]) })
+	}
+
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+
+	end := min(start+limit, len(matched))
+	page.Accounts = append(page.Accounts, matched[start:end]...)
+
+	if end < len(matched) {
+		page.NextCursor = encodeCursor(q, matched[end-1])
+	}
+
+	return page, nil
+}
+
+func (q AccountQuery) validate() error {
+	switch q.SortBy {
+	case "", SortByNumber, SortByBalance, SortByCreated, SortByCustomer:
+	default:
+		return fmt.Errorf("%w: unknown sort %q", ErrInvalidQuery, q.SortBy)
+	}
+
+	if q.Status != "" && q.Status != Active && q.Status != Blocked {
+		return fmt.Errorf("%w: unknown status %q", ErrInvalidQuery, q.Status)
+	}
+
+	if q.MinBalance != nil && q.MaxBalance != nil && *q.MinBalance > *q.MaxBalance {
+		return fmt.Errorf("%w: min balance %.2f is greater than max balance %.2f", ErrInvalidQuery, *q.MinBalance, *q.MaxBalance)
+	}
+
+	if !q.CreatedFrom.IsZero() && !q.CreatedTo.IsZero() && !q.CreatedFrom.Before(q.CreatedTo) {
+		return fmt.Errorf("%w: created from %s is not before created to %s", ErrInvalidQuery, q.CreatedFrom.Format(time.RFC3339), q.CreatedTo.Format(time.RFC3339))
+	}
+
+	if q.Limit < 0 || q.Limit > MaxPageSize {
+		return fmt.Errorf("%w: limit must be between 0 and %d", ErrInvalidQuery, MaxPageSize)
+	}
+
+	return nil
+}
+
+func (q AccountQuery) matches(a Account) bool {
+	switch {
+	case q.CustomerId != "" && a.CustomerId != q.CustomerId,
+		q.CurrencyCode != "" && a.CurrencyCode != q.CurrencyCode,
+		q.Status != "" && a.Status != q.Status,
+		q.Type != "" && accountType(a.Num) != q.Type,
+		q.MinBalance != nil && a.Balance < *q.MinBalance,
+		q.MaxBalance != nil && a.Balance > *q.MaxBalance,
+		!q.CreatedFrom.IsZero() && a.CreatedAt.Before(q.CreatedFrom),
+		!q.CreatedTo.IsZero() && !a.CreatedAt.Before(q.CreatedTo):
+		return false
+	}
+
+	return true
+}
+
+// less orders the accounts by the sort of the query and then by number.
+func (q AccountQuery) less(a Account, b Account) bool {
+	if q.Desc {
+		a, b = b, a
+	}
+
+	switch q.SortBy {
+	case SortByBalance:
+		if a.Balance != b.Balance {
+			return a.Balance < b.Balance
+		}
+	case SortByCreated:
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+	case SortByCustomer:
+		if a.CustomerId != b.CustomerId {
+			return a.CustomerId < b.CustomerId
+		}
+	}
+
+	return a.Num < b.Num
+}
+
+func encodeCursor(q AccountQuery, last Account) string {
+	c := cursor{SortBy: q.SortBy, Desc: q.Desc, Num: last.Num}
+
+	switch q.SortBy {
+	case SortByBalance:
+		c.Balance = last.Balance
+	case SortByCreated:
+		c.CreatedAt = last.CreatedAt
+	case SortByCustomer:
+		c.CustomerId = last.CustomerId
+	}
+
+	data, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads the cursor of the query, which must have the same order.
+func decodeCursor(q AccountQuery) (cursor, error) {
+	var c cursor
+
+	data, err := base64.RawURLEncoding.DecodeString(q.Cursor)
+	if err == nil {
+		err = json.Unmarshal(data, &c)
+	}
+
+	if err != nil || c.Num == "" {
+		return c, fmt.Errorf("%w: malformed cursor", ErrInvalidQuery)
+	}
+
+	if c.SortBy != q.SortBy || c.Desc != q.Desc {
+		return c, fmt.Errorf("%w: the cursor belongs to a query with another order", ErrInvalidQuery)
+	}
+
+	return c, nil
+}
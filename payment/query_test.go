@@ -0,0 +1,163 @@
+package payment_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newQuerySystem(t *testing.T) *payment.PaymentSystem {
+	t.Helper()
+
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(`{
+		"0": {
+			"SE": {"customer_id": "0", "num": "SE00MMMM00000000000000000001", "currency_code": "BYN", "status": "active", "balance": 500, "created_at": "2024-01-01T00:00:00Z", "version": 1}
+		},
+		"1": {
+			"K1": {"customer_id": "1", "num": "BY11ABCD00000000000000000001", "currency_code": "BYN", "status": "active", "balance": 100, "created_at": "2024-01-02T00:00:00Z", "version": 1},
+			"K2": {"customer_id": "1", "num": "BY11ABCD00000000000000000002", "currency_code": "USD", "status": "blocked", "balance": 20, "created_at": "2024-01-03T00:00:00Z", "version": 1}
+		},
+		"2": {
+			"K3": {"customer_id": "2", "num": "BY22ABCD00000000000000000003", "currency_code": "BYN", "status": "active", "balance": 300, "created_at": "2024-01-04T00:00:00Z", "version": 1},
+			"K4": {"customer_id": "2", "num": "BY22ABCD00000000000000000004", "currency_code": "BYN", "status": "active", "balance": 100, "created_at": "2024-01-05T00:00:00Z", "version": 1}
+		}
+	}`)))
+
+	return ps
+}
+
+func nums(page payment.AccountPage) []string {
+	res := make([]string, 0, len(page.Accounts))
+	for _, a := range page.Accounts {
+		res = append(res, a.Num[len(a.Num)-1:])
+	}
+
+	return res
+}
+
+func TestPaymentSystem_QueryAccounts(t *testing.T) {
+	ps := newQuerySystem(t)
+
+	low, high := float32(50), float32(300)
+
+	testCases := []struct {
+		desc  string
+		query payment.AccountQuery
+		want  []string
+	}{
+		{desc: "all accounts by number", query: payment.AccountQuery{}, want: []string{"1", "2", "3", "4", "1"}},
+		{desc: "customer", query: payment.AccountQuery{CustomerId: "2"}, want: []string{"3", "4"}},
+		{desc: "currency", query: payment.AccountQuery{CurrencyCode: payment.USD}, want: []string{"2"}},
+		{desc: "status", query: payment.AccountQuery{Status: payment.Blocked}, want: []string{"2"}},
+		{desc: "type", query: payment.AccountQuery{Type: payment.AccountStateEmissionPrefix}, want: []string{"1"}},
+		{desc: "unknown currency", query: payment.AccountQuery{CurrencyCode: payment.EUR}, want: []string{}},
+		{
+			desc:  "balance range by balance descending",
+			query: payment.AccountQuery{CurrencyCode: payment.BYN, MinBalance: &low, MaxBalance: &high, SortBy: payment.SortByBalance, Desc: true},
+			want:  []string{"3", "4", "1"},
+		},
+		{
+			desc:  "created range by creation",
+			query: payment.AccountQuery{CreatedFrom: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC), CreatedTo: time.Date(2024, 1, 5, 0, 0, 0, 0, time.UTC), SortBy: payment.SortByCreated},
+			want:  []string{"1", "2", "3"},
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+
+		t.Run(tt.desc, func(t *testing.T) {
+			page, err := ps.QueryAccounts(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, nums(page))
+			assert.Equal(t, len(tt.want), page.Total)
+			assert.Empty(t, page.NextCursor)
+		})
+	}
+
+	_, err := ps.QueryAccounts(payment.AccountQuery{SortBy: "name"})
+	assert.ErrorIs(t, err, payment.ErrInvalidQuery)
+
+	_, err = ps.QueryAccounts(payment.AccountQuery{MinBalance: &high, MaxBalance: &low})
+	assert.ErrorIs(t, err, payment.ErrInvalidQuery)
+
+	_, err = ps.QueryAccounts(payment.AccountQuery{Cursor: "garbage"})
+	assert.ErrorIs(t, err, payment.ErrInvalidQuery)
+}
+
+func TestPaymentSystem_QueryAccountsPages(t *testing.T) {
+	ps := newQuerySystem(t)
+
+	q := payment.AccountQuery{SortBy: payment.SortByBalance, Limit: 2}
+
+	var got []string
+
+	for {
+		page, err := ps.QueryAccounts(q)
+		require.NoError(t, err)
+		assert.Equal(t, 5, page.Total)
+
+		got = append(got, nums(page)...)
+
+		if page.NextCursor == "" {
+			break
+		}
+
+		q.Cursor = page.NextCursor
+
+		// a change of the accounts between the pages does not repeat or skip the rest
+		if len(got) == 2 {
+			a, err := ps.GetAccountByNumber(payment.NewCustomer("1", "", ""), "BY11ABCD00000000000000000002")
+			require.NoError(t, err)
+			require.NoError(t, ps.CloseAccount(a))
+		}
+	}
+
+	assert.Equal(t, []string{"2", "1", "4", "3", "1"}, got)
+
+	_, err := ps.QueryAccounts(payment.AccountQuery{SortBy: payment.SortByNumber, Cursor: q.Cursor})
+	assert.ErrorContains(t, err, "another order")
+}
+
+func TestPaymentSystem_QueryAccountsIndex(t *testing.T) {
+	ps := newQuerySystem(t)
+
+	a, err := ps.GetAccountByNumber(payment.NewCustomer("2", "", ""), "BY22ABCD00000000000000000003")
+	require.NoError(t, err)
+	require.NoError(t, ps.CloseAccount(a))
+
+	page, err := ps.QueryAccounts(payment.AccountQuery{Status: payment.Blocked})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, nums(page), "the index follows the status")
+
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("3", "", ""), payment.AccountPrefix, payment.EUR, 10))
+
+	page, err = ps.QueryAccounts(payment.AccountQuery{CurrencyCode: payment.EUR, Status: payment.Active})
+	require.NoError(t, err)
+	require.Len(t, page.Accounts, 1)
+	assert.Equal(t, "3", page.Accounts[0].CustomerId)
+
+	// the index is rebuilt from the snapshot
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, restored.LoadSnapshot(data))
+
+	page, err = restored.QueryAccounts(payment.AccountQuery{Status: payment.Blocked})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"2", "3"}, nums(page))
+}
+
+func TestFilterAccounts(t *testing.T) {
+	list := newQuerySystem(t).Accounts("")
+
+	page, err := payment.FilterAccounts(list, payment.AccountQuery{SortBy: payment.SortByCustomer, Desc: true, Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"4", "3", "2"}, nums(page))
+	assert.NotEmpty(t, page.NextCursor)
+}
@@ -0,0 +1,203 @@
+// Package recon reconciles the ledger of an account against an external
+// statement of the same account, e.g. a bank CSV export or an MT940.
+package recon
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/swift"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatMT940 = "mt940"
+)
+
+// csvColumns is the header of the CSV statement, the id column is optional.
+var csvColumns = []string{"date", "amount", "reference", "description"}
+
+// Entry is a movement of the account on one side of the reconciliation.
+type Entry struct {
+	// Id is the transaction id of the ledger or the bank reference of the statement.
+	Id   string    `json:"id,omitempty"`
+	Date time.Time `json:"date"`
+	// Amount is positive for a credit and negative for a debit of the account.
+	Amount      float32 `json:"amount"`
+	Reference   string  `json:"reference,omitempty"`
+	Description string  `json:"description,omitempty"`
+}
+
+/*
+This func returns the entries of the ledger from the statement of the account.
+The date of an entry is its value date, the time of the posting
+for the entries posted before value dates existed.
+*/
+func FromStatement(st payment.Statement) []Entry {
+	res := make([]Entry, 0, len(st.Lines))
+
+	for _, l := range st.Lines {
+		date := l.ValueDate
+		if date.IsZero() {
+			date = l.Time
+		}
+
+		res = append(res, Entry{
+			Id:          l.Id,
+			Date:        day(date),
+			Amount:      l.Credit - l.Debit,
+			Reference:   l.Reference,
+			Description: l.Type,
+		})
+	}
+
+	return res
+}
+
+// FromMT940 returns the entries of the MT940 statement.
+func FromMT940(mt swift.MT940) []Entry {
+	res := make([]Entry, 0, len(mt.Lines))
+
+	for _, l := range mt.Lines {
+		e := Entry{
+			Id:          l.BankReference,
+			Date:        day(l.ValueDate),
+			Amount:      l.Amount,
+			Reference:   l.Reference,
+			Description: l.Info,
+		}
+
+		if l.Mark == swift.Debit {
+			e.Amount = -e.Amount
+		}
+
+		if e.Reference == swift.NoReference {
+			e.Reference = ""
+		}
+
+		res = append(res, e)
+	}
+
+	return res
+}
+
+/*
+This func reads the entries of the CSV statement with the header
+date,amount,reference,description and an optional last id column.
+The date is 2006-01-02, the amount is negative for a debit.
+*/
+func ParseCSV(r io.Reader) ([]Entry, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("recon: statement is empty")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(header) != len(csvColumns) && !(len(header) == len(csvColumns)+1 && strings.TrimSpace(header[len(csvColumns)]) == "id") {
+		return nil, fmt.Errorf("recon: header must be %s[,id]", strings.Join(csvColumns, ","))
+	}
+
+	for i, col := range csvColumns {
+		if strings.TrimSpace(header[i]) != col {
+			return nil, fmt.Errorf("recon: column %d must be %s, got %q", i+1, col, header[i])
+		}
+	}
+
+	cr.FieldsPerRecord = len(header)
+
+	var res []Entry
+
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row, _ := cr.FieldPos(0)
+
+		date, err := time.Parse(time.DateOnly, strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("recon: line %d: invalid date %q", row, rec[0])
+		}
+
+		amount, err := strconv.ParseFloat(strings.TrimSpace(rec[1]), 32)
+		if err != nil || math.IsNaN(amount) || math.IsInf(amount, 0) {
+			return nil, fmt.Errorf("recon: line %d: invalid amount %q", row, rec[1])
+		}
+
+		e := Entry{
+			Date:        date,
+			Amount:      float32(amount),
+			Reference:   strings.TrimSpace(rec[2]),
+			Description: strings.TrimSpace(rec[3]),
+		}
+
+		if len(rec) > len(csvColumns) {
+			e.Id = strings.TrimSpace(rec[len(csvColumns)])
+		}
+
+		res = append(res, e)
+	}
+}
+
+/*
+This func reads the external statement in the format, csv or mt940.
+An empty format is detected from the content: a SWIFT message is MT940.
+*/
+func Parse(r io.Reader, format string) ([]Entry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == "" {
+		format = FormatCSV
+
+		if text := strings.TrimSpace(string(data)); strings.HasPrefix(text, "{") || strings.HasPrefix(text, ":") {
+			format = FormatMT940
+		}
+	}
+
+	switch format {
+	case FormatCSV:
+		return ParseCSV(strings.NewReader(string(data)))
+	case FormatMT940:
+		m, err := swift.ParseString(string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		mt, err := swift.ParseMT940(m)
+		if err != nil {
+			return nil, err
+		}
+
+		return FromMT940(mt), nil
+	}
+
+	return nil, fmt.Errorf("unknown statement format %q", format)
+}
+
+// day returns the date of the time in UTC.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
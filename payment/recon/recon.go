@@ -0,0 +1,352 @@
+package recon
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+// Sides of the reconciliation.
+const (
+	SideLedger   = "ledger"
+	SideExternal = "external"
+)
+
+// PostingPrefix starts the reference of a break booked on the suspense account.
+const PostingPrefix = "RECON "
+
+// Rules that matched two entries.
+const (
+	RuleReference = "reference"
+	RuleAmount    = "amount_date"
+)
+
+// Tolerance is how far apart the amount and the date of matched entries may be.
+type Tolerance struct {
+	Amount float32 `json:"amount"`
+	Days   int     `json:"days"`
+}
+
+// Match is a pair of entries of the ledger and of the external statement.
+type Match struct {
+	Ledger     Entry   `json:"ledger"`
+	External   Entry   `json:"external"`
+	Rule       string  `json:"rule"`
+	AmountDiff float32 `json:"amount_diff"`
+	DayDiff    int     `json:"day_diff"`
+}
+
+// Duplicate is an entry repeating an earlier entry of the same side.
+type Duplicate struct {
+	Side  string `json:"side"`
+	Entry Entry  `json:"entry"`
+	// Of is the position of the repeated entry in its side.
+	Of int `json:"of"`
+}
+
+// Booked is a break which was booked on the suspense account by an earlier run.
+type Booked struct {
+	Side  string `json:"side"`
+	Entry Entry  `json:"entry"`
+	// Posting is the ledger entry of the suspense posting.
+	Posting Entry `json:"posting"`
+}
+
+// Report is the result of the reconciliation.
+type Report struct {
+	Tolerance         Tolerance   `json:"tolerance"`
+	Matched           []Match     `json:"matched"`
+	UnmatchedLedger   []Entry     `json:"unmatched_ledger"`
+	UnmatchedExternal []Entry     `json:"unmatched_external"`
+	Duplicates        []Duplicate `json:"duplicates"`
+	Booked            []Booked    `json:"booked,omitempty"`
+}
+
+// Reconciled tells whether every entry of both sides is matched.
+func (r Report) Reconciled() bool {
+	return len(r.UnmatchedLedger) == 0 && len(r.UnmatchedExternal) == 0 && len(r.Duplicates) == 0
+}
+
+/*
+This func matches the entries of the ledger with the entries of the external
+statement. Entries repeating the date, amount and reference of an earlier
+entry of the same side are reported as duplicates and are not matched.
+Entries sharing a reference are matched first, then the entries with equal
+amount and date when at most one of them has a reference. Both rules accept
+the differences within the tolerance and prefer the closest candidate.
+The breaks booked by PostBreaks are reported as booked with their postings,
+so reconciling the period again does not book them twice.
+*/
+func Reconcile(ledger []Entry, external []Entry, tol Tolerance) Report {
+	r := Report{Tolerance: tol}
+
+	ledgerUsed := duplicates(SideLedger, ledger, &r)
+	externalUsed := duplicates(SideExternal, external, &r)
+
+	booked(ledger, external, ledgerUsed, externalUsed, &r)
+
+	for _, rule := range []string{RuleReference, RuleAmount} {
+		for i, l := range ledger {
+			if ledgerUsed[i] {
+				continue
+			}
+
+			best := -1
+
+			var bestDays int
+			var bestDiff float32
+
+			for j, e := range external {
+				if externalUsed[j] || !matches(rule, l, e, tol) {
+					continue
+				}
+
+				days, diff := dayDiff(l.Date, e.Date), amountDiff(l.Amount, e.Amount)
+
+				if best < 0 || days < bestDays || days == bestDays && diff < bestDiff {
+					best, bestDays, bestDiff = j, days, diff
+				}
+			}
+
+			if best < 0 {
+				continue
+			}
+
+			ledgerUsed[i], externalUsed[best] = true, true
+
+			r.Matched = append(r.Matched, Match{
+				Ledger:     l,
+				External:   external[best],
+				Rule:       rule,
+				AmountDiff: external[best].Amount - l.Amount,
+				DayDiff:    bestDays,
+			})
+		}
+	}
+
+	for i, l := range ledger {
+		if !ledgerUsed[i] {
+			r.UnmatchedLedger = append(r.UnmatchedLedger, l)
+		}
+	}
+
+	for j, e := range external {
+		if !externalUsed[j] {
+			r.UnmatchedExternal = append(r.UnmatchedExternal, e)
+		}
+	}
+
+	return r
+}
+
+/*
+This func reports the duplicates of the side and returns them marked as used.
+Only entries with a reference are checked, equal payments without
+a reference, e.g. two coffees on one day, are not duplicates.
+*/
+func duplicates(side string, entries []Entry, r *Report) []bool {
+	used := make([]bool, len(entries))
+	seen := make(map[string]int)
+
+	for i, e := range entries {
+		if e.Reference == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%.2f|%s", e.Date.Format(time.DateOnly), e.Amount, normalize(e.Reference))
+
+		if first, ok := seen[key]; ok {
+			used[i] = true
+			r.Duplicates = append(r.Duplicates, Duplicate{Side: side, Entry: e, Of: first})
+
+			continue
+		}
+
+		seen[key] = i
+	}
+
+	return used
+}
+
+/*
+This func reports the breaks booked on the suspense account and marks them
+and their postings as used. A posting books the external entry with its
+amount, or reverses the ledger entry, under the reference of the break.
+*/
+func booked(ledger []Entry, external []Entry, ledgerUsed []bool, externalUsed []bool, r *Report) {
+	for i, p := range ledger {
+		ref, ok := strings.CutPrefix(p.Reference, PostingPrefix)
+		if ledgerUsed[i] || !ok || ref == "" {
+			continue
+		}
+
+		for j, e := range external {
+			if !externalUsed[j] && e.Amount == p.Amount && breakReference(e) == ref {
+				ledgerUsed[i], externalUsed[j] = true, true
+				r.Booked = append(r.Booked, Booked{Side: SideExternal, Entry: e, Posting: p})
+
+				break
+			}
+		}
+
+		if ledgerUsed[i] {
+			continue
+		}
+
+		for j, l := range ledger {
+			if !ledgerUsed[j] && j != i && l.Amount == -p.Amount && breakReference(l) == ref {
+				ledgerUsed[i], ledgerUsed[j] = true, true
+				r.Booked = append(r.Booked, Booked{Side: SideLedger, Entry: l, Posting: p})
+
+				break
+			}
+		}
+	}
+}
+
+// breakReference is the reference the break of the entry is booked under.
+func breakReference(e Entry) string {
+	if e.Reference == "" {
+		return e.Id
+	}
+
+	return e.Reference
+}
+
+func matches(rule string, l Entry, e Entry, tol Tolerance) bool {
+	if amountDiff(l.Amount, e.Amount) > float32(math.Abs(float64(tol.Amount)))+0.001 || dayDiff(l.Date, e.Date) > tol.Days {
+		return false
+	}
+
+	switch rule {
+	case RuleReference:
+		return sharesReference(l, e)
+	case RuleAmount:
+		return l.Reference == "" || e.Reference == ""
+	}
+
+	return false
+}
+
+// sharesReference tells whether the reference or the id of one entry is the reference or the id of the other.
+func sharesReference(l Entry, e Entry) bool {
+	for _, a := range []string{l.Reference, l.Id} {
+		for _, b := range []string{e.Reference, e.Id} {
+			if a != "" && normalize(a) == normalize(b) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func normalize(ref string) string {
+	return strings.ToUpper(strings.TrimSpace(ref))
+}
+
+func amountDiff(a float32, b float32) float32 {
+	return float32(math.Abs(float64(a - b)))
+}
+
+func dayDiff(a time.Time, b time.Time) int {
+	days := int(math.Round(day(a).Sub(day(b)).Hours() / 24))
+	if days < 0 {
+		return -days
+	}
+
+	return days
+}
+
+// Poster books the breaks on the suspense account, *payment.PaymentSystem implements it.
+type Poster interface {
+	PostSuspense(accountNum string, amount float32, reference string) (payment.Transaction, error)
+}
+
+// Posting is a break booked on the suspense account.
+type Posting struct {
+	Side          string  `json:"side"`
+	Entry         Entry   `json:"entry"`
+	Amount        float32 `json:"amount"`
+	TransactionId string  `json:"transaction_id,omitempty"`
+	Error         string  `json:"error,omitempty"`
+}
+
+/*
+This func parks the unmatched entries on the suspense account, so the ledger
+of the account agrees with the external statement: an entry missing
+in the ledger is booked on the account, an entry missing in the external
+statement is reversed. The caller holds the lock of the payment system.
+A failed posting is reported and does not stop the others.
+*/
+func PostBreaks(p Poster, accountNum string, r Report) []Posting {
+	var res []Posting
+
+	post := func(side string, e Entry, amount float32) {
+		posting := Posting{Side: side, Entry: e, Amount: amount}
+
+		tx, err := p.PostSuspense(accountNum, amount, strings.TrimSpace(PostingPrefix+breakReference(e)))
+		if err != nil {
+			posting.Error = strings.TrimSpace(err.Error())
+		}
+
+		posting.TransactionId = tx.Id
+		res = append(res, posting)
+	}
+
+	for _, e := range r.UnmatchedExternal {
+		post(SideExternal, e, e.Amount)
+	}
+
+	for _, e := range r.UnmatchedLedger {
+		post(SideLedger, e, -e.Amount)
+	}
+
+	return res
+}
+
+// Write prints the report.
+func (r Report) Write(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "matched %d, unmatched ledger %d, unmatched external %d, duplicates %d, booked %d\n",
+		len(r.Matched), len(r.UnmatchedLedger), len(r.UnmatchedExternal), len(r.Duplicates), len(r.Booked))
+
+	for _, m := range r.Matched {
+		fmt.Fprintf(&b, "  %-11s %s  %10.2f  %-20s %-20s %s", "matched", m.Ledger.Date.Format(time.DateOnly), m.Ledger.Amount, m.Ledger.Id, m.External.Id, m.Rule)
+
+		if m.AmountDiff != 0 || m.DayDiff != 0 {
+			fmt.Fprintf(&b, "  diff %.2f, %d days", m.AmountDiff, m.DayDiff)
+		}
+
+		b.WriteString("\n")
+	}
+
+	for _, e := range r.UnmatchedLedger {
+		writeEntry(&b, "ledger only", e)
+	}
+
+	for _, e := range r.UnmatchedExternal {
+		writeEntry(&b, "bank only", e)
+	}
+
+	for _, d := range r.Duplicates {
+		writeEntry(&b, "duplicate", d.Entry)
+	}
+
+	for _, bk := range r.Booked {
+		writeEntry(&b, "booked", bk.Entry)
+	}
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+func writeEntry(b *strings.Builder, kind string, e Entry) {
+	fmt.Fprintf(b, "  %-11s %s  %10.2f  %-20s %s\n", kind, e.Date.Format(time.DateOnly), e.Amount, e.Id, e.Reference)
+}
@@ -0,0 +1,175 @@
+package recon_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/recon"
+	"github.com/soundrise/go-payment-system/payment/swift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	num1 = "BY11ABCD00000000000000000001"
+	num2 = "BY22ABCD00000000000000000002"
+)
+
+func date(day int) time.Time {
+	return time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC)
+}
+
+func TestReconcile(t *testing.T) {
+	ledger := []recon.Entry{
+		{Id: "TX1", Date: date(10), Amount: -100, Reference: "INV1"},
+		{Id: "TX2", Date: date(11), Amount: 50},
+		{Id: "TX3", Date: date(12), Amount: -20, Reference: "FEE"},
+		{Id: "TX4", Date: date(10), Amount: -100, Reference: "inv1"},
+	}
+
+	external := []recon.Entry{
+		{Id: "B1", Date: date(11), Amount: -100, Reference: "INV1"},
+		{Id: "B2", Date: date(11), Amount: 50.004},
+		{Id: "B3", Date: date(15), Amount: 70, Reference: "X"},
+		{Id: "B4", Date: date(15), Amount: 70, Reference: "X"},
+	}
+
+	r := recon.Reconcile(ledger, external, recon.Tolerance{Amount: 0.01, Days: 1})
+
+	require.Len(t, r.Matched, 2)
+	assert.Equal(t, "TX1", r.Matched[0].Ledger.Id)
+	assert.Equal(t, "B1", r.Matched[0].External.Id)
+	assert.Equal(t, recon.RuleReference, r.Matched[0].Rule)
+	assert.Equal(t, 1, r.Matched[0].DayDiff)
+	assert.Equal(t, "TX2", r.Matched[1].Ledger.Id)
+	assert.Equal(t, "B2", r.Matched[1].External.Id)
+	assert.Equal(t, recon.RuleAmount, r.Matched[1].Rule)
+
+	assert.Equal(t, []recon.Entry{ledger[2]}, r.UnmatchedLedger)
+	assert.Equal(t, []recon.Entry{external[2]}, r.UnmatchedExternal)
+	assert.Equal(t, []recon.Duplicate{
+		{Side: recon.SideLedger, Entry: ledger[3], Of: 0},
+		{Side: recon.SideExternal, Entry: external[3], Of: 2},
+	}, r.Duplicates)
+	assert.False(t, r.Reconciled())
+
+	// without the tolerance the entries one day or a fraction apart are breaks
+	strict := recon.Reconcile(ledger[:2], external[:2], recon.Tolerance{})
+	assert.Empty(t, strict.Matched)
+
+	var buf bytes.Buffer
+	require.NoError(t, r.Write(&buf))
+	assert.Contains(t, buf.String(), "matched 2, unmatched ledger 1, unmatched external 1, duplicates 2")
+	assert.Contains(t, buf.String(), "bank only")
+}
+
+func TestParse(t *testing.T) {
+	entries, err := recon.Parse(strings.NewReader("date,amount,reference,description,id\n2024-01-10,-100.5,INV1,rent,B1\n2024-01-11,50,,salary,B2\n"), "")
+	require.NoError(t, err)
+	assert.Equal(t, []recon.Entry{
+		{Id: "B1", Date: date(10), Amount: -100.5, Reference: "INV1", Description: "rent"},
+		{Id: "B2", Date: date(11), Amount: 50, Description: "salary"},
+	}, entries)
+
+	_, err = recon.Parse(strings.NewReader("date,amount\n"), recon.FormatCSV)
+	assert.Error(t, err)
+
+	_, err = recon.Parse(strings.NewReader("date,amount,reference,description\n10.01.2024,1,,\n"), recon.FormatCSV)
+	assert.ErrorContains(t, err, "invalid date")
+
+	_, err = recon.Parse(strings.NewReader("date,amount,reference,description\n2024-01-10,NaN,,\n"), recon.FormatCSV)
+	assert.ErrorContains(t, err, "invalid amount")
+
+	mt := swift.MT940{
+		Reference: "S1",
+		Account:   num1,
+		Opening:   swift.Balance{Mark: swift.Credit, Date: date(10), Currency: payment.BYN, Amount: 1000},
+		Closing:   swift.Balance{Mark: swift.Credit, Date: date(11), Currency: payment.BYN, Amount: 950},
+		Lines: []swift.StatementLine{
+			{ValueDate: date(10), Mark: swift.Debit, Amount: 100, TypeCode: "TRF", Reference: "INV1", BankReference: "B1"},
+			{ValueDate: date(11), Mark: swift.Credit, Amount: 50, TypeCode: "MSC", Reference: swift.NoReference},
+		},
+	}
+
+	entries, err = recon.Parse(strings.NewReader(mt.Message().String()), "")
+	require.NoError(t, err)
+	assert.Equal(t, []recon.Entry{
+		{Id: "B1", Date: date(10), Amount: -100, Reference: "INV1"},
+		{Date: date(11), Amount: 50},
+	}, entries)
+}
+
+func TestPostBreaks(t *testing.T) {
+	now := time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithClock(func() time.Time { return now }))
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "`+num1+`", "currency_code": "BYN", "status": "active", "balance": 1000}},
+		"2": {"K2": {"customer_id": "2", "num": "`+num2+`", "currency_code": "BYN", "status": "active", "balance": 0}}
+	}`)))
+
+	require.NoError(t, ps.TransferByNumber(num1, num2, 100))
+	require.NoError(t, ps.TransferByNumber(num1, num2, 20))
+
+	st, err := ps.Statement(payment.NewCustomer("1", "", ""), num1, time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	ledger := recon.FromStatement(st)
+	require.Len(t, ledger, 2)
+	assert.Equal(t, recon.Entry{Id: st.Lines[0].Id, Date: date(10), Amount: -100, Description: payment.TxTransfer}, ledger[0])
+
+	// the bank has the first transfer and an unknown credit, the second transfer is missing
+	external := []recon.Entry{
+		{Id: "B1", Date: date(10), Amount: -100, Reference: st.Lines[0].Id},
+		{Id: "B2", Date: date(10), Amount: 70, Reference: "UNKNOWN"},
+	}
+
+	r := recon.Reconcile(ledger, external, recon.Tolerance{})
+	require.Len(t, r.Matched, 1)
+
+	postings := recon.PostBreaks(ps, num1, r)
+	require.Len(t, postings, 2)
+	assert.Equal(t, float32(70), postings[0].Amount)
+	assert.Equal(t, float32(20), postings[1].Amount, "the transfer missing at the bank is reversed")
+
+	for _, p := range postings {
+		assert.Empty(t, p.Error)
+		assert.NotEmpty(t, p.TransactionId)
+	}
+
+	a, err := ps.GetAccountByNumber(payment.NewCustomer("1", "", ""), num1)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1000-100+70), a.Balance, "the ledger agrees with the bank")
+	assert.Equal(t, float32(-90), ps.SuspenseAccount(payment.BYN).Balance)
+
+	// reconciling the period again finds the breaks booked and books nothing
+	st, err = ps.Statement(payment.NewCustomer("1", "", ""), num1, time.Time{}, time.Time{})
+	require.NoError(t, err)
+
+	again := recon.Reconcile(recon.FromStatement(st), external, recon.Tolerance{})
+	assert.True(t, again.Reconciled())
+	require.Len(t, again.Booked, 2)
+	assert.Equal(t, recon.SideExternal, again.Booked[0].Side)
+	assert.Equal(t, "B2", again.Booked[0].Entry.Id)
+	assert.Equal(t, recon.SideLedger, again.Booked[1].Side)
+	assert.Equal(t, float32(-20), again.Booked[1].Entry.Amount)
+	assert.Empty(t, recon.PostBreaks(ps, num1, again))
+
+	failed := recon.PostBreaks(ps, num1, recon.Report{UnmatchedExternal: []recon.Entry{{Id: "B9", Amount: -1e6}}})
+	require.Len(t, failed, 1)
+	assert.Contains(t, failed[0].Error, "insufficient funds")
+}
+
+func TestPostBreaks_Blocked(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "`+num1+`", "currency_code": "BYN", "status": "blocked", "balance": 1000}}
+	}`)))
+
+	postings := recon.PostBreaks(ps, num1, recon.Report{UnmatchedExternal: []recon.Entry{{Id: "B1", Amount: 10}}})
+	require.Len(t, postings, 1)
+	assert.Contains(t, postings[0].Error, "not valid or blocked")
+	assert.Equal(t, float32(0), ps.SuspenseAccount(payment.BYN).Balance)
+}
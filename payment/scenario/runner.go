@@ -0,0 +1,252 @@
+package scenario
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+// BalanceTolerance is the allowed difference of an expected balance.
+const BalanceTolerance = 0.005
+
+var errUnexpectedSuccess = errors.New("step succeeded, but an error was expected")
+
+// Result is the outcome of one step.
+type Result struct {
+	Step     int           `json:"step"`
+	Name     string        `json:"name"`
+	Passed   bool          `json:"passed"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// Report holds the results of all steps of the scenario.
+type Report struct {
+	Scenario string   `json:"scenario"`
+	Results  []Result `json:"results"`
+}
+
+// Passed reports whether every step passed.
+func (r Report) Passed() bool {
+	for _, res := range r.Results {
+		if !res.Passed {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WriteText writes one PASS or FAIL line per step and a summary.
+func (r Report) WriteText(w io.Writer) error {
+	failed := 0
+
+	fmt.Fprintf(w, "Scenario: %s\n", r.Scenario)
+
+	for _, res := range r.Results {
+		mark := "PASS"
+		if !res.Passed {
+			mark = "FAIL"
+			failed++
+		}
+
+		fmt.Fprintf(w, "%s %3d %s (%s)\n", mark, res.Step, res.Name, res.Duration.Round(time.Millisecond))
+
+		if !res.Passed {
+			fmt.Fprintf(w, "         %s\n", res.Error)
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "%d passed, %d failed\n", len(r.Results)-failed, failed)
+
+	return err
+}
+
+// Runner executes scenarios against the store through a PaymentController.
+type Runner struct {
+	ps payment.Store
+	pc payment.PaymentController
+}
+
+func NewRunner(ps payment.Store, opts ...payment.ControllerOption) *Runner {
+	r := &Runner{
+		ps: ps,
+		pc: payment.NewPaymentController(ps, opts...),
+	}
+
+	return r
+}
+
+/*
+This func adds the steps to the controller in batches that fit its queue,
+runs every batch and collects the result of each step.
+*/
+func (r *Runner) Run(sc *Scenario) Report {
+	report := Report{
+		Scenario: sc.Name,
+		Results:  make([]Result, len(sc.Steps)),
+	}
+
+	for from := 0; from < len(sc.Steps); from += payment.ControllerQueueSize {
+		to := min(from+payment.ControllerQueueSize, len(sc.Steps))
+
+		for i := from; i < to; i++ {
+			i := i
+
+			r.pc.Add(func() error {
+				report.Results[i] = r.runStep(i, sc.Steps[i])
+
+				if !report.Results[i].Passed {
+					return errors.New(report.Results[i].Error)
+				}
+
+				return nil
+			})
+		}
+
+		done := make(chan bool)
+
+		r.pc.Run(done)
+		<-done
+	}
+
+	return report
+}
+
+func (r *Runner) runStep(i int, st Step) Result {
+	start := time.Now()
+	err := r.execute(st)
+
+	if st.ExpectError != "" {
+		switch {
+		case err == nil:
+			err = errUnexpectedSuccess
+		case strings.Contains(err.Error(), st.ExpectError):
+			err = nil
+		default:
+			err = fmt.Errorf("error %q does not contain %q", strings.TrimSpace(err.Error()), st.ExpectError)
+		}
+	}
+
+	res := Result{
+		Step:     i + 1,
+		Name:     st.title(),
+		Passed:   err == nil,
+		Duration: time.Since(start),
+	}
+
+	if err != nil {
+		res.Error = strings.TrimSpace(err.Error())
+	}
+
+	return res
+}
+
+func (r *Runner) execute(st Step) error {
+	switch {
+	case st.CreateAccount != nil:
+		ca := st.CreateAccount
+
+		accType := ca.Type
+		if accType == "" {
+			accType = payment.AccountPrefix
+		}
+
+		return r.ps.CreateAccount(payment.NewCustomer(ca.Customer, ca.Name, accType), accType, ca.Currency, ca.Amount)
+	case st.CloseAccount != nil:
+		a, err := r.account(*st.CloseAccount)
+		if err != nil {
+			return err
+		}
+
+		return r.ps.CloseAccount(a)
+	case st.Emit != nil:
+		return r.ps.EmitIn(currency(st.Emit.Currency), st.Emit.Amount)
+	case st.Terminate != nil:
+		a, err := r.account(st.Terminate.Account)
+		if err != nil {
+			return err
+		}
+
+		return r.ps.Terminate(a, st.Terminate.Amount)
+	case st.Transfer != nil:
+		return r.transfer(*st.Transfer)
+	case st.ExpectBalance != nil:
+		return r.expectBalance(*st.ExpectBalance)
+	case st.PrintStore:
+		return r.ps.PrintStoreJson()
+	}
+
+	return fmt.Errorf("step has no action")
+}
+
+func (r *Runner) transfer(t Transfer) error {
+	s, err := r.account(t.From)
+	if err != nil {
+		return err
+	}
+
+	d, err := r.account(t.To)
+	if err != nil {
+		return err
+	}
+
+	if !t.JSON {
+		return r.ps.Transfer(s, d, t.Amount)
+	}
+
+	data, err := json.Marshal(payment.NewTransferData(s, d, t.Amount))
+	if err != nil {
+		return err
+	}
+
+	return r.ps.TransferJson(data)
+}
+
+func (r *Runner) expectBalance(eb ExpectBalance) error {
+	a, err := r.account(eb.Account)
+	if err != nil {
+		return err
+	}
+
+	if math.Abs(float64(a.Balance-eb.Balance)) > BalanceTolerance {
+		return fmt.Errorf("balance of %s is %.2f, expected %.2f", a.Num, a.Balance, eb.Balance)
+	}
+
+	return nil
+}
+
+// account resolves the reference to the current state of the account.
+func (r *Runner) account(ref AccountRef) (payment.Account, error) {
+	switch {
+	case ref.Special != "":
+		return r.ps.GetSpecialAccountIn(ref.Special, currency(ref.Currency))
+	case ref.Number != "":
+		for _, a := range r.ps.Accounts(ref.Customer) {
+			if a.Num == ref.Number {
+				return a, nil
+			}
+		}
+
+		return payment.Account{CustomerId: ref.Customer, Num: ref.Number}, nil
+	case ref.Customer != "" && ref.Currency != "":
+		return r.ps.FindAccount(payment.NewCustomer(ref.Customer, "", ""), ref.Currency)
+	}
+
+	return payment.Account{}, fmt.Errorf("account reference needs special, number or customer and currency")
+}
+
+// currency returns the currency of the step, the default currency when it is empty.
+func currency(code string) string {
+	if code == "" {
+		return payment.DefaultCurrency
+	}
+
+	return code
+}
@@ -0,0 +1,129 @@
+package scenario_test
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/scenario"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const flow = `
+name: transfer flow
+steps:
+  - create_account: {customer: "1", currency: BYN, amount: 100}
+  - create_account: {customer: "2", currency: BYN}
+  - name: move money
+    transfer: {from: {customer: "1", currency: BYN}, to: {customer: "2", currency: BYN}, amount: 40}
+  - expect_balance: {account: {customer: "2", currency: BYN}, balance: 40}
+  - name: wrong expectation
+    expect_balance: {account: {customer: "1", currency: BYN}, balance: 100}
+  - name: overdraft is rejected
+    transfer: {from: {customer: "1", currency: BYN}, to: {customer: "2", currency: BYN}, amount: 500}
+    expect_error: insufficient funds
+  - name: other error happens
+    emit: {amount: 1}
+    expect_error: insufficient funds
+  - name: expected error does not happen
+    expect_balance: {account: {customer: "2", currency: BYN}, balance: 40}
+    expect_error: insufficient funds
+`
+
+func newRunner() *scenario.Runner {
+	discard := slog.New(slog.NewTextHandler(io.Discard, nil))
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithLogger(discard))
+
+	return scenario.NewRunner(ps, payment.WithControllerLogger(discard))
+}
+
+func TestRunner_Run(t *testing.T) {
+	sc, err := scenario.Parse([]byte(flow))
+	require.NoError(t, err)
+
+	report := newRunner().Run(sc)
+	require.Len(t, report.Results, 8)
+
+	passed := make([]bool, 0, len(report.Results))
+	for _, res := range report.Results {
+		passed = append(passed, res.Passed)
+	}
+
+	assert.Equal(t, []bool{true, true, true, true, false, true, false, false}, passed)
+	assert.False(t, report.Passed())
+	assert.Equal(t, "move money", report.Results[2].Name)
+	assert.Equal(t, "create account", report.Results[0].Name)
+	assert.Contains(t, report.Results[4].Error, "is 60.00, expected 100.00")
+	assert.Contains(t, report.Results[6].Error, "special account not found SE\" does not contain \"insufficient funds\"")
+	assert.Equal(t, "step succeeded, but an error was expected", report.Results[7].Error)
+
+	var out bytes.Buffer
+
+	require.NoError(t, report.WriteText(&out))
+	assert.Contains(t, out.String(), "FAIL   5 wrong expectation")
+	assert.Contains(t, out.String(), "5 passed, 3 failed")
+}
+
+func TestRunner_RunManySteps(t *testing.T) {
+	sc := &scenario.Scenario{Name: "many steps"}
+
+	sc.Steps = append(sc.Steps,
+		scenario.Step{CreateAccount: &scenario.CreateAccount{Customer: "0", Type: payment.AccountStateEmissionPrefix, Currency: payment.BYN}})
+
+	for i := 0; i < 2*payment.ControllerQueueSize; i++ {
+		sc.Steps = append(sc.Steps, scenario.Step{Emit: &scenario.Emit{Amount: 1}})
+	}
+
+	sc.Steps = append(sc.Steps, scenario.Step{ExpectBalance: &scenario.ExpectBalance{
+		Account: scenario.AccountRef{Special: payment.AccountStateEmissionPrefix},
+		Balance: 2 * payment.ControllerQueueSize,
+	}})
+
+	report := newRunner().Run(sc)
+	assert.True(t, report.Passed())
+	assert.Len(t, report.Results, len(sc.Steps))
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		desc string
+		data string
+		err  string
+	}{
+		{
+			desc: "json",
+			data: `{"name": "json", "steps": [{"emit": {"amount": 1}}]}`,
+		},
+		{
+			desc: "no steps",
+			data: `name: empty`,
+			err:  "scenario has no steps",
+		},
+		{
+			desc: "two actions",
+			data: "steps:\n  - name: both\n    emit: {amount: 1}\n    print_store: true\n",
+			err:  "step 1 (both) must have exactly one action, got 2",
+		},
+		{
+			desc: "unknown field",
+			data: "steps:\n  - withdraw: {amount: 1}\n",
+			err:  "field withdraw not found",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := scenario.Parse([]byte(tt.data))
+			if tt.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tt.err)
+			}
+		})
+	}
+}
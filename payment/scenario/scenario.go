@@ -0,0 +1,164 @@
+// Package scenario runs flows of payment operations described in YAML or JSON files.
+package scenario
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a named list of steps executed in order.
+type Scenario struct {
+	Name  string `yaml:"name"`
+	Steps []Step `yaml:"steps"`
+}
+
+/*
+This struct is one step of the scenario: exactly one action
+and optionally the error the action is expected to fail with.
+*/
+type Step struct {
+	Name string `yaml:"name"`
+
+	CreateAccount *CreateAccount `yaml:"create_account"`
+	CloseAccount  *AccountRef    `yaml:"close_account"`
+	Emit          *Emit          `yaml:"emit"`
+	Terminate     *Terminate     `yaml:"terminate"`
+	Transfer      *Transfer      `yaml:"transfer"`
+	ExpectBalance *ExpectBalance `yaml:"expect_balance"`
+	PrintStore    bool           `yaml:"print_store"`
+
+	// ExpectError is a part of the error message the action must fail with.
+	ExpectError string `yaml:"expect_error"`
+}
+
+type CreateAccount struct {
+	Customer string  `yaml:"customer"`
+	Name     string  `yaml:"name"`
+	Type     string  `yaml:"type"`
+	Currency string  `yaml:"currency"`
+	Amount   float32 `yaml:"amount"`
+}
+
+/*
+This struct selects an account: the special account (SE or ST)
+of the currency (the default one when empty),
+the account of the customer in the currency or the account by number.
+An account number unknown to the store is passed to it as is,
+so a scenario can check how invalid accounts are rejected.
+*/
+type AccountRef struct {
+	Special  string `yaml:"special"`
+	Customer string `yaml:"customer"`
+	Currency string `yaml:"currency"`
+	Number   string `yaml:"number"`
+}
+
+type Emit struct {
+	Amount float32 `yaml:"amount"`
+	// Currency of the emission, the default currency when empty.
+	Currency string `yaml:"currency"`
+}
+
+type Terminate struct {
+	Account AccountRef `yaml:"account"`
+	Amount  float32    `yaml:"amount"`
+}
+
+type Transfer struct {
+	From   AccountRef `yaml:"from"`
+	To     AccountRef `yaml:"to"`
+	Amount float32    `yaml:"amount"`
+	// JSON sends the transfer through TransferJson.
+	JSON bool `yaml:"json"`
+}
+
+type ExpectBalance struct {
+	Account AccountRef `yaml:"account"`
+	Balance float32    `yaml:"balance"`
+}
+
+// Load reads the scenario file.
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sc, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("scenario %s: %w", path, err)
+	}
+
+	return sc, nil
+}
+
+// Parse decodes the scenario from YAML or JSON and validates its steps.
+func Parse(data []byte) (*Scenario, error) {
+	var sc Scenario
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&sc); err != nil {
+		return nil, err
+	}
+
+	if len(sc.Steps) == 0 {
+		return nil, fmt.Errorf("scenario has no steps")
+	}
+
+	for i, st := range sc.Steps {
+		if n := st.actions(); n != 1 {
+			return nil, fmt.Errorf("step %d (%s) must have exactly one action, got %d", i+1, st.title(), n)
+		}
+	}
+
+	return &sc, nil
+}
+
+func (st Step) actions() int {
+	n := 0
+
+	for _, set := range []bool{
+		st.CreateAccount != nil,
+		st.CloseAccount != nil,
+		st.Emit != nil,
+		st.Terminate != nil,
+		st.Transfer != nil,
+		st.ExpectBalance != nil,
+		st.PrintStore,
+	} {
+		if set {
+			n++
+		}
+	}
+
+	return n
+}
+
+// title is the name of the step or the name of its action.
+func (st Step) title() string {
+	switch {
+	case st.Name != "":
+		return st.Name
+	case st.CreateAccount != nil:
+		return "create account"
+	case st.CloseAccount != nil:
+		return "close account"
+	case st.Emit != nil:
+		return "emit"
+	case st.Terminate != nil:
+		return "terminate"
+	case st.Transfer != nil:
+		return "transfer"
+	case st.ExpectBalance != nil:
+		return "expect balance"
+	case st.PrintStore:
+		return "print store"
+	}
+
+	return "unnamed"
+}
@@ -0,0 +1,433 @@
+package payment
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+	"time"
+)
+
+const (
+	DecisionAllow  = "allow"
+	DecisionReview = "review"
+	DecisionDeny   = "deny"
+)
+
+// ScreeningRequest describes a transfer to be checked before it is executed.
+type ScreeningRequest struct {
+	S       Account
+	D       Account
+	Amount  float32
+	Time    time.Time
+	History []Transaction // earlier outgoing transactions of the source account
+}
+
+// ScreeningResult is the decision of a screener and the rule that produced it.
+type ScreeningResult struct {
+	Decision string `json:"decision"`
+	Rule     string `json:"rule"`
+	Reason   string `json:"reason"`
+}
+
+// Screener checks a transfer for fraud risk before money moves.
+type Screener interface {
+	Screen(req ScreeningRequest) ScreeningResult
+}
+
+// WithScreener sets the screener called for every transfer.
+func WithScreener(s Screener) Option {
+	return func(ps *PaymentSystem) {
+		ps.screener = s
+	}
+}
+
+// WithReviewers sets the names allowed to resolve transfers flagged by the screener.
+func WithReviewers(names ...string) Option {
+	return func(ps *PaymentSystem) {
+		for _, n := range names {
+			ps.reviewers[n] = true
+		}
+	}
+}
+
+func (ps *PaymentSystem) screen(s Account, d Account, amount float32) ScreeningResult {
+	req := ScreeningRequest{
+		S:      s,
+		D:      d,
+		Amount: amount,
+		Time:   ps.now(),
+	}
+
+	if _, acc, ok := ps.lookup(s.CustomerId, s.Num); ok {
+		req.S = acc
+	}
+
+	if _, acc, ok := ps.lookup(d.CustomerId, d.Num); ok {
+		req.D = acc
+	}
+
+	for _, tx := range ps.journal {
+		if tx.Source == s.Num && tx.Type == TxTransfer {
+			req.History = append(req.History, tx)
+		}
+	}
+
+	res := ps.screener.Screen(req)
+
+	if res.Decision != DecisionAllow && res.Decision != "" {
+		ps.logger.Warn("transfer flagged by screening", "op", OpTransfer, "account", s.Num, "destination", d.Num, "amount", amount,
+			"decision", res.Decision, "rule", res.Rule, "reason", res.Reason)
+	}
+
+	return res
+}
+
+// ReviewQueue returns the transfers flagged for review ordered by id.
+func (ps *PaymentSystem) ReviewQueue() []PendingTransfer {
+	return ps.transfersWithStatus(TransferInReview)
+}
+
+func (ps *PaymentSystem) checkReviewer(id string, reviewer string) (*PendingTransfer, error) {
+	ps.ExpirePendingTransfers()
+
+	pt, ok := ps.pending[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrTransferNotFound, id)
+	}
+
+	if pt.Status != TransferInReview {
+		return nil, fmt.Errorf("%w: %s is %s", ErrTransferNotInReview, id, pt.Status)
+	}
+
+	if !ps.reviewers[reviewer] {
+		return nil, fmt.Errorf("%w: %s", ErrReviewerNotAllowed, reviewer)
+	}
+
+	if pt.Maker != "" && pt.Maker == reviewer {
+		return nil, fmt.Errorf("%w: %s", ErrApproverIsInitiator, reviewer)
+	}
+
+	return pt, nil
+}
+
+/*
+This func clears the flagged transfer. It is executed at once, within
+the limits left after the transfers executed since it was flagged,
+or goes on to wait for approval when it exceeds the approval threshold.
+*/
+func (ps *PaymentSystem) ClearReview(id string, reviewer string) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpClearReview, reviewer, start, auditInput{"tx_id": id}, "", err)
+	}()
+
+	pt, err := ps.checkReviewer(id, reviewer)
+	if err != nil {
+		return err
+	}
+
+	if ps.requiresApproval(pt.Amount) {
+		pt.Status = TransferPending
+		pt.ExpiresAt = ps.now().Add(ps.pendingTimeout)
+
+		ps.logger.Info("transfer is waiting for approval", "op", OpClearReview, "tx_id", id)
+
+		return nil
+	}
+
+	if err := ps.checkLimits(pt.S, pt.Amount); err != nil {
+		return err
+	}
+
+	d, ok := ps.destination(pt.D)
+	if !ok {
+		return fmt.Errorf("Clearing is imposible: account %s is not valid or blocked", pt.D.Num)
+	}
+
+	if err := ps.reserve(pt.S, -pt.Amount); err != nil {
+		return err
+	}
+
+	if err := ps.execute(pt, d); err != nil {
+		_ = ps.reserve(pt.S, pt.Amount)
+
+		return err
+	}
+
+	pt.Status = TransferApproved
+	pt.Checker = reviewer
+
+	return nil
+}
+
+/*
+This func declines the flagged transfer and releases the reserved funds.
+*/
+func (ps *PaymentSystem) DeclineReview(id string, reviewer string) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpDeclineReview, reviewer, start, auditInput{"tx_id": id}, "", err)
+	}()
+
+	pt, err := ps.checkReviewer(id, reviewer)
+	if err != nil {
+		return err
+	}
+
+	if err := ps.reserve(pt.S, -pt.Amount); err != nil {
+		return err
+	}
+
+	pt.Status = TransferRejected
+	pt.Checker = reviewer
+
+	ps.publishFailed(pt, "declined by "+reviewer)
+
+	return nil
+}
+
+// Duration is a time.Duration written as a string ("72h") in rules files.
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	d.Duration = v
+
+	return nil
+}
+
+// VelocityRule flags bursts of outgoing transfers within the window.
+type VelocityRule struct {
+	Window    Duration `json:"window"`
+	MaxCount  int      `json:"max_count"`
+	MaxAmount float32  `json:"max_amount"`
+	Decision  string   `json:"decision"`
+}
+
+// NewAccountRule flags large transfers from or to recently created accounts.
+type NewAccountRule struct {
+	Age       Duration `json:"age"`
+	MaxAmount float32  `json:"max_amount"`
+	Decision  string   `json:"decision"`
+}
+
+// RoundAmountRule flags large amounts that are multiples of a round number.
+type RoundAmountRule struct {
+	Multiple  float32 `json:"multiple"`
+	MinAmount float32 `json:"min_amount"`
+	Decision  string  `json:"decision"`
+}
+
+// BlacklistRule flags transfers from or to the listed accounts.
+type BlacklistRule struct {
+	Accounts []string `json:"accounts"`
+	Decision string   `json:"decision"`
+}
+
+// Rules is the content of a rules file. Missing rules are not checked.
+type Rules struct {
+	Velocity    *VelocityRule    `json:"velocity"`
+	NewAccount  *NewAccountRule  `json:"new_account"`
+	RoundAmount *RoundAmountRule `json:"round_amount"`
+	Blacklist   *BlacklistRule   `json:"blacklist"`
+}
+
+// RuleScreener is the built-in Screener driven by Rules.
+type RuleScreener struct {
+	rules Rules
+}
+
+/*
+This func returns a screener for the rules.
+A rule with a decision other than allow, review or deny is an error,
+an empty decision means review.
+*/
+func NewRuleScreener(r Rules) (*RuleScreener, error) {
+	decisions := map[string]string{}
+
+	if r.Velocity != nil {
+		decisions["velocity"] = r.Velocity.Decision
+	}
+
+	if r.NewAccount != nil {
+		decisions["new_account"] = r.NewAccount.Decision
+	}
+
+	if r.RoundAmount != nil {
+		decisions["round_amount"] = r.RoundAmount.Decision
+	}
+
+	if r.Blacklist != nil {
+		decisions["blacklist"] = r.Blacklist.Decision
+	}
+
+	for _, rule := range sortedKeys(decisions) {
+		switch decisions[rule] {
+		case "", DecisionAllow, DecisionReview, DecisionDeny:
+		default:
+			return nil, fmt.Errorf("rule %s: unknown decision %q", rule, decisions[rule])
+		}
+	}
+
+	rs := &RuleScreener{
+		rules: r,
+	}
+
+	return rs, nil
+}
+
+/*
+This func reads the rules file in JSON format and returns a screener for it.
+*/
+func LoadRules(path string) (*RuleScreener, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var r Rules
+
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("cannot parse rules file %s: %w", path, err)
+	}
+
+	rs, err := NewRuleScreener(r)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+
+	return rs, nil
+}
+
+// Screen applies every configured rule and returns the most severe decision.
+func (rs *RuleScreener) Screen(req ScreeningRequest) ScreeningResult {
+	res := ScreeningResult{Decision: DecisionAllow}
+
+	for _, r := range []ScreeningResult{
+		rs.velocity(req),
+		rs.newAccount(req),
+		rs.roundAmount(req),
+		rs.blacklist(req),
+	} {
+		if severity(r.Decision) > severity(res.Decision) {
+			res = r
+		}
+	}
+
+	return res
+}
+
+func (rs *RuleScreener) velocity(req ScreeningRequest) ScreeningResult {
+	r := rs.rules.Velocity
+	if r == nil {
+		return ScreeningResult{}
+	}
+
+	count := 1
+	sum := req.Amount
+
+	for _, tx := range req.History {
+		if req.Time.Sub(tx.Time) <= r.Window.Duration {
+			count++
+			sum += tx.Amount
+		}
+	}
+
+	if r.MaxCount > 0 && count > r.MaxCount {
+		return flag(r.Decision, "velocity", fmt.Sprintf("%d transfers within %s", count, r.Window))
+	}
+
+	if r.MaxAmount > 0 && sum > r.MaxAmount {
+		return flag(r.Decision, "velocity", fmt.Sprintf("%.2f transferred within %s", sum, r.Window))
+	}
+
+	return ScreeningResult{}
+}
+
+func (rs *RuleScreener) newAccount(req ScreeningRequest) ScreeningResult {
+	r := rs.rules.NewAccount
+	if r == nil || req.Amount <= r.MaxAmount {
+		return ScreeningResult{}
+	}
+
+	for _, a := range []Account{req.S, req.D} {
+		if !a.CreatedAt.IsZero() && req.Time.Sub(a.CreatedAt) < r.Age.Duration {
+			return flag(r.Decision, "new_account", fmt.Sprintf("account %s is younger than %s", a.Num, r.Age))
+		}
+	}
+
+	return ScreeningResult{}
+}
+
+func (rs *RuleScreener) roundAmount(req ScreeningRequest) ScreeningResult {
+	r := rs.rules.RoundAmount
+	if r == nil || r.Multiple <= 0 || req.Amount < r.MinAmount {
+		return ScreeningResult{}
+	}
+
+	if math.Mod(float64(req.Amount), float64(r.Multiple)) == 0 {
+		return flag(r.Decision, "round_amount", fmt.Sprintf("amount %.2f is a multiple of %.2f", req.Amount, r.Multiple))
+	}
+
+	return ScreeningResult{}
+}
+
+func (rs *RuleScreener) blacklist(req ScreeningRequest) ScreeningResult {
+	r := rs.rules.Blacklist
+	if r == nil {
+		return ScreeningResult{}
+	}
+
+	decision := r.Decision
+	if decision == "" {
+		decision = DecisionDeny
+	}
+
+	for _, num := range []string{req.S.Num, req.D.Num} {
+		if slices.Contains(r.Accounts, num) {
+			return flag(decision, "blacklist", fmt.Sprintf("account %s is blacklisted", num))
+		}
+	}
+
+	return ScreeningResult{}
+}
+
+// flag returns a result with the decision of the rule, review by default.
+func flag(decision string, rule string, reason string) ScreeningResult {
+	if decision == "" {
+		decision = DecisionReview
+	}
+
+	return ScreeningResult{Decision: decision, Rule: rule, Reason: reason}
+}
+
+func severity(decision string) int {
+	switch decision {
+	case DecisionDeny:
+		return 2
+	case DecisionReview:
+		return 1
+	}
+
+	return 0
+}
@@ -0,0 +1,220 @@
+package payment_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func ruleScreener(t *testing.T, r payment.Rules) *payment.RuleScreener {
+	t.Helper()
+
+	rs, err := payment.NewRuleScreener(r)
+	require.NoError(t, err)
+
+	return rs
+}
+
+func TestNewRuleScreener_Decisions(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		decision string
+		valid    bool
+	}{
+		{desc: "empty means review", decision: "", valid: true},
+		{desc: "allow", decision: payment.DecisionAllow, valid: true},
+		{desc: "review", decision: payment.DecisionReview, valid: true},
+		{desc: "deny", decision: payment.DecisionDeny, valid: true},
+		{desc: "misspelled", decision: "denny"},
+		{desc: "capitalized", decision: "Review"},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := payment.NewRuleScreener(payment.Rules{
+				Blacklist: &payment.BlacklistRule{Accounts: []string{destNum}, Decision: tt.decision},
+			})
+
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, "unknown decision")
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "rules.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"round_amount": {"multiple": 100, "decision": "denny"}}`), 0o600))
+
+	_, err := payment.LoadRules(path)
+	assert.ErrorContains(t, err, `rule round_amount: unknown decision "denny"`)
+}
+
+func TestRuleScreener_Screen(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	rs, err := payment.LoadRules("testdata/rules.json")
+	require.NoError(t, err)
+
+	old := payment.Account{Num: sourceNum, CreatedAt: now.Add(-30 * 24 * time.Hour)}
+	dest := payment.Account{Num: destNum}
+
+	testCases := []struct {
+		desc         string
+		req          payment.ScreeningRequest
+		expectedRule string
+		expected     string
+	}{
+		{
+			desc:     "ordinary transfer is allowed",
+			req:      payment.ScreeningRequest{S: old, D: dest, Amount: 120, Time: now},
+			expected: payment.DecisionAllow,
+		},
+		{
+			desc: "burst of transfers is reviewed",
+			req: payment.ScreeningRequest{S: old, D: dest, Amount: 10, Time: now, History: []payment.Transaction{
+				{Amount: 10, Time: now.Add(-time.Minute)},
+				{Amount: 10, Time: now.Add(-2 * time.Minute)},
+				{Amount: 10, Time: now.Add(-3 * time.Minute)},
+			}},
+			expectedRule: "velocity",
+			expected:     payment.DecisionReview,
+		},
+		{
+			desc:         "large transfer from new account is reviewed",
+			req:          payment.ScreeningRequest{S: payment.Account{Num: sourceNum, CreatedAt: now.Add(-time.Hour)}, D: dest, Amount: 700, Time: now},
+			expectedRule: "new_account",
+			expected:     payment.DecisionReview,
+		},
+		{
+			desc:         "round amount is reviewed",
+			req:          payment.ScreeningRequest{S: old, D: dest, Amount: 6000, Time: now},
+			expectedRule: "round_amount",
+			expected:     payment.DecisionReview,
+		},
+		{
+			desc:         "blacklisted account is denied over other rules",
+			req:          payment.ScreeningRequest{S: old, D: payment.Account{Num: "BY99ZZZZ00000000000000000099"}, Amount: 6000, Time: now},
+			expectedRule: "blacklist",
+			expected:     payment.DecisionDeny,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			res := rs.Screen(tt.req)
+
+			assert.Equal(t, tt.expected, res.Decision)
+			assert.Equal(t, tt.expectedRule, res.Rule)
+		})
+	}
+}
+
+func TestPaymentSystem_ReviewQueue(t *testing.T) {
+	rs := ruleScreener(t, payment.Rules{
+		RoundAmount: &payment.RoundAmountRule{Multiple: 100, MinAmount: 100},
+		Blacklist:   &payment.BlacklistRule{Accounts: []string{destNum}, Decision: payment.DecisionDeny},
+	})
+
+	ps := payment.NewPaymentSystem(payment.WithScreener(rs), payment.WithReviewers("analyst"))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	assert.ErrorIs(t, ps.Transfer(s, d, 50), payment.ErrTransferDenied)
+
+	ps = payment.NewPaymentSystem(
+		payment.WithScreener(ruleScreener(t, payment.Rules{
+			RoundAmount: &payment.RoundAmountRule{Multiple: 100, MinAmount: 100},
+		})),
+		payment.WithReviewers("analyst"),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	id, err := ps.SubmitTransfer("maker", s, d, 300)
+	require.NoError(t, err)
+
+	queue := ps.ReviewQueue()
+	require.Len(t, queue, 1)
+	assert.Equal(t, id, queue[0].Id)
+	assert.Contains(t, queue[0].Reason, "round_amount")
+
+	assert.ErrorIs(t, ps.ClearReview(id, "maker"), payment.ErrReviewerNotAllowed)
+	require.NoError(t, ps.ClearReview(id, "analyst"))
+	assert.Empty(t, ps.ReviewQueue())
+
+	d, _ = ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	assert.Equal(t, float32(300), d.Balance)
+}
+
+func TestPaymentSystem_ClearReview_Checks(t *testing.T) {
+	ps := payment.NewPaymentSystem(
+		payment.WithScreener(ruleScreener(t, payment.Rules{
+			RoundAmount: &payment.RoundAmountRule{Multiple: 100, MinAmount: 100},
+		})),
+		payment.WithReviewers("analyst", "maker"),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+	ps.SetAccountLimit(sourceNum, payment.Limit{DailyAmount: 700})
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	id, err := ps.SubmitTransfer("maker", s, d, 600)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, ps.ClearReview(id, "maker"), payment.ErrApproverIsInitiator)
+
+	// the flagged amount is not used yet, the limit allows the next transfer
+	_, err = ps.SubmitTransfer("maker", s, d, 150)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, ps.ClearReview(id, "analyst"), payment.ErrLimitExceeded)
+	assert.Len(t, ps.ReviewQueue(), 1)
+
+	d, _ = ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	assert.Equal(t, float32(150), d.Balance)
+}
+
+func TestPaymentSystem_ReviewExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	ps := payment.NewPaymentSystem(
+		payment.WithScreener(ruleScreener(t, payment.Rules{
+			RoundAmount: &payment.RoundAmountRule{Multiple: 100, MinAmount: 100},
+		})),
+		payment.WithReviewers("analyst"),
+		payment.WithPendingTimeout(time.Hour),
+		payment.WithClock(func() time.Time { return now }),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+
+	id, err := ps.SubmitTransfer("maker", s, d, 300)
+	require.NoError(t, err)
+	require.Len(t, ps.ReviewQueue(), 1)
+
+	now = now.Add(2 * time.Hour)
+
+	assert.Empty(t, ps.ReviewQueue())
+	assert.ErrorIs(t, ps.ClearReview(id, "analyst"), payment.ErrTransferNotInReview)
+
+	pt, err := ps.GetPendingTransfer(id)
+	require.NoError(t, err)
+	assert.Equal(t, payment.TransferExpired, pt.Status)
+
+	s, err = ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1000), s.Balance)
+	assert.Zero(t, s.Reserved, "the funds of the expired review hold are released")
+}
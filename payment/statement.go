@@ -0,0 +1,96 @@
+package payment
+
+import (
+	"fmt"
+	"time"
+)
+
+// StatementLine is a journal entry of the account with the balance after it.
+type StatementLine struct {
+	Transaction
+	Debit   float32 `json:"debit"`
+	Credit  float32 `json:"credit"`
+	Balance float32 `json:"balance"`
+}
+
+// Statement lists the movements of the account in the period [From, To).
+type Statement struct {
+	Account Account         `json:"account"`
+	From    time.Time       `json:"from"`
+	To      time.Time       `json:"to"`
+	Opening float32         `json:"opening_balance"`
+	Closing float32         `json:"closing_balance"`
+	Lines   []StatementLine `json:"lines"`
+}
+
+/*
+This func builds the statement of the account for the period.
+Zero from or to leave the period open on that side.
+Balances are derived backwards from the current balance of the account.
+*/
+func (ps *PaymentSystem) Statement(c Customer, accountNum string, from time.Time, to time.Time) (Statement, error) {
+	_, a, ok := ps.lookup(c.Id, accountNum)
+	if !ok {
+		return Statement{}, fmt.Errorf("%w: %s", ErrAccountNotFound, accountNum)
+	}
+
+	st := Statement{
+		Account: a,
+		From:    from,
+		To:      to,
+	}
+
+	balance := a.Balance
+
+	txs := ps.Transactions(accountNum)
+
+	// roll back movements after the end of the period
+	for i := len(txs) - 1; i >= 0 && !to.IsZero() && !txs[i].Time.Before(to); i-- {
+		balance -= movement(txs[i], accountNum)
+	}
+
+	st.Closing = balance
+
+	var lines []StatementLine
+
+	for i := len(txs) - 1; i >= 0; i-- {
+		tx := txs[i]
+		if !to.IsZero() && !tx.Time.Before(to) {
+			continue
+		}
+
+		if !from.IsZero() && tx.Time.Before(from) {
+			break
+		}
+
+		line := StatementLine{Transaction: tx, Balance: balance}
+
+		if m := movement(tx, accountNum); m < 0 {
+			line.Debit = -m
+		} else {
+			line.Credit = m
+		}
+
+		balance -= movement(tx, accountNum)
+		lines = append(lines, line)
+	}
+
+	st.Opening = balance
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+
+	st.Lines = lines
+
+	return st, nil
+}
+
+// movement is the signed change of the account balance made by the transaction.
+func movement(tx Transaction, accountNum string) float32 {
+	if tx.Dest == accountNum {
+		return tx.Amount
+	}
+
+	return -tx.Amount
+}
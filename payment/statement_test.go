@@ -0,0 +1,145 @@
+package payment_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_Statement(t *testing.T) {
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	ps := payment.NewPaymentSystem(payment.WithClock(func() time.Time { return now }))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	c1 := payment.NewCustomer("1", "", "")
+	c2 := payment.NewCustomer("2", "", "")
+
+	s, _ := ps.FindAccount(c1, payment.BYN)
+	d, _ := ps.FindAccount(c2, payment.BYN)
+
+	require.NoError(t, ps.Transfer(s, d, 100))
+
+	now = now.AddDate(0, 0, 1)
+	d, _ = ps.FindAccount(c2, payment.BYN)
+	s, _ = ps.FindAccount(c1, payment.BYN)
+	require.NoError(t, ps.Transfer(d, s, 30))
+
+	now = now.AddDate(0, 0, 1)
+	s, _ = ps.FindAccount(c1, payment.BYN)
+	d, _ = ps.FindAccount(c2, payment.BYN)
+	require.NoError(t, ps.Transfer(s, d, 50))
+
+	testCases := []struct {
+		desc    string
+		from    time.Time
+		to      time.Time
+		opening float32
+		closing float32
+		lines   int
+	}{
+		{
+			desc:    "whole history",
+			opening: 1000,
+			closing: 880,
+			lines:   3,
+		},
+		{
+			desc:    "second day",
+			from:    time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+			to:      time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+			opening: 900,
+			closing: 930,
+			lines:   1,
+		},
+		{
+			desc:    "until the third day",
+			to:      time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC),
+			opening: 1000,
+			closing: 930,
+			lines:   2,
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+
+		t.Run(tt.desc, func(t *testing.T) {
+			st, err := ps.Statement(c1, sourceNum, tt.from, tt.to)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.opening, st.Opening)
+			assert.Equal(t, tt.closing, st.Closing)
+			require.Len(t, st.Lines, tt.lines)
+			assert.Equal(t, tt.closing, st.Lines[len(st.Lines)-1].Balance)
+		})
+	}
+
+	_, err := ps.Statement(c2, sourceNum, time.Time{}, time.Time{})
+	assert.ErrorIs(t, err, payment.ErrAccountNotFound)
+}
+
+func TestPaymentSystem_Snapshot(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+	require.NoError(t, ps.CreateCustomer(payment.NewCustomer("1", "Customer One", payment.AccountPrefix)))
+
+	s, _ := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	d, _ := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, ps.Transfer(s, d, 10))
+
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem()
+	require.NoError(t, restored.LoadSnapshot(data))
+
+	again, err := restored.Snapshot()
+	require.NoError(t, err)
+	assert.JSONEq(t, string(data), string(again))
+	assert.Len(t, restored.Transactions(sourceNum), 1)
+
+	c, err := restored.GetCustomer("1")
+	require.NoError(t, err)
+	assert.Equal(t, "Customer One", c.Name)
+
+	assert.ErrorIs(t, restored.CreateCustomer(c), payment.ErrCustomerExists)
+}
+
+func TestPaymentSystem_Snapshot_Settings(t *testing.T) {
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithWatchlist(payment.NewWatchlist("Иван Петров")),
+		payment.WithEmissionCeiling(payment.BYN, 1000),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	ps.SetAccountLimit(sourceNum, payment.Limit{MaxAmount: 50})
+	ps.SetCustomerLimit("1", payment.Limit{DailyCount: 3})
+	ps.SetCurrencyLimit(payment.BYN, payment.Limit{MonthlyAmount: 500})
+	assert.ErrorIs(t, ps.CreateCustomer(payment.NewCustomer("7", "Ivan Petrow", payment.AccountPrefix)), payment.ErrCustomerBlocked)
+
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem(payment.WithEmissionCeiling(payment.USD, 10))
+	require.NoError(t, restored.LoadSnapshot(data))
+
+	hits := restored.WatchlistHits()
+	require.Len(t, hits, 1)
+	assert.Equal(t, "7", hits[0].CustomerId)
+	assert.Equal(t, payment.CustomerBlocked, restored.CustomerStatus("7"))
+
+	s, err := restored.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+
+	allowance, err := restored.RemainingAllowance(s)
+	require.NoError(t, err)
+	assert.Equal(t, float32(50), allowance.MaxAmount)
+	assert.Equal(t, 3, allowance.DailyCount)
+	assert.Equal(t, float32(500), allowance.MonthlyAmount)
+
+	assert.ErrorIs(t, restored.Emit(1001), payment.ErrEmissionCeiling, "the persisted ceiling applies")
+}
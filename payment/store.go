@@ -5,9 +5,12 @@ package payment
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"math/rand"
 	"regexp"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -23,6 +26,9 @@ const (
 	AccountLength               = 26
 )
 
+// DefaultProcessingDelay simulates long processing work of CreateAccount.
+const DefaultProcessingDelay = 5 * time.Second
+
 func init() {
 	source := rand.NewSource(time.Now().UnixNano())
 	rand.New(source)
@@ -31,11 +37,25 @@ func init() {
 type Store interface {
 	CreateAccount(c Customer, accType string, currencyCode string, amount float32) error
 	GetSpecialAccount(accountPrefix string) (Account, error)
+	GetSpecialAccountIn(accountPrefix string, currencyCode string) (Account, error)
 	FindAccount(c Customer, currencyCode string) (Account, error)
+	GetAccountByNumber(c Customer, accountNum string) (Account, error)
+	AccountByNumber(accountNum string) (Account, error)
+	GetCustomer(id string) (Customer, error)
+	CreateCustomer(c Customer) error
+	Accounts(customerId string) []Account
+	QueryAccounts(q AccountQuery) (AccountPage, error)
+	Statement(c Customer, accountNum string, from time.Time, to time.Time) (Statement, error)
+	MoneySupply() []MoneySupply
 	CloseAccount(ac Account) error
+	ActivateAccount(ac Account) error
 	Emit(amount float32) error
+	EmitIn(currencyCode string, amount float32) error
+	Disburse(d Account, amount float32, reference string) error
+	ReceiveCorrespondent(settlement string, dest string, currencyCode string, amount float32, reference string) error
 	Terminate(acc Account, a float32) error
 	Transfer(s Account, d Account, amount float32) error
+	TransferByNumber(source string, dest string, amount float32) error
 	TransferJson(json []byte) error
 	PrintStore() error
 	PrintStoreJson() error
@@ -52,19 +72,274 @@ and represent a bank store and gives the methods to work with it.
 type PaymentSystem struct {
 	store map[string]map[string]Account
 	mu    sync.Mutex
+	now   func() time.Time
+	seq   int
+	actor string
+	delay time.Duration
+
+	accounts *accountIndex
+
+	logger   *slog.Logger
+	auditLog *AuditLog
+	metrics  *Metrics
+
+	journal []Transaction
+
+	approvalThreshold float32
+	approvers         map[string]bool
+	pendingTimeout    time.Duration
+	pending           map[string]*PendingTransfer
+
+	accountLimits  map[string]Limit
+	customerLimits map[string]Limit
+	currencyLimits map[string]Limit
+
+	screener  Screener
+	reviewers map[string]bool
+
+	customers      map[string]Customer
+	watchlist      *Watchlist
+	watchlistHits  []WatchlistHit
+	customerStatus map[string]string
+
+	emissionCeilings    map[string]float32
+	emissionQuorum      int
+	emissionAuthorizers map[string]bool
+	emissions           map[string]*EmissionRequest
+
+	outbox       []Event
+	eventSeq     int
+	eventOffsets map[string]int
+	subscribers  map[string]Subscriber
+	eventNotify  chan struct{}
+	dispatchMu   sync.Mutex
+
+	bankCode string
+	outbound []InterbankTransfer
+
+	businessDate time.Time
+	inEOD        bool
+	eodJobs      []eodJob
+	dayCloses    []DayClose
+	scheduled    map[string]*ScheduledTransfer
+	backValue    time.Time
+}
+
+// Option configures optional behaviour of the PaymentSystem.
+type Option func(ps *PaymentSystem)
+
+// WithProcessingDelay sets how long CreateAccount simulates processing work.
+func WithProcessingDelay(d time.Duration) Option {
+	return func(ps *PaymentSystem) {
+		ps.delay = d
+	}
 }
 
-func NewPaymentSystem() *PaymentSystem {
+// WithClock replaces the time source used for timestamps and expiration.
+func WithClock(now func() time.Time) Option {
+	return func(ps *PaymentSystem) {
+		ps.now = now
+	}
+}
+
+func NewPaymentSystem(opts ...Option) *PaymentSystem {
 	ps := &PaymentSystem{
-		store: make(map[string]map[string]Account),
+		store:          make(map[string]map[string]Account),
+		accounts:       newAccountIndex(),
+		now:            time.Now,
+		actor:          DefaultActor,
+		delay:          DefaultProcessingDelay,
+		logger:         slog.Default(),
+		auditLog:       NewAuditLog(nil),
+		approvers:      make(map[string]bool),
+		pendingTimeout: DefaultPendingTimeout,
+		pending:        make(map[string]*PendingTransfer),
+		accountLimits:  make(map[string]Limit),
+		customerLimits: make(map[string]Limit),
+		currencyLimits: make(map[string]Limit),
+		reviewers:      make(map[string]bool),
+		customers:      make(map[string]Customer),
+		customerStatus: make(map[string]string),
+
+		emissionCeilings:    make(map[string]float32),
+		emissionAuthorizers: make(map[string]bool),
+		emissions:           make(map[string]*EmissionRequest),
+		scheduled:           make(map[string]*ScheduledTransfer),
+
+		eventOffsets: make(map[string]int),
+		subscribers:  make(map[string]Subscriber),
+		eventNotify:  make(chan struct{}, eventNotifyChannelSize),
+	}
+
+	for _, opt := range opts {
+		opt(ps)
 	}
 
 	return ps
 }
 
+// nextID returns a new sequential identifier with the given prefix.
+func (ps *PaymentSystem) nextID(prefix string) string {
+	ps.seq++
+
+	return fmt.Sprintf("%s%08d", prefix, ps.seq)
+}
+
+// lookup returns the store key and the current state of the account.
+func (ps *PaymentSystem) lookup(cid string, num string) (string, Account, bool) {
+	if ref, ok := ps.accounts.byNumber[num]; ok && ref.customerId == cid {
+		if v, ok := ps.store[cid][ref.key]; ok && v.Num == num {
+			return ref.key, v, true
+		}
+	}
+
+	// a number used by accounts of several customers is indexed once
+	for k, v := range ps.store[cid] {
+		if v.Num == num && v.CustomerId == cid {
+			return k, v, true
+		}
+	}
+
+	return "", Account{}, false
+}
+
+// findByNumber returns the account with the number of any customer.
+func (ps *PaymentSystem) findByNumber(num string) (Account, bool) {
+	ref, ok := ps.accounts.byNumber[num]
+	if !ok {
+		return Account{}, false
+	}
+
+	_, a, ok := ps.lookup(ref.customerId, num)
+
+	return a, ok
+}
+
+/*
+This func returns the account with the number without knowing its customer,
+e.g. the destination of a payment, which carries only the IBAN.
+*/
+func (ps *PaymentSystem) AccountByNumber(accountNum string) (Account, error) {
+	a, ok := ps.findByNumber(accountNum)
+	if !ok {
+		return a, fmt.Errorf("%w: %s", ErrAccountNotFound, accountNum)
+	}
+
+	return a, nil
+}
+
+/*
+This func replaces the account of the caller by the account from the store,
+so the status, currency and balance of the caller are never trusted.
+The account may be given only by its number. The accounts of other banks
+and unknown numbers are returned unchanged.
+*/
+func (ps *PaymentSystem) resolveAccount(a Account) Account {
+	if a.CustomerId != "" {
+		if _, res, ok := ps.lookup(a.CustomerId, a.Num); ok {
+			return res
+		}
+
+		return a
+	}
+
+	if res, ok := ps.findByNumber(a.Num); ok {
+		return res
+	}
+
+	return a
+}
+
+// positive tells whether the amount can be moved: NaN and infinity are not amounts.
+func positive(amount float32) bool {
+	return amount > 0 && !math.IsInf(float64(amount), 1)
+}
+
+/*
+This func writes the account back to the store and increments its version.
+The write fails with ErrConflict when the account has been changed
+since the caller read it, so a stale copy never overwrites a newer state.
+*/
+func (ps *PaymentSystem) put(key string, a Account) (Account, error) {
+	if err := ps.checkPut(key, a); err != nil {
+		return a, err
+	}
+
+	return ps.write(key, a), nil
+}
+
+// checkPut tells why the account cannot be written back to the store under the key.
+func (ps *PaymentSystem) checkPut(key string, a Account) error {
+	cur, ok := ps.store[a.CustomerId][key]
+	if !ok || cur.Num != a.Num {
+		return fmt.Errorf("%w: %s", ErrAccountNotFound, a.Num)
+	}
+
+	if cur.Version != a.Version {
+		return fmt.Errorf("%w: account %s has version %d, the change is based on version %d", ErrConflict, a.Num, cur.Version, a.Version)
+	}
+
+	return nil
+}
+
+func (ps *PaymentSystem) write(key string, a Account) Account {
+	a.Version++
+	ps.store[a.CustomerId][key] = a
+	ps.index(key, a)
+
+	return a
+}
+
+/*
+This func writes the two accounts of a movement back to the store, both
+or none: both writes are checked before either account is written, so
+a failed credit never leaves the debit behind. A movement between
+the account and itself is rejected, its second write would be stale.
+*/
+func (ps *PaymentSystem) putPair(key1 string, a1 Account, key2 string, a2 Account) (Account, Account, error) {
+	if a1.CustomerId == a2.CustomerId && key1 == key2 {
+		return a1, a2, fmt.Errorf("%w: %s", ErrSameAccount, a1.Num)
+	}
+
+	for _, w := range []struct {
+		key string
+		a   Account
+	}{{key1, a1}, {key2, a2}} {
+		if err := ps.checkPut(w.key, w.a); err != nil {
+			return a1, a2, err
+		}
+	}
+
+	return ps.write(key1, a1), ps.write(key2, a2), nil
+}
+
+/*
+This func checks the account passed by the caller against the store.
+An account read before a later change fails with ErrConflict,
+an account without version (built by the caller) is not checked.
+*/
+func (ps *PaymentSystem) checkVersion(a Account) error {
+	if a.Version == 0 {
+		return nil
+	}
+
+	_, cur, ok := ps.lookup(a.CustomerId, a.Num)
+	if ok && cur.Version != a.Version {
+		return fmt.Errorf("%w: account %s has version %d, the caller has version %d", ErrConflict, a.Num, cur.Version, a.Version)
+	}
+
+	return nil
+}
+
 func (ps *PaymentSystem) Lock() error {
+	start := time.Now()
+
 	ps.mu.Lock()
 
+	if ps.metrics != nil {
+		ps.metrics.observeLockWait(time.Since(start))
+	}
+
 	return nil
 }
 
@@ -102,42 +377,59 @@ func (ps *PaymentSystem) Restore(oldStore []byte) error {
 		return err
 	}
 
+	ps.reindex()
+
 	return nil
 }
 
 /*
 This func create a new account with params assined to customer.
 */
-func (ps *PaymentSystem) CreateAccount(c Customer, accType string, currencyCode string, amount float32) error {
-	log.Printf("Try to create account for cusromer %s currency %s amount %.2f \n", c.Id, currencyCode, amount)
+func (ps *PaymentSystem) CreateAccount(c Customer, accType string, currencyCode string, amount float32) (err error) {
+	var num string
+
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpCreateAccount, "", start, auditInput{"customer_id": c.Id, "type": accType, "currency": currencyCode, "amount": amount}, num, err)
+	}()
 
-	if amount < 0 {
-		log.Println("Creating account is imposible: amount < 0")
+	ps.logger.Debug("try to create account", "op", OpCreateAccount, "customer_id", c.Id, "currency", currencyCode, "amount", amount)
 
+	if amount != 0 && !positive(amount) {
 		return fmt.Errorf("Creating account  is imposible: amount < 0 \n")
 	}
 
 	if c.Id == "" {
-		log.Println("Creating account is imposible: without client ID")
-
 		return fmt.Errorf("Creating account is imposible: without client ID\n")
 	}
 
+	// a known customer is screened by the name it was registered with
+	if prev, ok := ps.customers[c.Id]; ok {
+		c = prev
+	} else {
+		ps.customers[c.Id] = c
+	}
+
+	if err := ps.screenCustomer(c, "create_account"); err != nil {
+		return err
+	}
+
 	sid := ""
 	aid := ""
 
-	if accType == AccountStateEmissionPrefix {
-		sid = AccountStateEmissionPrefix
-		aid = AccountStateEmissionNumber
-	} else if accType == AccountStateTerminatePrefix {
-		sid = AccountStateTerminatePrefix
-		aid = AccountStateTerminateNumber
+	if accType == AccountStateEmissionPrefix || accType == AccountStateTerminatePrefix {
+		sid = specialKey(accType, currencyCode)
+		aid = SpecialAccountNumber(accType, currencyCode)
 	} else {
 		sid = generateIdentifier(AccountLength)
-		aid = GenerateAccountNumber()
+		aid = ps.newAccountNumber()
 	}
 
 	na := NewAccount(c.Id, currencyCode, aid, amount)
+	na.CreatedAt = ps.now()
+	na.Version = 1
+	num = na.Num
 
 	list, ok := ps.store[c.Id]
 	if ok {
@@ -148,37 +440,44 @@ func (ps *PaymentSystem) CreateAccount(c Customer, accType string, currencyCode
 		ps.store[c.Id] = accounts
 	}
 
-	// simulate long processing work
-	time.Sleep(time.Second * 5)
+	ps.index(sid, na)
 
-	log.Printf("Account with number:%s is created successfully\n", na.Num)
+	ps.publish(EventAccountCreated, AccountCreated{Account: na})
+
+	// simulate long processing work
+	time.Sleep(ps.delay)
 
 	return nil
 }
 
 // Function for quick access to special accounts.
 func (ps *PaymentSystem) GetSpecialAccount(accountPrefix string) (Account, error) {
+	return ps.GetSpecialAccountIn(accountPrefix, DefaultCurrency)
+}
+
+// GetSpecialAccountIn returns the emission (SE) or termination (ST) account of the currency.
+func (ps *PaymentSystem) GetSpecialAccountIn(accountPrefix string, currencyCode string) (Account, error) {
 	var res Account
 
-	acc, ok := ps.store["0"][accountPrefix]
-	if ok {
-		res = acc
-	} else {
-		log.Println("Special account not found")
+	if accountPrefix != AccountStateEmissionPrefix && accountPrefix != AccountStateTerminatePrefix {
+		ps.logger.Warn("special account not found", "prefix", accountPrefix)
 
 		return res, fmt.Errorf("special account not found %s\n", accountPrefix)
 	}
 
-	if accountPrefix == AccountStateEmissionPrefix {
-		log.Printf("EmissionAccount Number  is %s\n", res.Num)
-	} else if accountPrefix == AccountStateTerminatePrefix {
-		log.Printf("TerminateAccount Number is %s\n", res.Num)
-	} else {
-		log.Println("Special account not found")
+	key := specialKey(accountPrefix, currencyCode)
 
-		return res, fmt.Errorf("special account not found %s\n", accountPrefix)
+	acc, ok := ps.store[SpecialCustomerId][key]
+	if !ok {
+		ps.logger.Warn("special account not found", "prefix", accountPrefix, "currency", currencyCode)
+
+		return res, fmt.Errorf("special account not found %s\n", key)
 	}
 
+	res = acc
+
+	ps.logger.Debug("special account found", "prefix", accountPrefix, "account", res.Num)
+
 	err := VerifyAccountNumber(res.Num)
 	if err != nil {
 		return res, err
@@ -191,19 +490,11 @@ func (ps *PaymentSystem) GetSpecialAccount(accountPrefix string) (Account, error
 This func returns the account from store by customer and account number.
 */
 func (ps *PaymentSystem) GetAccountByNumber(c Customer, accountNum string) (Account, error) {
-	var res Account
+	_, res, ok := ps.lookup(c.Id, accountNum)
+	if !ok {
+		ps.logger.Warn("account not found", "customer_id", c.Id, "account", accountNum)
 
-	_, ok := ps.store[c.Id]
-	if ok {
-		for _, v := range ps.store[c.Id] {
-			if v.Num == accountNum {
-				res = v
-			}
-		}
-	} else {
-		log.Println("Account not found!")
-		// panic("Account not found!")
-		return res, fmt.Errorf("Account not found!%s", accountNum)
+		return res, fmt.Errorf("%w: %s of customer %s", ErrAccountNotFound, accountNum, c.Id)
 	}
 
 	return res, nil
@@ -229,197 +520,256 @@ func (ps *PaymentSystem) FindAccount(c Customer, currencyCode string) (Account,
 	return res, nil
 }
 
+// GetCustomer returns the customer registered by CreateAccount.
+func (ps *PaymentSystem) GetCustomer(id string) (Customer, error) {
+	c, ok := ps.customers[id]
+	if !ok {
+		return c, fmt.Errorf("%w: %s", ErrCustomerNotFound, id)
+	}
+
+	return c, nil
+}
+
 /*
-This func blocks the account.
+This func registers a new customer without accounts.
+The customer is screened against the watchlist like in CreateAccount.
 */
-func (ps *PaymentSystem) CloseAccount(ac Account) error {
-	log.Printf("Try to block account %s \n", ac.Num)
+func (ps *PaymentSystem) CreateCustomer(c Customer) (err error) {
+	start := time.Now()
 
-	var res Account
+	defer func() {
+		ps.finish(OpCreateCustomer, "", start, auditInput{"customer_id": c.Id, "name": c.Name}, "", err)
+	}()
+
+	if c.Id == "" {
+		return fmt.Errorf("Creating customer is imposible: without client ID\n")
+	}
 
-	var oldKey string
+	if _, ok := ps.customers[c.Id]; ok {
+		return fmt.Errorf("%w: %s", ErrCustomerExists, c.Id)
+	}
 
-	_, ok := ps.store[ac.CustomerId]
+	ps.customers[c.Id] = c
 
-	if ok {
-		for k, v := range ps.store[ac.CustomerId] {
-			if v.Num == ac.Num && v.CustomerId == ac.CustomerId {
-				res = v
-				oldKey = k
+	return ps.screenCustomer(c, OpCreateCustomer)
+}
+
+// Accounts returns the accounts of the customer, or of all customers when the id is empty,
+// ordered by customer and account number.
+func (ps *PaymentSystem) Accounts(customerId string) []Account {
+	var res []Account
 
-				break
-			}
+	for cid, accounts := range ps.store {
+		if customerId != "" && cid != customerId {
+			continue
 		}
 
+		for _, a := range accounts {
+			res = append(res, a)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CustomerId != res[j].CustomerId {
+			return res[i].CustomerId < res[j].CustomerId
+		}
+
+		return res[i].Num < res[j].Num
+	})
+
+	return res
+}
+
+/*
+This func blocks the account.
+*/
+func (ps *PaymentSystem) CloseAccount(ac Account) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpCloseAccount, "", start, auditInput{"customer_id": ac.CustomerId, "account": ac.Num}, "", err)
+	}()
+
+	ps.logger.Debug("try to block account", "op", OpCloseAccount, "account", ac.Num)
+
+	if err := ps.checkVersion(ac); err != nil {
+		return err
+	}
+
+	if key, res, ok := ps.lookup(ac.CustomerId, ac.Num); ok {
 		res.Status = Blocked
-		ps.store[ac.CustomerId][oldKey] = res
 
-		log.Printf("Account %s was closed successfully. CustomerId: %s\n", res.Num, res.CustomerId)
+		res, err := ps.put(key, res)
+		if err != nil {
+			return err
+		}
+
+		ps.publish(EventAccountBlocked, AccountBlocked{Account: res})
 
 		return nil
 	}
 
-	log.Printf("Account %s is not closed. The reason: not founded. CustomerId: %s\n", res.Num, res.CustomerId)
-
-	return fmt.Errorf("Account %s is not closed. The reason: not founded. CustomerId: %s\n", res.Num, res.CustomerId)
+	return fmt.Errorf("Account %s is not closed. The reason: not founded. CustomerId: %s\n", ac.Num, ac.CustomerId)
 }
 
 /*
 This func activate the account.
 */
-func (ps *PaymentSystem) ActivateAccount(ac Account) error {
-	log.Printf("Try to activate account %s \n", ac.Num)
+func (ps *PaymentSystem) ActivateAccount(ac Account) (err error) {
+	start := time.Now()
 
-	var res Account
+	defer func() {
+		ps.finish(OpActivateAccount, "", start, auditInput{"customer_id": ac.CustomerId, "account": ac.Num}, "", err)
+	}()
 
-	var oldKey string
+	ps.logger.Debug("try to activate account", "op", OpActivateAccount, "account", ac.Num)
 
-	_, ok := ps.store[ac.CustomerId]
+	if err := ps.checkVersion(ac); err != nil {
+		return err
+	}
 
-	if ok {
-		for k, v := range ps.store[ac.CustomerId] {
-			if v.Num == ac.Num && v.CustomerId == ac.CustomerId {
-				res = v
-				oldKey = k
+	if key, res, ok := ps.lookup(ac.CustomerId, ac.Num); ok {
+		res.Status = Active
 
-				break
-			}
+		res, err := ps.put(key, res)
+		if err != nil {
+			return err
 		}
 
-		res.Status = Active
-		ps.store[ac.CustomerId][oldKey] = res
-
-		log.Printf("Account %s was activate successfully. CustomerId: %s\n", res.Num, res.CustomerId)
+		ps.publish(EventAccountActivated, AccountActivated{Account: res})
 
 		return nil
 	}
 
-	log.Printf("Account %s is not activated. The reason: not founded. CustomerId: %s\n", res.Num, res.CustomerId)
-
-	return fmt.Errorf("Account %s is not activated. The reason: not founded. CustomerId: %s\n", res.Num, res.CustomerId)
+	return fmt.Errorf("Account %s is not activated. The reason: not founded. CustomerId: %s\n", ac.Num, ac.CustomerId)
 }
 
 /*
 This func emit amount of money to special emission account.
 */
 func (ps *PaymentSystem) Emit(amount float32) error {
-	log.Printf("Try to emit amount: %.2f to spec emission account \n", amount)
+	return ps.EmitIn(DefaultCurrency, amount)
+}
 
-	if amount <= 0 {
-		log.Println("Emission is imposible: amount <= 0")
+/*
+This func emit amount of money to special emission account of the currency.
+When emission needs authorization it must be requested with RequestEmission instead.
+*/
+func (ps *PaymentSystem) EmitIn(currencyCode string, amount float32) (err error) {
+	start := time.Now()
 
-		return fmt.Errorf("Emission is imposible: amount <= 0 \n")
-	}
+	defer func() {
+		ps.finish(OpEmit, "", start, auditInput{"currency": currencyCode, "amount": amount}, "", err)
+	}()
 
-	e, err := ps.GetSpecialAccount(AccountStateEmissionPrefix)
-	if err != nil {
-		log.Println("Emission is imposible: account not found!")
+	ps.logger.Debug("try to emit amount to spec emission account", "op", OpEmit, "currency", currencyCode, "amount", amount)
 
-		return err
+	if ps.emissionQuorum > 0 {
+		return fmt.Errorf("Emission is imposible: %w", ErrEmissionNotAuthorized)
 	}
 
-	e.Balance += amount
+	return ps.emit(currencyCode, amount)
+}
 
-	var oldKey string
+// emit credits the emission account of the currency within the ceiling.
+func (ps *PaymentSystem) emit(currencyCode string, amount float32) error {
+	if !positive(amount) {
+		return fmt.Errorf("Emission is imposible: amount <= 0 \n")
+	}
 
-	_, ok := ps.store[e.CustomerId]
-	if ok {
-		for k, v := range ps.store[e.CustomerId] {
-			if v.Num == e.Num && v.CustomerId == e.CustomerId {
-				oldKey = k
+	if err := ps.checkCeiling(currencyCode, amount); err != nil {
+		return err
+	}
 
-				break
-			}
-		}
+	e, err := ps.GetSpecialAccountIn(AccountStateEmissionPrefix, currencyCode)
+	if err != nil {
+		return err
+	}
 
-		ps.store[e.CustomerId][oldKey] = e
+	key, e, ok := ps.lookup(e.CustomerId, e.Num)
+	if !ok {
+		return fmt.Errorf("Emission is imposible: spec emission account not found! \n")
+	}
 
-		log.Printf("Emission %2.f to account %s was done successfully. CustomerId: %s\n", amount, e.Num, e.CustomerId)
-	} else {
-		log.Println("Emission is imposible: account not found!")
+	e.Balance += amount
 
-		return fmt.Errorf("Emission is imposible: spec emission account not found! \n")
+	e, err = ps.put(key, e)
+	if err != nil {
+		return err
 	}
 
+	tx := ps.record(TxEmission, "", e.Num, e.CurrencyCode, amount)
+
+	ps.publish(EventFundsEmitted, FundsEmitted{Account: e, Amount: amount, TransactionId: tx.Id})
+
 	return nil
 }
 
 /*
 This func transfers amount of money from source account to terminate account.
 */
-func (ps *PaymentSystem) Terminate(s Account, amount float32) error {
-	log.Printf("Try to terminate amount: %.2f from account %s\n", amount, s.Num)
+func (ps *PaymentSystem) Terminate(s Account, amount float32) (err error) {
+	start := time.Now()
 
-	if !accountAvailable(s) {
-		log.Printf("Termination is imposible: account %s is not valid or blocked", s.Num)
+	defer func() {
+		ps.finish(OpTerminate, "", start, auditInput{"account": s.Num, "amount": amount}, "", err)
+	}()
 
-		return fmt.Errorf("Termination is imposible: account %s is not valid or blocked", s.Num)
-	}
+	ps.logger.Debug("try to terminate amount", "op", OpTerminate, "account", s.Num, "amount", amount)
 
-	if amount <= 0 {
-		log.Println("Termination is imposible: amount <= 0")
+	if err := ps.checkVersion(s); err != nil {
+		return err
+	}
 
+	if !positive(amount) {
 		return fmt.Errorf("Termination is imposible: amount <= 0 \n")
 	}
 
-	t, err := ps.GetSpecialAccount(AccountStateTerminatePrefix)
-	if err != nil {
-		log.Println("Termination is imposible: account not found!")
+	// the checks run on the stored account, not on the copy of the caller
+	s = ps.resolveAccount(s)
 
-		return err
+	key, res, ok := ps.lookup(s.CustomerId, s.Num)
+	if !ok {
+		return fmt.Errorf("Termination is imposible: account not found! %s\n", s.Num)
 	}
 
-	var res Account
-
-	var oldKey, oldKey1 string
+	if !accountAvailable(res) {
+		return fmt.Errorf("Termination is imposible: account %s is not valid or blocked", res.Num)
+	}
 
-	// find key and value for source account
+	if err := ps.checkLimits(res, amount); err != nil {
+		return err
+	}
 
-	_, ok := ps.store[s.CustomerId]
-	if !ok {
-		log.Println("Termination is imposible: account not found!")
-		// panic("Terminate is imposible: account not found!")
-		return fmt.Errorf("Termination is imposible: account not found! %s\n", s.Num)
+	t, err := ps.GetSpecialAccountIn(AccountStateTerminatePrefix, res.CurrencyCode)
+	if err != nil {
+		return err
 	}
 
 	// find key for terminate account
-	_, ok1 := ps.store[t.CustomerId]
-	if !ok1 {
-		log.Println("Termination is imposible: account not found!")
-		// panic("Terminate is imposible: account not found!")
+	key1, t, ok := ps.lookup(t.CustomerId, t.Num)
+	if !ok {
 		return fmt.Errorf("Termination is imposible: spec terminate account not found! \n")
 	}
 
-	if ok {
-		for k, v := range ps.store[s.CustomerId] {
-			if v.Num == s.Num && v.CustomerId == s.CustomerId {
-				oldKey = k
-				res = v
-
-				break
-			}
-		}
+	if res.Balance-res.Reserved < amount {
+		return fmt.Errorf("Termination is imposible: insufficient funds on account %s: %w", res.Num, ErrInsufficientFunds)
+	}
 
-		res.Balance -= amount
-		ps.store[s.CustomerId][oldKey] = res
+	res.Balance -= amount
+	t.Balance += amount
 
-		log.Printf("Amount: %.2f was terminated from account %s\n", amount, s.Num)
+	res, t, err = ps.putPair(key, res, key1, t)
+	if err != nil {
+		return err
 	}
 
-	if ok1 {
-		for k, v := range ps.store[t.CustomerId] {
-			if v.Num == t.Num && v.CustomerId == t.CustomerId {
-				oldKey1 = k
-
-				break
-			}
-		}
+	ps.logger.Debug("amount was terminated from account", "op", OpTerminate, "account", s.Num, "amount", amount)
+	ps.logger.Debug("amount was transferred to special terminate account", "op", OpTerminate, "account", t.Num, "amount", amount)
 
-		t.Balance += amount
-		ps.store[t.CustomerId][oldKey1] = t
+	tx := ps.record(TxTermination, res.Num, t.Num, res.CurrencyCode, amount)
 
-		log.Printf("Amount: %.2f was transferred to special terminate account %s\n", amount, t.Num)
-	}
+	ps.publish(EventFundsTerminated, FundsTerminated{Account: res, Amount: amount, TransactionId: tx.Id})
 
 	return nil
 }
@@ -430,86 +780,165 @@ func accountAvailable(s Account) bool {
 
 /*
 This func transfers amount of money from source account to destination account.
+An account without customer id is found by its number.
+A transfer held for approval or review is initiated by the actor set by SetActor.
 */
 func (ps *PaymentSystem) Transfer(s Account, d Account, amount float32) error {
-	log.Printf("Try to transfer amount: %.2f from account %s to account %s\n", amount, s.Num, d.Num)
+	_, err := ps.SubmitTransfer("", s, d, amount)
 
-	if !accountAvailable(s) {
-		log.Printf("Transfer is imposible: account %s is not valid or blocked", s.Num)
+	return err
+}
+
+/*
+This func transfers amount of money between the accounts with the numbers.
+The accounts are read from the store, so the caller cannot pass stale balances.
+*/
+func (ps *PaymentSystem) TransferByNumber(source string, dest string, amount float32) error {
+	_, err := ps.SubmitTransferByNumber("", source, dest, amount)
+
+	return err
+}
+
+// SubmitTransferByNumber is SubmitTransfer for the accounts with the numbers.
+func (ps *PaymentSystem) SubmitTransferByNumber(maker string, source string, dest string, amount float32) (string, error) {
+	return ps.SubmitTransfer(maker, Account{Num: source}, Account{Num: dest}, amount)
+}
+
+/*
+This func validates a transfer initiated by maker and executes it.
+When the amount exceeds the approval threshold the funds are reserved instead
+and the id of the pending transfer is returned. The accounts given only
+by number are read from the store.
+*/
+func (ps *PaymentSystem) SubmitTransfer(maker string, s Account, d Account, amount float32) (id string, err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpTransfer, maker, start, auditInput{"source": s.Num, "destination": d.Num, "amount": amount}, id, err)
 
-		return fmt.Errorf("Transfer is imposible: account %s is not valid or blocked", s.Num)
+		if err != nil {
+			ps.publish(EventTransferFailed, TransferFailed{Source: s.Num, Destination: d.Num, Amount: amount, Reason: strings.TrimSpace(err.Error())})
+		}
+	}()
+
+	ps.logger.Debug("try to transfer amount", "op", OpTransfer, "account", s.Num, "destination", d.Num, "amount", amount)
+
+	for _, a := range []Account{s, d} {
+		if err := ps.checkVersion(a); err != nil {
+			return "", err
+		}
 	}
 
-	if !accountAvailable(d) {
-		log.Printf("Transfer is imposible: account %s is not valid or blocked", d.Num)
+	s, d = ps.resolveAccount(s), ps.resolveAccount(d)
+
+	// the account of another bank is reached through the clearing
+	if ps.foreign(d) {
+		d.Status = Active
+
+		if d.CurrencyCode == "" {
+			d.CurrencyCode = s.CurrencyCode
+		}
+	}
 
-		return fmt.Errorf("Transfer is imposible: account %s is not valid or blocked", d.Num)
+	if !accountAvailable(s) {
+		return "", fmt.Errorf("Transfer is imposible: account %s is not valid or blocked", s.Num)
 	}
 
-	if amount <= 0 {
-		log.Println("Transfer is imposible: amount < 0")
+	if !accountAvailable(d) {
+		return "", fmt.Errorf("Transfer is imposible: account %s is not valid or blocked", d.Num)
+	}
 
-		return fmt.Errorf("Transfer is imposible: amount < 0 \n")
+	if !positive(amount) {
+		return "", fmt.Errorf("Transfer is imposible: amount < 0 \n")
+	}
+
+	if s.Num == d.Num {
+		return "", fmt.Errorf("Transfer is imposible: %w: %s", ErrSameAccount, s.Num)
 	}
 
 	if s.CurrencyCode != d.CurrencyCode {
-		log.Println("Transfer is imposible: different currency!")
+		return "", fmt.Errorf("Transfer is imposible: different currency! \n")
+	}
 
-		return fmt.Errorf("Transfer is imposible: different currency! \n")
+	if err := ps.checkLimits(s, amount); err != nil {
+		return "", err
 	}
 
-	var res Account
+	for _, a := range []Account{s, d} {
+		if err := ps.screenParty(a); err != nil {
+			return "", err
+		}
+	}
 
-	var oldKey, oldKey1 string
+	if ps.screener != nil {
+		res := ps.screen(s, d, amount)
+
+		switch res.Decision {
+		case DecisionDeny:
+			return "", fmt.Errorf("%w by rule %s: %s", ErrTransferDenied, res.Rule, res.Reason)
+		case DecisionReview:
+			return ps.holdTransfer(maker, s, d, amount, TransferInReview, res.Rule+": "+res.Reason)
+		}
+	}
+
+	if ps.requiresApproval(amount) {
+		return ps.holdTransfer(maker, s, d, amount, TransferPending, "")
+	}
+
+	return "", ps.transfer(s, d, amount)
+}
+
+/*
+This func moves the amount between two validated accounts.
+Both accounts are read from the store and checked again, the status,
+currency and balances passed by the caller are not used.
+*/
+func (ps *PaymentSystem) transfer(s Account, d Account, amount float32) error {
+	if ps.foreign(d) {
+		return ps.sendOutbound(s, d, amount)
+	}
 
 	// find key and value for source account
-	_, ok := ps.store[s.CustomerId]
+	key, res, ok := ps.lookup(s.CustomerId, s.Num)
 	if !ok {
-		log.Println("Transfer is imposible: account not found!")
-		// panic("Transfer is imposible: account not found!")
 		return fmt.Errorf("Transfer is imposible: account not found! %s\n", s.Num)
 	}
-	// find key for terminate account
-	_, ok1 := ps.store[d.CustomerId]
 
-	if !ok1 {
-		log.Println("Transfer is imposible: account not found!")
-		// panic("Transfer is imposible: account not found!")
+	// find key and value for destination account
+	key1, dst, ok := ps.lookup(d.CustomerId, d.Num)
+	if !ok {
 		return fmt.Errorf("Transfer is imposible: account not found! %s\n", d.Num)
 	}
 
-	if ok {
-		for k, v := range ps.store[s.CustomerId] {
-			if v.Num == s.Num && v.CustomerId == s.CustomerId {
-				oldKey = k
-				res = v
-
-				break
-			}
+	// the stored accounts may have changed since the transfer was validated or held
+	for _, a := range []Account{res, dst} {
+		if !accountAvailable(a) {
+			return fmt.Errorf("Transfer is imposible: account %s is not valid or blocked", a.Num)
 		}
+	}
 
-		res.Balance -= amount
-		ps.store[s.CustomerId][oldKey] = res
+	if res.CurrencyCode != dst.CurrencyCode {
+		return fmt.Errorf("Transfer is imposible: different currency! \n")
+	}
 
-		log.Printf("Amount: %.2f was transferred from account %s\n", amount, s.Num)
+	if res.Balance-res.Reserved < amount {
+		return fmt.Errorf("Transfer is imposible: insufficient funds on account %s: %w", s.Num, ErrInsufficientFunds)
 	}
 
-	// find key for terminate account
+	res.Balance -= amount
+	dst.Balance += amount
 
-	if ok1 {
-		for k, v := range ps.store[d.CustomerId] {
-			if v.Num == d.Num && v.CustomerId == d.CustomerId {
-				oldKey1 = k
+	res, dst, err := ps.putPair(key, res, key1, dst)
+	if err != nil {
+		return err
+	}
 
-				break
-			}
-		}
+	ps.logger.Debug("amount was transferred from account", "op", OpTransfer, "account", s.Num, "amount", amount)
+	ps.logger.Debug("amount was transferred to account", "op", OpTransfer, "account", d.Num, "amount", amount)
 
-		d.Balance += amount
-		ps.store[d.CustomerId][oldKey1] = d
+	tx := ps.record(TxTransfer, res.Num, dst.Num, res.CurrencyCode, amount)
 
-		log.Printf("Amount: %.2f was transferfed to account %s\n", amount, d.Num)
-	}
+	ps.publish(EventTransferCompleted, TransferCompleted{Source: res, Destination: dst, Amount: amount, TransactionId: tx.Id})
 
 	return nil
 }
@@ -520,7 +949,7 @@ func (ps *PaymentSystem) TransferJson(data []byte) error {
 
 	err := json.Unmarshal(data, &t)
 	if err != nil {
-		log.Printf("Error: cannot deserialize JSON object.")
+		ps.logger.Error("cannot deserialize JSON object", "op", OpTransfer, "error", err)
 
 		return err
 	}
@@ -535,7 +964,7 @@ func (ps *PaymentSystem) PrintStoreJson() error {
 
 	obj, err := json.MarshalIndent(ps.store, "", "    ")
 	if err != nil {
-		log.Printf("error while converting store to json in func PrintStoreJson: %v", err)
+		ps.logger.Error("error while converting store to json in func PrintStoreJson", "error", err)
 
 		return err
 	}
@@ -547,20 +976,18 @@ func (ps *PaymentSystem) PrintStoreJson() error {
 }
 
 func (ps *PaymentSystem) PrintStore() error {
-	log.Println("______________________________________________________")
-	log.Println()
-	log.Println("\nStore Info:")
-	log.Println("______________________________________________________")
+	ps.logger.Info("store info", "customers", len(ps.store))
 
 	for k, v := range ps.store {
-		log.Println()
-		log.Printf("Client Id: %s\n", k)
-
 		for _, a := range v {
-			log.Printf("account num: %v\n", a)
+			ps.logger.Info("store account",
+				"customer_id", k,
+				"account", a.Num,
+				"currency", a.CurrencyCode,
+				"status", a.Status,
+				"balance", a.Balance,
+				"reserved", a.Reserved)
 		}
-
-		log.Println("______________________________________________________")
 	}
 
 	return nil
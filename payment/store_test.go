@@ -2,6 +2,7 @@ package payment_test
 
 import (
 	"fmt"
+	"math"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	"github.com/soundrise/go-payment-system/payment"
 	mocks "github.com/soundrise/go-payment-system/payment/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPaymentSystem_CreateAccount(t *testing.T) {
@@ -229,3 +231,221 @@ func TestPaymentSystem_Emit(t *testing.T) {
 		})
 	}
 }
+
+func TestPaymentSystem_Versions(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+
+	// accounts restored from an old dump are not versioned yet
+	assert.Equal(t, 0, s.Version)
+
+	require.NoError(t, ps.Transfer(s, d, 100))
+
+	fresh, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, 1, fresh.Version)
+	assert.Equal(t, float32(100), fresh.Balance)
+
+	// the stale copy of the destination does not overwrite the first transfer
+	require.NoError(t, ps.Transfer(s, d, 50))
+
+	fresh, err = ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, 2, fresh.Version)
+	assert.Equal(t, float32(150), fresh.Balance)
+
+	testCases := []struct {
+		desc string
+		op   func(a payment.Account) error
+	}{
+		{desc: "transfer", op: func(a payment.Account) error { return ps.Transfer(a, s, 1) }},
+		{desc: "terminate", op: func(a payment.Account) error { return ps.Terminate(a, 1) }},
+		{desc: "close", op: func(a payment.Account) error { return ps.CloseAccount(a) }},
+		{desc: "activate", op: func(a payment.Account) error { return ps.ActivateAccount(a) }},
+	}
+
+	stale := fresh
+	stale.Version = 1
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			assert.ErrorIs(t, tt.op(stale), payment.ErrConflict)
+		})
+	}
+
+	got, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, fresh, got)
+}
+
+func TestPaymentSystem_TransferByNumber(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	require.NoError(t, ps.TransferByNumber(sourceNum, destNum, 250))
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(750), s.Balance)
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(250), d.Balance)
+
+	assert.ErrorContains(t, ps.TransferByNumber(sourceNum, "BY00NONE00000000000000000000", 1), "is not valid or blocked")
+	assert.ErrorIs(t, ps.TransferByNumber(destNum, sourceNum, 1000), payment.ErrInsufficientFunds)
+}
+
+func TestPaymentSystem_TransferSameAccount(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, ps.Transfer(s, s, 100), payment.ErrSameAccount)
+	assert.ErrorIs(t, ps.TransferByNumber(sourceNum, sourceNum, 100), payment.ErrSameAccount)
+
+	got, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+	assert.Equal(t, s, got)
+}
+
+func TestPaymentSystem_TransferStoredAccounts(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("2", "", ""), payment.AccountPrefix, payment.USD, 0))
+
+	usd, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.USD)
+	require.NoError(t, err)
+	require.NoError(t, ps.CloseAccount(usd))
+
+	s, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+
+	// the caller claims the blocked USD account is an active BYN account
+	forged := payment.Account{CustomerId: "2", Num: usd.Num, CurrencyCode: payment.BYN, Status: payment.Active}
+
+	assert.ErrorContains(t, ps.Transfer(s, forged, 100), "is not valid or blocked")
+	assert.ErrorContains(t, ps.Transfer(s, payment.Account{Num: usd.Num}, 100), "is not valid or blocked")
+	assert.ErrorContains(t, ps.TransferJson([]byte(`{"source": "`+sourceNum+`", "destination": {"customer_id": "2", "num": "`+usd.Num+`", "currency_code": "BYN", "status": "active"}, "amount": 100}`)), "is not valid or blocked")
+
+	usd, err = ps.AccountByNumber(usd.Num)
+	require.NoError(t, err)
+	require.NoError(t, ps.ActivateAccount(usd))
+
+	forged.Status = payment.Blocked
+	assert.ErrorContains(t, ps.Transfer(s, forged, 100), "different currency")
+
+	got, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+	assert.Equal(t, s, got)
+}
+
+func TestPaymentSystem_TerminateStoredAccount(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	gov := payment.NewCustomer(payment.SpecialCustomerId, "GOVERNMENT", "")
+	require.NoError(t, ps.CreateAccount(gov, payment.AccountStateTerminatePrefix, payment.BYN, 0))
+	require.NoError(t, ps.CreateAccount(gov, payment.AccountStateTerminatePrefix, payment.USD, 0))
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("2", "", ""), payment.AccountPrefix, payment.USD, 500))
+
+	usd, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.USD)
+	require.NoError(t, err)
+	require.NoError(t, ps.CloseAccount(usd))
+
+	// the caller claims the blocked USD account is an active BYN account
+	forged := payment.Account{CustomerId: "2", Num: usd.Num, CurrencyCode: payment.BYN, Status: payment.Active}
+
+	assert.ErrorContains(t, ps.Terminate(forged, 100), "is not valid or blocked")
+
+	usd, err = ps.AccountByNumber(usd.Num)
+	require.NoError(t, err)
+	require.NoError(t, ps.ActivateAccount(usd))
+
+	// the amount is terminated in the currency of the stored account
+	require.NoError(t, ps.Terminate(forged, 100))
+
+	stByn, err := ps.GetSpecialAccountIn(payment.AccountStateTerminatePrefix, payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(0), stByn.Balance)
+
+	stUsd, err := ps.GetSpecialAccountIn(payment.AccountStateTerminatePrefix, payment.USD)
+	require.NoError(t, err)
+	assert.Equal(t, float32(100), stUsd.Balance)
+
+	usd, err = ps.AccountByNumber(usd.Num)
+	require.NoError(t, err)
+	assert.Equal(t, float32(400), usd.Balance)
+}
+
+func TestPaymentSystem_NonFiniteAmounts(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	s, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+
+	for _, amount := range []float32{float32(math.NaN()), float32(math.Inf(1)), float32(math.Inf(-1))} {
+		assert.ErrorContains(t, ps.TransferByNumber(sourceNum, destNum, amount), "amount")
+		assert.ErrorContains(t, ps.Emit(amount), "amount")
+		assert.ErrorContains(t, ps.Terminate(s, amount), "amount")
+
+		_, err = ps.PostSuspense(sourceNum, amount, "RECON B1")
+		assert.ErrorContains(t, err, "amount")
+	}
+
+	got, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+	assert.Equal(t, s, got)
+}
+
+func TestPaymentSystem_AccountByNumber(t *testing.T) {
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	d, err := ps.AccountByNumber(destNum)
+	require.NoError(t, err)
+	assert.Equal(t, "2", d.CustomerId)
+
+	_, err = ps.AccountByNumber("BY00NONE00000000000000000000")
+	assert.ErrorIs(t, err, payment.ErrAccountNotFound)
+
+	_, err = ps.GetAccountByNumber(payment.NewCustomer("1", "", ""), destNum)
+	assert.ErrorIs(t, err, payment.ErrAccountNotFound, "the account of another customer is not found")
+
+	// the accounts are given only by their numbers
+	require.NoError(t, ps.Transfer(payment.Account{Num: sourceNum}, payment.Account{Num: destNum}, 100))
+	require.NoError(t, ps.TransferJson([]byte(`{"source": "`+sourceNum+`", "destination": "`+destNum+`", "amount": 50}`)))
+	require.NoError(t, ps.TransferJson([]byte(`{"source": {"num": "`+sourceNum+`"}, "dectination": {"num": "`+destNum+`"}, "amount": 25}`)))
+
+	d, err = ps.AccountByNumber(destNum)
+	require.NoError(t, err)
+	assert.Equal(t, float32(175), d.Balance)
+
+	// the index follows the accounts created and blocked after the restore
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("3", "", ""), payment.AccountPrefix, payment.BYN, 0))
+
+	n, err := ps.FindAccount(payment.NewCustomer("3", "", ""), payment.BYN)
+	require.NoError(t, err)
+	require.NoError(t, ps.TransferJson([]byte(`{"source": "`+sourceNum+`", "destination": "`+n.Num+`", "amount": 5}`)))
+
+	n, err = ps.AccountByNumber(n.Num)
+	require.NoError(t, err)
+	require.NoError(t, ps.CloseAccount(n))
+
+	assert.ErrorContains(t, ps.TransferJson([]byte(`{"source": "`+sourceNum+`", "destination": "`+n.Num+`", "amount": 5}`)), "is not valid or blocked")
+
+	n, err = ps.AccountByNumber(n.Num)
+	require.NoError(t, err)
+	assert.Equal(t, float32(5), n.Balance)
+	assert.Equal(t, payment.Blocked, n.Status)
+}
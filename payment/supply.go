@@ -0,0 +1,335 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultCurrency is the currency of Emit and GetSpecialAccount.
+	DefaultCurrency = BYN
+	// SpecialCustomerId owns the emission and termination accounts.
+	SpecialCustomerId = "0"
+
+	EmissionPrefix = "EM"
+	// SupplyTolerance is the allowed rounding difference of the money supply.
+	SupplyTolerance = 0.005
+)
+
+/*
+This func returns the store key of the special account of the currency.
+The accounts of the default currency keep the keys of the single
+special accounts used before emission per currency.
+*/
+func specialKey(prefix string, currencyCode string) string {
+	if currencyCode == DefaultCurrency {
+		return prefix
+	}
+
+	return prefix + currencyCode
+}
+
+/*
+This func returns the number of the special account of the currency,
+e.g. SE00USDM00000000000000000001. The accounts of the default currency
+keep the numbers AccountStateEmissionNumber and AccountStateTerminateNumber.
+*/
+func SpecialAccountNumber(prefix string, currencyCode string) string {
+	tail := "1"
+	if prefix == AccountStateTerminatePrefix {
+		tail = "2"
+	}
+
+	digits := strings.Repeat("0", 19) + tail
+
+	if currencyCode == DefaultCurrency {
+		return prefix + "00MMMM" + digits
+	}
+
+	return prefix + "00" + (currencyCode + "MMMM")[:4] + digits
+}
+
+/*
+This func returns the store key and the internal account of the bank with the prefix
+in the currency, e.g. the clearing account, and creates the account on first use.
+*/
+func (ps *PaymentSystem) internalAccount(prefix string, currencyCode string) (string, Account) {
+	key := specialKey(prefix, currencyCode)
+
+	if a, ok := ps.store[SpecialCustomerId][key]; ok {
+		return key, a
+	}
+
+	a := NewAccount(SpecialCustomerId, currencyCode, SpecialAccountNumber(prefix, currencyCode), 0)
+	a.CreatedAt = ps.now()
+	a.Version = 1
+
+	if _, ok := ps.store[SpecialCustomerId]; !ok {
+		ps.store[SpecialCustomerId] = make(map[string]Account)
+	}
+
+	ps.store[SpecialCustomerId][key] = a
+	ps.index(key, a)
+
+	return key, a
+}
+
+// EmissionRequest is an emission waiting for the authorization of the quorum.
+type EmissionRequest struct {
+	Id           string    `json:"id"`
+	CurrencyCode string    `json:"currency_code"`
+	Amount       float32   `json:"amount"`
+	Maker        string    `json:"maker"`
+	Approvals    []string  `json:"approvals"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// WithEmissionCeiling limits the money supply (emitted minus terminated) of the currency.
+func WithEmissionCeiling(currencyCode string, amount float32) Option {
+	return func(ps *PaymentSystem) {
+		ps.emissionCeilings[currencyCode] = amount
+	}
+}
+
+/*
+This func makes every emission wait for quorum approvals of the authorizers.
+The maker of the request cannot approve it. Zero quorum disables the authorization.
+*/
+func WithEmissionAuthorizers(quorum int, names ...string) Option {
+	return func(ps *PaymentSystem) {
+		ps.emissionQuorum = quorum
+
+		for _, n := range names {
+			ps.emissionAuthorizers[n] = true
+		}
+	}
+}
+
+// checkCeiling verifies that the emission keeps the money supply under the ceiling.
+func (ps *PaymentSystem) checkCeiling(currencyCode string, amount float32) error {
+	ceiling, ok := ps.emissionCeilings[currencyCode]
+	if !ok {
+		return nil
+	}
+
+	emitted, terminated := ps.emittedAndTerminated(currencyCode)
+
+	if supply := emitted - terminated + float64(amount); supply > float64(ceiling)+SupplyTolerance {
+		return fmt.Errorf("%w: supply of %s would be %.2f, ceiling is %.2f", ErrEmissionCeiling, currencyCode, supply, ceiling)
+	}
+
+	return nil
+}
+
+func (ps *PaymentSystem) emittedAndTerminated(currencyCode string) (float64, float64) {
+	var emitted, terminated float64
+
+	for _, tx := range ps.journal {
+		if tx.Currency != currencyCode {
+			continue
+		}
+
+		switch tx.Type {
+		case TxEmission:
+			emitted += float64(tx.Amount)
+		case TxTermination:
+			terminated += float64(tx.Amount)
+		}
+	}
+
+	return emitted, terminated
+}
+
+/*
+This func registers the emission request of the maker
+and returns its id. The money is emitted once the quorum approves it.
+*/
+func (ps *PaymentSystem) RequestEmission(maker string, currencyCode string, amount float32) (id string, err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpRequestEmission, maker, start, auditInput{"currency": currencyCode, "amount": amount}, id, err)
+	}()
+
+	if !positive(amount) {
+		return "", fmt.Errorf("Emission is imposible: amount <= 0 \n")
+	}
+
+	if err := ps.checkCeiling(currencyCode, amount); err != nil {
+		return "", err
+	}
+
+	if _, err := ps.GetSpecialAccountIn(AccountStateEmissionPrefix, currencyCode); err != nil {
+		return "", err
+	}
+
+	er := &EmissionRequest{
+		Id:           ps.nextID(EmissionPrefix),
+		CurrencyCode: currencyCode,
+		Amount:       amount,
+		Maker:        maker,
+		Status:       TransferPending,
+		CreatedAt:    ps.now(),
+	}
+
+	ps.emissions[er.Id] = er
+
+	ps.logger.Info("emission is waiting for authorization", "emission_id", er.Id, "currency", currencyCode, "amount", amount, "quorum", ps.emissionQuorum)
+
+	return er.Id, nil
+}
+
+func (ps *PaymentSystem) checkAuthorizer(id string, authorizer string) (*EmissionRequest, error) {
+	er, ok := ps.emissions[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEmissionNotFound, id)
+	}
+
+	if er.Status != TransferPending {
+		return nil, fmt.Errorf("%w: %s is %s", ErrEmissionNotPending, id, er.Status)
+	}
+
+	if !ps.emissionAuthorizers[authorizer] {
+		return nil, fmt.Errorf("%w: %s", ErrApproverNotAllowed, authorizer)
+	}
+
+	if authorizer == er.Maker {
+		return nil, fmt.Errorf("%w: %s", ErrApproverIsInitiator, authorizer)
+	}
+
+	if slices.Contains(er.Approvals, authorizer) {
+		return nil, fmt.Errorf("%w: %s", ErrAlreadyApproved, authorizer)
+	}
+
+	return er, nil
+}
+
+/*
+This func adds the approval of the authorizer to the emission request
+and emits the money when the quorum is reached.
+*/
+func (ps *PaymentSystem) ApproveEmission(id string, authorizer string) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpApproveEmission, authorizer, start, auditInput{"emission_id": id}, "", err)
+	}()
+
+	er, err := ps.checkAuthorizer(id, authorizer)
+	if err != nil {
+		return err
+	}
+
+	if len(er.Approvals)+1 < ps.emissionQuorum {
+		er.Approvals = append(er.Approvals, authorizer)
+
+		return nil
+	}
+
+	if err := ps.emit(er.CurrencyCode, er.Amount); err != nil {
+		return err
+	}
+
+	er.Approvals = append(er.Approvals, authorizer)
+	er.Status = TransferApproved
+
+	return nil
+}
+
+// RejectEmission rejects the emission request, nothing is emitted.
+func (ps *PaymentSystem) RejectEmission(id string, authorizer string) (err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpRejectEmission, authorizer, start, auditInput{"emission_id": id}, "", err)
+	}()
+
+	er, err := ps.checkAuthorizer(id, authorizer)
+	if err != nil {
+		return err
+	}
+
+	er.Status = TransferRejected
+
+	return nil
+}
+
+// EmissionRequests returns the emission requests waiting for authorization ordered by id.
+func (ps *PaymentSystem) EmissionRequests() []EmissionRequest {
+	res := make([]EmissionRequest, 0, len(ps.emissions))
+
+	for _, er := range ps.emissions {
+		if er.Status == TransferPending {
+			res = append(res, *er)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+
+	return res
+}
+
+/*
+This struct is the money supply of one currency. Emitted minus Terminated
+is the supply, which must be equal to Circulation: the money on all accounts
+except the termination account. A difference means money was created or
+destroyed outside of Emit and Terminate, e.g. by opening balances of accounts.
+*/
+type MoneySupply struct {
+	CurrencyCode string  `json:"currency_code"`
+	Emitted      float64 `json:"emitted"`
+	Terminated   float64 `json:"terminated"`
+	Supply       float64 `json:"supply"`
+	Circulation  float64 `json:"circulation"`
+	Difference   float64 `json:"difference"`
+	Ceiling      float32 `json:"ceiling,omitempty"`
+	Balanced     bool    `json:"balanced"`
+}
+
+// MoneySupply returns the money supply report of every currency ordered by currency.
+func (ps *PaymentSystem) MoneySupply() []MoneySupply {
+	circulation := make(map[string]float64)
+
+	for _, list := range ps.store {
+		for _, a := range list {
+			if a.CustomerId == SpecialCustomerId && a.Num == SpecialAccountNumber(AccountStateTerminatePrefix, a.CurrencyCode) {
+				continue
+			}
+
+			circulation[a.CurrencyCode] += float64(a.Balance)
+		}
+	}
+
+	for _, tx := range ps.journal {
+		if _, ok := circulation[tx.Currency]; !ok {
+			circulation[tx.Currency] = 0
+		}
+	}
+
+	res := make([]MoneySupply, 0, len(circulation))
+
+	for _, cur := range sortedKeys(circulation) {
+		emitted, terminated := ps.emittedAndTerminated(cur)
+
+		ms := MoneySupply{
+			CurrencyCode: cur,
+			Emitted:      emitted,
+			Terminated:   terminated,
+			Supply:       emitted - terminated,
+			Circulation:  circulation[cur],
+			Ceiling:      ps.emissionCeilings[cur],
+		}
+
+		ms.Difference = ms.Circulation - ms.Supply
+		ms.Balanced = math.Abs(ms.Difference) <= SupplyTolerance
+
+		res = append(res, ms)
+	}
+
+	return res
+}
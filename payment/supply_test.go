@@ -0,0 +1,136 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSupplySystem(t *testing.T, opts ...payment.Option) *payment.PaymentSystem {
+	t.Helper()
+
+	ps := payment.NewPaymentSystem(append([]payment.Option{payment.WithProcessingDelay(0)}, opts...)...)
+	gov := payment.NewCustomer(payment.SpecialCustomerId, "GOVERNMENT", "")
+
+	for _, cur := range []string{payment.BYN, payment.USD} {
+		require.NoError(t, ps.CreateAccount(gov, payment.AccountStateEmissionPrefix, cur, 0))
+		require.NoError(t, ps.CreateAccount(gov, payment.AccountStateTerminatePrefix, cur, 0))
+	}
+
+	return ps
+}
+
+func TestPaymentSystem_EmitIn(t *testing.T) {
+	ps := newSupplySystem(t, payment.WithEmissionCeiling(payment.USD, 1000))
+
+	require.NoError(t, ps.Emit(500))
+	require.NoError(t, ps.EmitIn(payment.USD, 700))
+
+	byn, err := ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, payment.AccountStateEmissionNumber, byn.Num)
+	assert.Equal(t, float32(500), byn.Balance)
+
+	usd, err := ps.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, payment.USD)
+	require.NoError(t, err)
+	assert.Equal(t, "SE00USDM00000000000000000001", usd.Num)
+	assert.Equal(t, float32(700), usd.Balance)
+
+	assert.ErrorIs(t, ps.EmitIn(payment.USD, 301), payment.ErrEmissionCeiling)
+	require.NoError(t, ps.EmitIn(payment.USD, 300))
+
+	_, err = ps.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, payment.EUR)
+	assert.Error(t, err)
+
+	// termination goes to the termination account of the currency and frees the ceiling
+	usd, err = ps.GetSpecialAccountIn(payment.AccountStateEmissionPrefix, payment.USD)
+	require.NoError(t, err)
+	require.NoError(t, ps.Terminate(usd, 200))
+
+	st, err := ps.GetSpecialAccountIn(payment.AccountStateTerminatePrefix, payment.USD)
+	require.NoError(t, err)
+	assert.Equal(t, float32(200), st.Balance)
+
+	require.NoError(t, ps.EmitIn(payment.USD, 200))
+}
+
+func TestPaymentSystem_EmissionAuthorization(t *testing.T) {
+	ps := newSupplySystem(t, payment.WithEmissionAuthorizers(2, "maker", "alice", "bob", "carol"))
+
+	assert.ErrorIs(t, ps.Emit(100), payment.ErrEmissionNotAuthorized)
+
+	id, err := ps.RequestEmission("maker", payment.BYN, 100)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc       string
+		authorizer string
+		err        error
+	}{
+		{desc: "maker", authorizer: "maker", err: payment.ErrApproverIsInitiator},
+		{desc: "unknown", authorizer: "mallory", err: payment.ErrApproverNotAllowed},
+		{desc: "first approval", authorizer: "alice"},
+		{desc: "same approver twice", authorizer: "alice", err: payment.ErrAlreadyApproved},
+		{desc: "quorum", authorizer: "bob"},
+		{desc: "after quorum", authorizer: "carol", err: payment.ErrEmissionNotPending},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			err := ps.ApproveEmission(id, tt.authorizer)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	se, err := ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+	assert.Equal(t, float32(100), se.Balance)
+	assert.Empty(t, ps.EmissionRequests())
+
+	id, err = ps.RequestEmission("maker", payment.BYN, 50)
+	require.NoError(t, err)
+	require.NoError(t, ps.RejectEmission(id, "carol"))
+	assert.ErrorIs(t, ps.ApproveEmission(id, "alice"), payment.ErrEmissionNotPending)
+	assert.ErrorIs(t, ps.ApproveEmission("EM404", "alice"), payment.ErrEmissionNotFound)
+}
+
+func TestPaymentSystem_MoneySupply(t *testing.T) {
+	ps := newSupplySystem(t, payment.WithEmissionCeiling(payment.BYN, 5000))
+
+	require.NoError(t, ps.Emit(1000))
+
+	se, err := ps.GetSpecialAccount(payment.AccountStateEmissionPrefix)
+	require.NoError(t, err)
+
+	c := payment.NewCustomer("1", "Customer One", payment.AccountPrefix)
+	require.NoError(t, ps.CreateAccount(c, payment.AccountPrefix, payment.BYN, 0))
+
+	a, err := ps.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Transfer(se, a, 400))
+
+	a, err = ps.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+	require.NoError(t, ps.Terminate(a, 150))
+
+	want := []payment.MoneySupply{
+		{CurrencyCode: payment.BYN, Emitted: 1000, Terminated: 150, Supply: 850, Circulation: 850, Ceiling: 5000, Balanced: true},
+		{CurrencyCode: payment.USD, Balanced: true},
+	}
+	assert.Equal(t, want, ps.MoneySupply())
+
+	// an opening balance creates money outside of emission
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("2", "Customer Two", payment.AccountPrefix), payment.AccountPrefix, payment.BYN, 25))
+
+	byn := ps.MoneySupply()[0]
+	assert.False(t, byn.Balanced)
+	assert.InDelta(t, 25, byn.Difference, payment.SupplyTolerance)
+}
@@ -0,0 +1,63 @@
+package payment
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// TxSuspense parks a reconciliation break on the suspense account.
+	TxSuspense = "suspense"
+
+	// SuspensePrefix is the key prefix of the suspense account of a currency.
+	SuspensePrefix = "SU"
+)
+
+/*
+This func books the signed amount on the account against the suspense
+account of its currency: a positive amount credits the account, a negative
+one debits it. It is used to park the breaks found by the reconciliation
+until they are investigated.
+*/
+func (ps *PaymentSystem) PostSuspense(accountNum string, amount float32, reference string) (tx Transaction, err error) {
+	start := time.Now()
+
+	defer func() {
+		ps.finish(OpPostSuspense, "", start, auditInput{"account": accountNum, "amount": amount, "reference": reference}, tx.Id, err)
+	}()
+
+	a, ok := ps.findByNumber(accountNum)
+	if !ok {
+		return Transaction{}, fmt.Errorf("Suspense posting is imposible: %w: %s", ErrAccountNotFound, accountNum)
+	}
+
+	if !accountAvailable(a) {
+		return Transaction{}, fmt.Errorf("Suspense posting is imposible: account %s is not valid or blocked", a.Num)
+	}
+
+	if !positive(amount) && !positive(-amount) {
+		return Transaction{}, fmt.Errorf("Suspense posting is imposible: amount must be a finite non-zero number \n")
+	}
+
+	if amount < 0 && a.Balance-a.Reserved < -amount {
+		return Transaction{}, fmt.Errorf("Suspense posting is imposible: insufficient funds on account %s: %w", a.Num, ErrInsufficientFunds)
+	}
+
+	credit := amount > 0
+	if !credit {
+		amount = -amount
+	}
+
+	if err := ps.postInternal(TxSuspense, SuspensePrefix, a, amount, credit, reference); err != nil {
+		return Transaction{}, err
+	}
+
+	return ps.journal[len(ps.journal)-1], nil
+}
+
+// SuspenseAccount returns the suspense account of the currency.
+func (ps *PaymentSystem) SuspenseAccount(currencyCode string) Account {
+	_, a := ps.internalAccount(SuspensePrefix, currencyCode)
+
+	return a
+}
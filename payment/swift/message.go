@@ -0,0 +1,230 @@
+// Package swift parses and generates the SWIFT MT messages exchanged with correspondent banks:
+// MT103 customer credit transfers and MT940 statements.
+package swift
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TypeMT103 = "103"
+	TypeMT940 = "940"
+
+	// dateLayout is the YYMMDD date of the fields.
+	dateLayout = "060102"
+)
+
+// Field is a tag of block 4 with its value, lines of the value are separated by "\n".
+type Field struct {
+	Tag   string
+	Value string
+}
+
+/*
+This struct is an MT message: the sender of the basic header (block 1),
+the message type and the receiver of the application header (block 2)
+and the fields of the text block (block 4) in their order.
+*/
+type Message struct {
+	Sender   string
+	Type     string
+	Receiver string
+	Fields   []Field
+}
+
+// Get returns the value of the first field with the tag.
+func (m *Message) Get(tag string) (string, bool) {
+	for _, f := range m.Fields {
+		if f.Tag == tag {
+			return f.Value, true
+		}
+	}
+
+	return "", false
+}
+
+// Add appends the field to the text block.
+func (m *Message) Add(tag string, value string) {
+	m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+}
+
+// String formats the message with blocks 1, 2 and 4.
+func (m *Message) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "{1:F01%s0000000000}{2:I%s%sN}{4:\r\n", pad(m.Sender), m.Type, pad(m.Receiver))
+
+	for _, f := range m.Fields {
+		fmt.Fprintf(&b, ":%s:%s\r\n", f.Tag, strings.ReplaceAll(f.Value, "\n", "\r\n"))
+	}
+
+	b.WriteString("-}")
+
+	return b.String()
+}
+
+// pad makes the BIC the 12 characters logical terminal address with the terminal code A.
+func pad(bic string) string {
+	switch len(bic) {
+	case 8:
+		return bic + "AXXX"
+	case 11:
+		return bic[:8] + "A" + bic[8:]
+	}
+
+	return bic
+}
+
+// bic returns the BIC of the logical terminal address.
+func bic(lt string) string {
+	if len(lt) == 12 {
+		return lt[:8] + lt[9:]
+	}
+
+	return lt
+}
+
+// Parse reads one message.
+func Parse(r io.Reader) (*Message, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseString(string(data))
+}
+
+/*
+This func parses the message. Blocks 1 and 2 are optional,
+so a bare text block starting with the first field is accepted too.
+*/
+func ParseString(s string) (*Message, error) {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\r\n", "\n")
+	m := &Message{}
+
+	text := s
+
+	if strings.HasPrefix(s, "{") {
+		for _, block := range []string{"{1:", "{2:"} {
+			i := strings.Index(s, block)
+			if i < 0 {
+				continue
+			}
+
+			end := strings.Index(s[i:], "}")
+			if end < 0 {
+				return nil, fmt.Errorf("swift: block %s is not closed", block)
+			}
+
+			header := s[i+len(block) : i+end]
+
+			switch {
+			case block == "{1:" && len(header) >= 15:
+				m.Sender = bic(header[3:15])
+			case block == "{2:" && len(header) >= 16 && header[0] == 'I':
+				m.Type = header[1:4]
+				m.Receiver = bic(header[4:16])
+			case block == "{2:" && len(header) >= 4 && header[0] == 'O':
+				m.Type = header[1:4]
+			}
+		}
+
+		i := strings.Index(s, "{4:")
+		if i < 0 {
+			return nil, fmt.Errorf("swift: message without text block")
+		}
+
+		end := strings.LastIndex(s, "-}")
+		if end < i {
+			return nil, fmt.Errorf("swift: text block is not closed")
+		}
+
+		text = s[i+3 : end]
+	}
+
+	for _, line := range strings.Split(strings.Trim(text, "\n"), "\n") {
+		if tag, value, ok := field(line); ok {
+			m.Fields = append(m.Fields, Field{Tag: tag, Value: value})
+
+			continue
+		}
+
+		if len(m.Fields) == 0 {
+			return nil, fmt.Errorf("swift: text block must start with a field, got %q", line)
+		}
+
+		last := &m.Fields[len(m.Fields)-1]
+		last.Value += "\n" + line
+	}
+
+	if len(m.Fields) == 0 {
+		return nil, fmt.Errorf("swift: message has no fields")
+	}
+
+	return m, nil
+}
+
+// field splits the line ":TAG:value" to the tag and the value.
+func field(line string) (string, string, bool) {
+	if !strings.HasPrefix(line, ":") {
+		return "", "", false
+	}
+
+	end := strings.Index(line[1:], ":")
+	if end < 2 || end > 3 {
+		return "", "", false
+	}
+
+	return line[1 : end+1], line[end+2:], true
+}
+
+// formatAmount writes the amount with the comma as the decimal mark, e.g. 1000,50.
+func formatAmount(v float64) string {
+	return strings.Replace(strconv.FormatFloat(v, 'f', 2, 64), ".", ",", 1)
+}
+
+func parseAmount(s string) (float32, error) {
+	v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 32)
+	if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("swift: invalid amount %q", s)
+	}
+
+	return float32(v), nil
+}
+
+/*
+This func parses the date, currency and amount of the fields 32A, 60F and 62F,
+e.g. 240131BYN1000,50. The debit/credit mark of the balances is parsed by the caller.
+*/
+func parseDateAmount(s string) (time.Time, string, float32, error) {
+	if len(s) < 10 {
+		return time.Time{}, "", 0, fmt.Errorf("swift: invalid date and amount %q", s)
+	}
+
+	date, err := time.Parse(dateLayout, s[:6])
+	if err != nil {
+		return time.Time{}, "", 0, fmt.Errorf("swift: invalid date %q", s[:6])
+	}
+
+	amount, err := parseAmount(s[9:])
+	if err != nil {
+		return time.Time{}, "", 0, err
+	}
+
+	return date, s[6:9], amount, nil
+}
+
+// partyLines splits the party field /ACCOUNT\nNAME to the account and the name.
+func partyLines(v string) (string, string) {
+	account, name, _ := strings.Cut(v, "\n")
+	if !strings.HasPrefix(account, "/") {
+		return "", v
+	}
+
+	return strings.TrimPrefix(account, "/"), name
+}
@@ -0,0 +1,156 @@
+package swift
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+// Charges of field 71A.
+const (
+	ChargesShared      = "SHA"
+	ChargesBeneficiary = "BEN"
+	ChargesOurs        = "OUR"
+)
+
+// MT103 is a single customer credit transfer.
+type MT103 struct {
+	Sender             string    `json:"sender"`
+	Receiver           string    `json:"receiver"`
+	Reference          string    `json:"reference"`
+	ValueDate          time.Time `json:"value_date"`
+	Currency           string    `json:"currency"`
+	Amount             float32   `json:"amount"`
+	OrderingAccount    string    `json:"ordering_account"`
+	OrderingName       string    `json:"ordering_name"`
+	BeneficiaryAccount string    `json:"beneficiary_account"`
+	BeneficiaryName    string    `json:"beneficiary_name"`
+	Remittance         string    `json:"remittance,omitempty"`
+	Charges            string    `json:"charges"`
+}
+
+/*
+This func reads the MT103 from the message. The fields 20, 32A, 50K (or 50A/50F)
+and 59 are required, the bank operation code 23B must be CRED when present.
+*/
+func ParseMT103(m *Message) (MT103, error) {
+	if m.Type != "" && m.Type != TypeMT103 {
+		return MT103{}, fmt.Errorf("swift: message type is MT%s, not MT103", m.Type)
+	}
+
+	mt := MT103{Sender: m.Sender, Receiver: m.Receiver, Charges: ChargesShared}
+
+	var ok bool
+
+	if mt.Reference, ok = m.Get("20"); !ok || mt.Reference == "" {
+		return MT103{}, fmt.Errorf("swift: MT103 without reference (20)")
+	}
+
+	if op, ok := m.Get("23B"); ok && op != "CRED" {
+		return MT103{}, fmt.Errorf("swift: MT103 %s: unsupported bank operation code %s", mt.Reference, op)
+	}
+
+	v, ok := m.Get("32A")
+	if !ok {
+		return MT103{}, fmt.Errorf("swift: MT103 %s without value date and amount (32A)", mt.Reference)
+	}
+
+	var err error
+
+	if mt.ValueDate, mt.Currency, mt.Amount, err = parseDateAmount(v); err != nil {
+		return MT103{}, err
+	}
+
+	for _, tag := range []string{"50K", "50A", "50F"} {
+		if v, ok := m.Get(tag); ok {
+			mt.OrderingAccount, mt.OrderingName = partyLines(v)
+
+			break
+		}
+	}
+
+	if mt.OrderingAccount == "" {
+		return MT103{}, fmt.Errorf("swift: MT103 %s without ordering customer account (50K)", mt.Reference)
+	}
+
+	v, ok = m.Get("59")
+	if !ok {
+		v, ok = m.Get("59A")
+	}
+
+	if mt.BeneficiaryAccount, mt.BeneficiaryName = partyLines(v); !ok || mt.BeneficiaryAccount == "" {
+		return MT103{}, fmt.Errorf("swift: MT103 %s without beneficiary account (59)", mt.Reference)
+	}
+
+	mt.Remittance, _ = m.Get("70")
+
+	if v, ok := m.Get("71A"); ok {
+		mt.Charges = v
+	}
+
+	return mt, nil
+}
+
+// Message returns the MT103 as a message.
+func (mt MT103) Message() *Message {
+	m := &Message{Sender: mt.Sender, Type: TypeMT103, Receiver: mt.Receiver}
+
+	m.Add("20", mt.Reference)
+	m.Add("23B", "CRED")
+	m.Add("32A", mt.ValueDate.Format(dateLayout)+mt.Currency+formatAmount(float64(mt.Amount)))
+	m.Add("50K", party(mt.OrderingAccount, mt.OrderingName))
+	m.Add("59", party(mt.BeneficiaryAccount, mt.BeneficiaryName))
+
+	if mt.Remittance != "" {
+		m.Add("70", mt.Remittance)
+	}
+
+	charges := mt.Charges
+	if charges == "" {
+		charges = ChargesShared
+	}
+
+	m.Add("71A", charges)
+
+	return m
+}
+
+func party(account string, name string) string {
+	if name == "" {
+		return "/" + account
+	}
+
+	return "/" + account + "\n" + name
+}
+
+/*
+This func books the inbound MT103 as a transfer to the beneficiary account.
+The money comes from the settlement account, the vostro account of
+the correspondent bank with us; the ordering customer account belongs to
+another bank and is never debited. The reference of field 20 is booked
+once, a repeated message fails with payment.ErrDuplicateReference.
+The caller holds the lock of the store.
+*/
+func Receive(ps payment.Store, mt MT103, settlement string) error {
+	if settlement == "" {
+		return fmt.Errorf("swift: MT103 %s: without settlement account", mt.Reference)
+	}
+
+	for _, num := range []string{settlement, mt.BeneficiaryAccount} {
+		a, err := ps.AccountByNumber(num)
+		if err != nil {
+			return fmt.Errorf("swift: MT103 %s: %w", mt.Reference, err)
+		}
+
+		if a.CurrencyCode != mt.Currency {
+			return fmt.Errorf("swift: MT103 %s: account %s is in %s, the transfer is in %s", mt.Reference, num, a.CurrencyCode, mt.Currency)
+		}
+	}
+
+	if err := ps.ReceiveCorrespondent(settlement, mt.BeneficiaryAccount, mt.Currency, mt.Amount, mt.Reference); err != nil {
+		return fmt.Errorf("swift: MT103 %s: %w", mt.Reference, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package swift
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+// Debit/credit marks of balances and statement lines.
+const (
+	Credit = "C"
+	Debit  = "D"
+)
+
+// NoReference is the customer reference of a line without one.
+const NoReference = "NONREF"
+
+// Balance is a booked balance of field 60F or 62F.
+type Balance struct {
+	Mark     string    `json:"mark"`
+	Date     time.Time `json:"date"`
+	Currency string    `json:"currency"`
+	Amount   float32   `json:"amount"`
+}
+
+// Signed returns the balance negative for a debit balance.
+func (b Balance) Signed() float32 {
+	if b.Mark == Debit {
+		return -b.Amount
+	}
+
+	return b.Amount
+}
+
+// StatementLine is a movement of field 61 with the information of field 86.
+type StatementLine struct {
+	ValueDate     time.Time `json:"value_date"`
+	Mark          string    `json:"mark"`
+	Amount        float32   `json:"amount"`
+	TypeCode      string    `json:"type_code"`
+	Reference     string    `json:"reference"`
+	BankReference string    `json:"bank_reference,omitempty"`
+	Info          string    `json:"info,omitempty"`
+}
+
+// MT940 is the customer statement message.
+type MT940 struct {
+	Sender          string          `json:"sender"`
+	Receiver        string          `json:"receiver"`
+	Reference       string          `json:"reference"`
+	Account         string          `json:"account"`
+	StatementNumber int             `json:"statement_number"`
+	Opening         Balance         `json:"opening"`
+	Lines           []StatementLine `json:"lines"`
+	Closing         Balance         `json:"closing"`
+}
+
+/*
+This func builds the MT940 from the statement of the account.
+Open bounds of the period are replaced by the creation time
+of the account and now, like in the camt.053 statement.
+*/
+func NewMT940(reference string, number int, st payment.Statement, now time.Time) MT940 {
+	from, to := st.From, st.To
+	if from.IsZero() {
+		from = st.Account.CreatedAt
+	}
+
+	if to.IsZero() {
+		to = now
+	}
+
+	cur := st.Account.CurrencyCode
+
+	mt := MT940{
+		Reference:       reference,
+		Account:         st.Account.Num,
+		StatementNumber: number,
+		Opening:         newBalance(st.Opening, from, cur),
+		Closing:         newBalance(st.Closing, to, cur),
+	}
+
+	for _, l := range st.Lines {
+		sl := StatementLine{
+			ValueDate:     l.ValueDate,
+			Mark:          Credit,
+			Amount:        l.Credit,
+			TypeCode:      "MSC",
+			Reference:     l.Reference,
+			BankReference: l.Id,
+			Info:          l.Type + " " + l.Source + " " + l.Dest,
+		}
+
+		if l.Debit > 0 {
+			sl.Mark = Debit
+			sl.Amount = l.Debit
+		}
+
+		if l.Type == payment.TxTransfer {
+			sl.TypeCode = "TRF"
+		}
+
+		if sl.Reference == "" {
+			sl.Reference = NoReference
+		}
+
+		mt.Lines = append(mt.Lines, sl)
+	}
+
+	return mt
+}
+
+func newBalance(v float32, date time.Time, currencyCode string) Balance {
+	b := Balance{Mark: Credit, Date: date, Currency: currencyCode, Amount: float32(math.Abs(float64(v)))}
+	if v < 0 {
+		b.Mark = Debit
+	}
+
+	return b
+}
+
+// Message returns the MT940 as a message.
+func (mt MT940) Message() *Message {
+	m := &Message{Sender: mt.Sender, Type: TypeMT940, Receiver: mt.Receiver}
+
+	m.Add("20", mt.Reference)
+	m.Add("25", mt.Account)
+	m.Add("28C", fmt.Sprintf("%d/1", mt.StatementNumber))
+	m.Add("60F", formatBalance(mt.Opening))
+
+	for _, l := range mt.Lines {
+		v := l.ValueDate.Format(dateLayout) + l.Mark + formatAmount(float64(l.Amount)) + "N" + l.TypeCode + l.Reference
+		if l.BankReference != "" {
+			v += "//" + l.BankReference
+		}
+
+		m.Add("61", v)
+
+		if l.Info != "" {
+			m.Add("86", l.Info)
+		}
+	}
+
+	m.Add("62F", formatBalance(mt.Closing))
+
+	return m
+}
+
+func formatBalance(b Balance) string {
+	return b.Mark + b.Date.Format(dateLayout) + b.Currency + formatAmount(float64(b.Amount))
+}
+
+// ParseMT940 reads the MT940 from the message.
+func ParseMT940(m *Message) (MT940, error) {
+	if m.Type != "" && m.Type != TypeMT940 {
+		return MT940{}, fmt.Errorf("swift: message type is MT%s, not MT940", m.Type)
+	}
+
+	mt := MT940{Sender: m.Sender, Receiver: m.Receiver}
+
+	for _, f := range m.Fields {
+		var err error
+
+		switch f.Tag {
+		case "20":
+			mt.Reference = f.Value
+		case "25":
+			mt.Account = f.Value
+		case "28C":
+			n, _, _ := strings.Cut(f.Value, "/")
+			mt.StatementNumber, err = strconv.Atoi(n)
+		case "60F", "60M":
+			mt.Opening, err = parseBalance(f.Value)
+		case "62F", "62M":
+			mt.Closing, err = parseBalance(f.Value)
+		case "61":
+			var l StatementLine
+
+			l, err = parseLine(f.Value)
+			mt.Lines = append(mt.Lines, l)
+		case "86":
+			if len(mt.Lines) > 0 {
+				mt.Lines[len(mt.Lines)-1].Info = f.Value
+			}
+		}
+
+		if err != nil {
+			return MT940{}, fmt.Errorf("swift: MT940 field %s: %w", f.Tag, err)
+		}
+	}
+
+	if mt.Reference == "" || mt.Account == "" {
+		return MT940{}, fmt.Errorf("swift: MT940 without reference (20) or account (25)")
+	}
+
+	return mt, nil
+}
+
+func parseBalance(v string) (Balance, error) {
+	if len(v) < 1 || (v[0] != 'C' && v[0] != 'D') {
+		return Balance{}, fmt.Errorf("invalid balance %q", v)
+	}
+
+	date, cur, amount, err := parseDateAmount(v[1:])
+	if err != nil {
+		return Balance{}, err
+	}
+
+	return Balance{Mark: v[:1], Date: date, Currency: cur, Amount: amount}, nil
+}
+
+/*
+This func parses the statement line YYMMDD[MMDD]C|D<amount>N<type><reference>[//<bank reference>].
+The reversal marks RC and RD are not supported.
+*/
+func parseLine(v string) (StatementLine, error) {
+	if len(v) < 12 {
+		return StatementLine{}, fmt.Errorf("invalid statement line %q", v)
+	}
+
+	date, err := time.Parse(dateLayout, v[:6])
+	if err != nil {
+		return StatementLine{}, fmt.Errorf("invalid value date %q", v[:6])
+	}
+
+	rest := v[6:]
+
+	// optional entry date
+	if len(rest) > 4 && rest[0] >= '0' && rest[0] <= '9' {
+		rest = rest[4:]
+	}
+
+	l := StatementLine{ValueDate: date, Mark: rest[:1]}
+	if l.Mark != Credit && l.Mark != Debit {
+		return StatementLine{}, fmt.Errorf("invalid debit/credit mark in %q", v)
+	}
+
+	amount, rest, ok := strings.Cut(rest[1:], "N")
+	if !ok || len(rest) < 3 {
+		return StatementLine{}, fmt.Errorf("invalid transaction type in %q", v)
+	}
+
+	if l.Amount, err = parseAmount(amount); err != nil {
+		return StatementLine{}, err
+	}
+
+	l.TypeCode = rest[:3]
+	l.Reference, l.BankReference, _ = strings.Cut(rest[3:], "//")
+
+	return l, nil
+}
@@ -0,0 +1,208 @@
+package swift_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/swift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	sourceNum = "BY11ABCD00000000000000000001"
+	destNum   = "BY22ABCD00000000000000000002"
+)
+
+const store = `{
+	"1": {"K1": {"customer_id": "1", "num": "` + sourceNum + `", "currency_code": "BYN", "status": "active", "balance": 1000}},
+	"2": {"K2": {"customer_id": "2", "num": "` + destNum + `", "currency_code": "BYN", "status": "active", "balance": 0}}
+}`
+
+const inbound = "{1:F01CORRBEBBAXXX0000000000}{2:I103ABCDBY2XAXXXN}{4:\r\n" +
+	":20:CORR-0001\r\n" +
+	":23B:CRED\r\n" +
+	":32A:240131BYN250,75\r\n" +
+	":50K:/" + sourceNum + "\r\n" +
+	"Customer One\r\n" +
+	"Minsk\r\n" +
+	":59:/" + destNum + "\r\n" +
+	"Customer Two\r\n" +
+	":70:INVOICE 42\r\n" +
+	":71A:OUR\r\n" +
+	"-}"
+
+func newSystem(t *testing.T, now *time.Time) *payment.PaymentSystem {
+	t.Helper()
+
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0), payment.WithBankCode("ABCD"), payment.WithClock(func() time.Time { return *now }))
+	require.NoError(t, ps.Restore([]byte(store)))
+
+	return ps
+}
+
+func TestParseMT103(t *testing.T) {
+	m, err := swift.ParseString(inbound)
+	require.NoError(t, err)
+	assert.Equal(t, "CORRBEBBXXX", m.Sender)
+	assert.Equal(t, "ABCDBY2XXXX", m.Receiver)
+
+	mt, err := swift.ParseMT103(m)
+	require.NoError(t, err)
+
+	want := swift.MT103{
+		Sender:             "CORRBEBBXXX",
+		Receiver:           "ABCDBY2XXXX",
+		Reference:          "CORR-0001",
+		ValueDate:          time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Currency:           payment.BYN,
+		Amount:             250.75,
+		OrderingAccount:    sourceNum,
+		OrderingName:       "Customer One\nMinsk",
+		BeneficiaryAccount: destNum,
+		BeneficiaryName:    "Customer Two",
+		Remittance:         "INVOICE 42",
+		Charges:            swift.ChargesOurs,
+	}
+	assert.Equal(t, want, mt)
+
+	// the generated message is parsed back to the same transfer
+	again, err := swift.ParseString(mt.Message().String())
+	require.NoError(t, err)
+
+	mt2, err := swift.ParseMT103(again)
+	require.NoError(t, err)
+	assert.Equal(t, want, mt2)
+	assert.Equal(t, inbound, mt.Message().String())
+}
+
+func TestParseMT103_Errors(t *testing.T) {
+	testCases := []struct {
+		desc string
+		text string
+	}{
+		{desc: "no fields", text: "{1:F01CORRBEBBAXXX0000000000}{4:\r\n-}"},
+		{desc: "text before fields", text: "hello\n:20:X"},
+		{desc: "no reference", text: ":32A:240131BYN1,00\n:50K:/A\n:59:/B"},
+		{desc: "wrong operation", text: ":20:X\n:23B:SPAY\n:32A:240131BYN1,00\n:50K:/A\n:59:/B"},
+		{desc: "bad amount", text: ":20:X\n:32A:240131BYNONE\n:50K:/A\n:59:/B"},
+		{desc: "NaN amount", text: ":20:X\n:32A:240131BYNNaN\n:50K:/A\n:59:/B"},
+		{desc: "infinite amount", text: ":20:X\n:32A:240131BYNInf\n:50K:/A\n:59:/B"},
+		{desc: "no beneficiary account", text: ":20:X\n:32A:240131BYN1,00\n:50K:/A\n:59:NAME ONLY"},
+		{desc: "other type", text: "{2:I940ABCDBY2XAXXXN}{4:\n:20:X\n-}"},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			m, err := swift.ParseString(tt.text)
+			if err == nil {
+				_, err = swift.ParseMT103(m)
+			}
+
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReceive(t *testing.T) {
+	now := time.Date(2024, 1, 31, 10, 0, 0, 0, time.UTC)
+	ps := newSystem(t, &now)
+
+	mt, err := swift.ParseMT103(&swift.Message{Fields: []swift.Field{
+		{Tag: "20", Value: "R1"},
+		{Tag: "32A", Value: "240131BYN100,00"},
+		{Tag: "50K", Value: "/BY99ZZZZ00000000000000000099\nAbroad"},
+		{Tag: "59", Value: "/" + destNum},
+	}})
+	require.NoError(t, err)
+
+	vostro, err := ps.OpenVostro("CORR", payment.BYN, 500)
+	require.NoError(t, err)
+
+	assert.ErrorContains(t, swift.Receive(ps, mt, ""), "without settlement account", "the ordering account is never debited")
+	assert.ErrorContains(t, swift.Receive(ps, mt, sourceNum), "is not a vostro account", "a customer account does not settle")
+	require.NoError(t, swift.Receive(ps, mt, vostro.Num), "the vostro account pays")
+	assert.ErrorIs(t, swift.Receive(ps, mt, vostro.Num), payment.ErrDuplicateReference)
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(100), d.Balance)
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1000), s.Balance)
+
+	vostro, err = ps.AccountByNumber(vostro.Num)
+	require.NoError(t, err)
+	assert.Equal(t, float32(400), vostro.Balance)
+
+	mt.Reference = "R2"
+	mt.Amount = 1000
+	assert.ErrorIs(t, swift.Receive(ps, mt, vostro.Num), payment.ErrInsufficientFunds)
+
+	mt.Currency = payment.USD
+	assert.ErrorContains(t, swift.Receive(ps, mt, vostro.Num), "the transfer is in USD")
+}
+
+func TestMT940(t *testing.T) {
+	now := time.Date(2024, 1, 30, 10, 0, 0, 0, time.UTC)
+	ps := newSystem(t, &now)
+
+	require.NoError(t, ps.TransferByNumber(sourceNum, destNum, 100))
+
+	_, err := ps.CloseDay()
+	require.NoError(t, err)
+
+	now = now.AddDate(0, 0, 1)
+	require.NoError(t, ps.TransferByNumber(destNum, sourceNum, 40.5))
+
+	st, err := ps.Statement(payment.NewCustomer("1", "", ""), sourceNum, time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	mt := swift.NewMT940("STMT-1", 7, st, now)
+	text := mt.Message().String()
+
+	assert.Contains(t, text, "{2:I940")
+	assert.Contains(t, text, ":25:"+sourceNum+"\r\n")
+	assert.Contains(t, text, ":28C:7/1\r\n")
+	assert.Contains(t, text, ":60F:C240130BYN1000,00\r\n")
+	assert.Contains(t, text, ":61:240130D100,00NTRFNONREF//")
+	assert.Contains(t, text, ":61:240131C40,50NTRFNONREF//")
+	assert.Contains(t, text, ":62F:C240201BYN940,50\r\n")
+
+	m, err := swift.ParseString(text)
+	require.NoError(t, err)
+
+	parsed, err := swift.ParseMT940(m)
+	require.NoError(t, err)
+	assert.Equal(t, "STMT-1", parsed.Reference)
+	assert.Equal(t, 7, parsed.StatementNumber)
+	assert.Equal(t, float32(1000), parsed.Opening.Signed())
+	assert.Equal(t, float32(940.5), parsed.Closing.Signed())
+	require.Len(t, parsed.Lines, 2)
+	assert.Equal(t, swift.Debit, parsed.Lines[0].Mark)
+	assert.Equal(t, st.Lines[0].Id, parsed.Lines[0].BankReference)
+	assert.True(t, strings.HasPrefix(parsed.Lines[1].Info, payment.TxTransfer))
+
+	// the balance is the opening balance plus the lines
+	balance := parsed.Opening.Signed()
+	for _, l := range parsed.Lines {
+		if l.Mark == swift.Debit {
+			balance -= l.Amount
+		} else {
+			balance += l.Amount
+		}
+	}
+
+	assert.Equal(t, parsed.Closing.Signed(), balance)
+
+	line, err := swift.ParseString(":20:X\n:25:A\n:61:2401310131D5,00NMSCREF1\n:86:fee")
+	require.NoError(t, err)
+
+	withEntryDate, err := swift.ParseMT940(line)
+	require.NoError(t, err)
+	assert.Equal(t, swift.StatementLine{ValueDate: time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), Mark: swift.Debit, Amount: 5, TypeCode: "MSC", Reference: "REF1", Info: "fee"}, withEntryDate.Lines[0])
+}
@@ -1,5 +1,10 @@
 package payment
 
+import (
+	"bytes"
+	"encoding/json"
+)
+
 type TransferData struct {
 	S      Account `json:"source"`
 	D      Account `json:"dectination"`
@@ -15,3 +20,46 @@ func NewTransferData(s Account, d Account, amount float32) TransferData {
 
 	return t
 }
+
+/*
+This func reads the transfer with the accounts given as objects or only
+by their numbers, e.g. {"source": "BY..", "destination": "BY..", "amount": 10}.
+The destination is read from "destination" as well as from "dectination".
+*/
+func (t *TransferData) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		S           json.RawMessage `json:"source"`
+		D           json.RawMessage `json:"dectination"`
+		Destination json.RawMessage `json:"destination"`
+		Amount      float32         `json:"amount"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.D == nil {
+		raw.D = raw.Destination
+	}
+
+	*t = TransferData{Amount: raw.Amount}
+
+	if err := unmarshalAccount(raw.S, &t.S); err != nil {
+		return err
+	}
+
+	return unmarshalAccount(raw.D, &t.D)
+}
+
+// unmarshalAccount reads the account object or the account number.
+func unmarshalAccount(data json.RawMessage, a *Account) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		return json.Unmarshal(data, &a.Num)
+	}
+
+	return json.Unmarshal(data, a)
+}
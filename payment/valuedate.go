@@ -0,0 +1,421 @@
+package payment
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	ScheduledWaiting   = "scheduled"
+	ScheduledPosted    = "posted"
+	ScheduledFailed    = "failed"
+	ScheduledCancelled = "cancelled"
+)
+
+const ScheduledTransferPrefix = "SC"
+
+// ScheduledTransfer is a transfer posted when the business date reaches its value date.
+type ScheduledTransfer struct {
+	Id          string    `json:"id"`
+	Source      string    `json:"source"`
+	Destination string    `json:"destination"`
+	Amount      float32   `json:"amount"`
+	ValueDate   time.Time `json:"value_date"`
+	Maker       string    `json:"maker"`
+	Status      string    `json:"status"`
+	// PendingId is the id of the pending transfer when the posted transfer waits for approval or review.
+	PendingId string    `json:"pending_id,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// InterestAdjustment is the interest of the account recomputed after a back-valued posting.
+type InterestAdjustment struct {
+	Account string  `json:"account"`
+	Days    int     `json:"days"`
+	Amount  float32 `json:"amount"`
+}
+
+// Correction is the result of a back-valued transfer.
+type Correction struct {
+	TransactionId string `json:"transaction_id,omitempty"`
+	// PendingId is the id of the pending transfer when the correction waits for approval or review.
+	PendingId   string               `json:"pending_id,omitempty"`
+	BookingDate time.Time            `json:"booking_date"`
+	ValueDate   time.Time            `json:"value_date"`
+	Interest    []InterestAdjustment `json:"interest,omitempty"`
+	// Restated are the closed business dates whose balances the correction changes.
+	Restated []time.Time `json:"restated,omitempty"`
+}
+
+// Restatement is a correction of the balances of a closed day, booked on a later business date.
+type Restatement struct {
+	TransactionId string    `json:"transaction_id"`
+	BookingDate   time.Time `json:"booking_date"`
+	ValueDate     time.Time `json:"value_date"`
+	Source        string    `json:"source"`
+	Destination   string    `json:"destination"`
+	Currency      string    `json:"currency_code"`
+	Amount        float32   `json:"amount"`
+}
+
+// valueDate returns the value date of a new posting: the back value of a correction or the booking date.
+func (ps *PaymentSystem) valueDate() time.Time {
+	if !ps.backValue.IsZero() {
+		return ps.backValue
+	}
+
+	return ps.bookingDate()
+}
+
+/*
+This func registers the transfer between the accounts with the numbers
+to be posted when the business date reaches the value date.
+The value date must be after the business date and the funds are
+not reserved: the transfer fails if the money is missing on the value date.
+*/
+func (ps *PaymentSystem) ScheduleTransfer(maker string, source string, dest string, amount float32, valueDate time.Time) (id string, err error) {
+	start := time.Now()
+	date := truncateDay(valueDate)
+
+	defer func() {
+		ps.finish(OpScheduleTransfer, maker, start, auditInput{"source": source, "destination": dest, "amount": amount, "value_date": date.Format(time.DateOnly)}, id, err)
+	}()
+
+	if !date.After(ps.BusinessDate()) {
+		return "", fmt.Errorf("Scheduling transfer is imposible: %w: %s is not after the business date %s", ErrInvalidValueDate, date.Format(time.DateOnly), ps.BusinessDate().Format(time.DateOnly))
+	}
+
+	if !positive(amount) {
+		return "", fmt.Errorf("Scheduling transfer is imposible: amount <= 0 \n")
+	}
+
+	s, ok := ps.findByNumber(source)
+	if !ok || !accountAvailable(s) {
+		return "", fmt.Errorf("Scheduling transfer is imposible: account %s is not valid or blocked", source)
+	}
+
+	// the account of another bank is checked by its bank
+	if d, ok := ps.findByNumber(dest); ok && !accountAvailable(d) || !ok && !ps.foreign(Account{Num: dest}) {
+		return "", fmt.Errorf("Scheduling transfer is imposible: account %s is not valid or blocked", dest)
+	}
+
+	id = ps.nextID(ScheduledTransferPrefix)
+
+	ps.scheduled[id] = &ScheduledTransfer{
+		Id:          id,
+		Source:      source,
+		Destination: dest,
+		Amount:      amount,
+		ValueDate:   date,
+		Maker:       maker,
+		Status:      ScheduledWaiting,
+		CreatedAt:   ps.now(),
+	}
+
+	return id, nil
+}
+
+// CancelScheduled cancels the scheduled transfer that is not posted yet.
+func (ps *PaymentSystem) CancelScheduled(id string) error {
+	st, ok := ps.scheduled[id]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrScheduledNotFound, id)
+	}
+
+	if st.Status != ScheduledWaiting {
+		return fmt.Errorf("Cancelling scheduled transfer is imposible: transfer %s is %s", id, st.Status)
+	}
+
+	st.Status = ScheduledCancelled
+
+	return nil
+}
+
+// ScheduledTransfers returns the scheduled transfers ordered by value date and id.
+func (ps *PaymentSystem) ScheduledTransfers() []ScheduledTransfer {
+	res := make([]ScheduledTransfer, 0, len(ps.scheduled))
+
+	for _, st := range ps.scheduled {
+		res = append(res, *st)
+	}
+
+	sortScheduled(res)
+
+	return res
+}
+
+func sortScheduled(list []ScheduledTransfer) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].ValueDate.Equal(list[j].ValueDate) {
+			return list[i].ValueDate.Before(list[j].ValueDate)
+		}
+
+		return list[i].Id < list[j].Id
+	})
+}
+
+/*
+This func posts the scheduled transfers whose value date has arrived
+like transfers of their makers and returns them with the outcome.
+It is called by CloseDay when the next business date opens.
+*/
+func (ps *PaymentSystem) PostScheduled() []ScheduledTransfer {
+	var due []ScheduledTransfer
+
+	for _, st := range ps.ScheduledTransfers() {
+		if st.Status == ScheduledWaiting && !st.ValueDate.After(ps.BusinessDate()) {
+			due = append(due, st)
+		}
+	}
+
+	for i, st := range due {
+		pendingId, err := ps.SubmitTransferByNumber(st.Maker, st.Source, st.Destination, st.Amount)
+
+		st.Status = ScheduledPosted
+		st.PendingId = pendingId
+
+		if err != nil {
+			st.Status = ScheduledFailed
+			st.Error = strings.TrimSpace(err.Error())
+		}
+
+		*ps.scheduled[st.Id] = st
+		due[i] = st
+	}
+
+	return due
+}
+
+/*
+This func returns the balance of the account at the end of the value date:
+the current balance without the movements valued after the date.
+*/
+func (ps *PaymentSystem) ValueBalance(accountNum string, date time.Time) (float32, error) {
+	a, ok := ps.findByNumber(accountNum)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrAccountNotFound, accountNum)
+	}
+
+	date = truncateDay(date)
+	balance := a.Balance
+
+	for _, tx := range ps.Transactions(accountNum) {
+		if tx.ValueDate.After(date) {
+			balance -= movement(tx, accountNum)
+		}
+	}
+
+	return balance, nil
+}
+
+/*
+This func posts a correction between the accounts with the numbers
+valued on a past business date. The interest paid by the end-of-day
+process on the closed days since the value date is recomputed with
+the corrected balances and the difference is posted to the accounts,
+the closed days since the value date record the restatement.
+The limits, screening and approval apply as to any transfer: a correction
+flagged for review or above the approval threshold waits with its value
+date and is posted when it is approved.
+*/
+func (ps *PaymentSystem) BackValueTransfer(maker string, source string, dest string, amount float32, valueDate time.Time) (c Correction, err error) {
+	start := time.Now()
+	date := truncateDay(valueDate)
+
+	defer func() {
+		ps.finish(OpBackValue, maker, start, auditInput{"source": source, "destination": dest, "amount": amount, "value_date": date.Format(time.DateOnly)}, c.TransactionId, err)
+	}()
+
+	if !date.Before(ps.BusinessDate()) {
+		return Correction{}, fmt.Errorf("Back-valued transfer is imposible: %w: %s is not before the business date %s", ErrInvalidValueDate, date.Format(time.DateOnly), ps.BusinessDate().Format(time.DateOnly))
+	}
+
+	if !positive(amount) {
+		return Correction{}, fmt.Errorf("Back-valued transfer is imposible: amount <= 0 \n")
+	}
+
+	s, ok := ps.findByNumber(source)
+	if !ok || !accountAvailable(s) {
+		return Correction{}, fmt.Errorf("Back-valued transfer is imposible: account %s is not valid or blocked", source)
+	}
+
+	d, ok := ps.findByNumber(dest)
+	if !ok || !accountAvailable(d) {
+		return Correction{}, fmt.Errorf("Back-valued transfer is imposible: account %s is not valid or blocked", dest)
+	}
+
+	if s.CurrencyCode != d.CurrencyCode {
+		return Correction{}, fmt.Errorf("Back-valued transfer is imposible: different currency! \n")
+	}
+
+	if s.Num == d.Num {
+		return Correction{}, fmt.Errorf("Back-valued transfer is imposible: %w: %s", ErrSameAccount, s.Num)
+	}
+
+	if err := ps.checkLimits(s, amount); err != nil {
+		return Correction{}, err
+	}
+
+	for _, a := range []Account{s, d} {
+		if err := ps.screenParty(a); err != nil {
+			return Correction{}, err
+		}
+	}
+
+	var status, reason string
+
+	if ps.screener != nil {
+		res := ps.screen(s, d, amount)
+
+		switch res.Decision {
+		case DecisionDeny:
+			return Correction{}, fmt.Errorf("%w by rule %s: %s", ErrTransferDenied, res.Rule, res.Reason)
+		case DecisionReview:
+			status, reason = TransferInReview, res.Rule+": "+res.Reason
+		}
+	}
+
+	if status == "" && ps.requiresApproval(amount) {
+		status = TransferPending
+	}
+
+	if status != "" {
+		id, err := ps.holdTransfer(maker, s, d, amount, status, reason)
+		if err != nil {
+			return Correction{}, err
+		}
+
+		ps.pending[id].ValueDate = date
+
+		return Correction{PendingId: id, ValueDate: date}, nil
+	}
+
+	return ps.correct(s, d, amount, date)
+}
+
+/*
+This func moves the amount between two validated accounts with the past
+value date, posts the interest adjustments and restates the closed days.
+An error with the transaction id of the correction means the amount is
+moved and only an interest adjustment failed.
+*/
+func (ps *PaymentSystem) correct(s Account, d Account, amount float32, date time.Time) (c Correction, err error) {
+	// the interest must be recomputed with the balances before the correction
+	adjustments := make([]InterestAdjustment, 2)
+
+	for i, a := range []Account{s, d} {
+		m := amount
+		if a.Num == s.Num {
+			m = -amount
+		}
+
+		if adjustments[i], err = ps.reaccrue(a, m, date); err != nil {
+			return Correction{}, err
+		}
+	}
+
+	ps.backValue = date
+	err = ps.transfer(s, d, amount)
+	ps.backValue = time.Time{}
+
+	if err != nil {
+		return Correction{}, err
+	}
+
+	tx := ps.journal[len(ps.journal)-1]
+	c = Correction{TransactionId: tx.Id, BookingDate: tx.BookingDate, ValueDate: tx.ValueDate}
+	c.Restated = ps.restate(tx)
+
+	for i, adj := range adjustments {
+		if adj.Amount == 0 {
+			continue
+		}
+
+		a := []Account{s, d}[i]
+
+		if err := ps.postInternal(TxInterest, InterestPrefix, a, float32(math.Abs(float64(adj.Amount))), adj.Amount > 0, tx.Id); err != nil {
+			return c, err
+		}
+
+		c.Interest = append(c.Interest, adj)
+	}
+
+	return c, nil
+}
+
+// restate records the correction on the closed days since its value date and returns their dates.
+func (ps *PaymentSystem) restate(tx Transaction) []time.Time {
+	var res []time.Time
+
+	for i := range ps.dayCloses {
+		dc := &ps.dayCloses[i]
+		if dc.Date.Before(tx.ValueDate) {
+			continue
+		}
+
+		dc.Restatements = append(dc.Restatements, Restatement{
+			TransactionId: tx.Id,
+			BookingDate:   tx.BookingDate,
+			ValueDate:     tx.ValueDate,
+			Source:        tx.Source,
+			Destination:   tx.Dest,
+			Currency:      tx.Currency,
+			Amount:        tx.Amount,
+		})
+
+		res = append(res, dc.Date)
+
+		ps.logger.Info("closed day is restated", "op", OpBackValue, "date", dc.Date.Format(time.DateOnly), "tx_id", tx.Id)
+	}
+
+	return res
+}
+
+/*
+This func returns the interest difference of the account when its balance
+changes by m from the value date on: the interest of every closed day
+since the value date is recomputed at the rate of the interest job of that day.
+*/
+func (ps *PaymentSystem) reaccrue(a Account, m float32, date time.Time) (InterestAdjustment, error) {
+	adj := InterestAdjustment{Account: a.Num}
+
+	if a.CustomerId == SpecialCustomerId {
+		return adj, nil
+	}
+
+	var diff float32
+
+	for _, dc := range ps.dayCloses {
+		rate := dc.interestRate()
+		if dc.Date.Before(date) || rate == 0 {
+			continue
+		}
+
+		old, err := ps.ValueBalance(a.Num, dc.Date)
+		if err != nil {
+			return adj, err
+		}
+
+		diff += max(dailyInterest(old+m, rate), 0) - max(dailyInterest(old, rate), 0)
+		adj.Days++
+	}
+
+	adj.Amount = roundCents(float64(diff))
+
+	return adj, nil
+}
+
+// interestRate returns the rate of the interest job that completed on the day, zero without one.
+func (dc DayClose) interestRate() float64 {
+	for _, j := range dc.Jobs {
+		if j.Rate != 0 && j.Error == "" {
+			return j.Rate
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,199 @@
+package payment_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentSystem_ValueDates(t *testing.T) {
+	const (
+		num1 = "BY11ABCD00000000000000000001"
+		num2 = "BY22ABCD00000000000000000002"
+	)
+
+	now := time.Date(2024, 1, 30, 10, 0, 0, 0, time.UTC)
+	jan30 := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+	jan31 := jan30.AddDate(0, 0, 1)
+	feb1 := jan30.AddDate(0, 0, 2)
+
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithClock(func() time.Time { return now }),
+		// 0.001 a day
+		payment.WithEODJob("interest", payment.InterestJob(0.365)),
+	)
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "`+num1+`", "currency_code": "BYN", "status": "active", "balance": 1000}},
+		"2": {"K2": {"customer_id": "2", "num": "`+num2+`", "currency_code": "BYN", "status": "active", "balance": 0}}
+	}`)))
+
+	_, err := ps.ScheduleTransfer("maker", num1, num2, 100, jan30)
+	assert.ErrorIs(t, err, payment.ErrInvalidValueDate, "the value date must be in the future")
+
+	id, err := ps.ScheduleTransfer("maker", num1, num2, 100, feb1)
+	require.NoError(t, err)
+
+	tooMuch, err := ps.ScheduleTransfer("maker", num2, num1, 1e6, feb1)
+	require.NoError(t, err)
+
+	cancelled, err := ps.ScheduleTransfer("maker", num2, num1, 1, feb1)
+	require.NoError(t, err)
+	require.NoError(t, ps.CancelScheduled(cancelled))
+	assert.Error(t, ps.CancelScheduled(cancelled))
+	assert.ErrorIs(t, ps.CancelScheduled("SC404"), payment.ErrScheduledNotFound)
+
+	dc, err := ps.CloseDay()
+	require.NoError(t, err)
+	assert.Empty(t, dc.Scheduled)
+
+	now = now.AddDate(0, 0, 1)
+
+	dc, err = ps.CloseDay()
+	require.NoError(t, err)
+	assert.Equal(t, jan31, dc.Date)
+
+	// the scheduled transfers are posted when their value date opens
+	require.Len(t, dc.Scheduled, 2)
+	assert.Equal(t, id, dc.Scheduled[0].Id)
+	assert.Equal(t, payment.ScheduledPosted, dc.Scheduled[0].Status)
+	assert.Equal(t, tooMuch, dc.Scheduled[1].Id)
+	assert.Equal(t, payment.ScheduledFailed, dc.Scheduled[1].Status)
+	assert.Contains(t, dc.Scheduled[1].Error, "insufficient funds")
+
+	txs := ps.Transactions(num1)
+	require.Len(t, txs, 3)
+	assert.Equal(t, payment.TxTransfer, txs[2].Type)
+	assert.Equal(t, feb1, txs[2].BookingDate)
+	assert.Equal(t, feb1, txs[2].ValueDate)
+
+	a1, err := ps.GetAccountByNumber(payment.NewCustomer("1", "", ""), num1)
+	require.NoError(t, err)
+	assert.Equal(t, float32(1000+1+1-100), a1.Balance)
+
+	_, err = ps.BackValueTransfer("ops", num1, num2, 500, feb1)
+	assert.ErrorIs(t, err, payment.ErrInvalidValueDate, "the value date must be in the past")
+
+	// the correction is valued on Jan 30, the interest of Jan 30 and 31 is recomputed
+	c, err := ps.BackValueTransfer("ops", num1, num2, 500, jan30)
+	require.NoError(t, err)
+	assert.Equal(t, feb1, c.BookingDate)
+	assert.Equal(t, jan30, c.ValueDate)
+	assert.Equal(t, []payment.InterestAdjustment{
+		{Account: num1, Days: 2, Amount: -1},
+		{Account: num2, Days: 2, Amount: 1},
+	}, c.Interest)
+
+	// the closed days since the value date record the restatement
+	assert.Equal(t, []time.Time{jan30, jan31}, c.Restated)
+
+	for _, dc := range ps.DayCloses() {
+		require.Len(t, dc.Restatements, 1, dc.Date)
+		assert.Equal(t, payment.Restatement{
+			TransactionId: c.TransactionId,
+			BookingDate:   feb1,
+			ValueDate:     jan30,
+			Source:        num1,
+			Destination:   num2,
+			Currency:      payment.BYN,
+			Amount:        500,
+		}, dc.Restatements[0])
+	}
+
+	a1, err = ps.GetAccountByNumber(payment.NewCustomer("1", "", ""), num1)
+	require.NoError(t, err)
+	assert.Equal(t, float32(902-500-1), a1.Balance)
+
+	a2, err := ps.GetAccountByNumber(payment.NewCustomer("2", "", ""), num2)
+	require.NoError(t, err)
+	assert.Equal(t, float32(100+500+1), a2.Balance)
+
+	balance, err := ps.ValueBalance(num1, jan30)
+	require.NoError(t, err)
+	assert.Equal(t, float32(500), balance, "the balance of Jan 30 includes the correction")
+
+	balance, err = ps.ValueBalance(num2, jan30)
+	require.NoError(t, err)
+	assert.Equal(t, float32(500), balance)
+
+	// the correction is booked today and keeps the trial balance of today balanced
+	dc, err = ps.CloseDay()
+	require.NoError(t, err)
+	assert.True(t, dc.TrialBalance[0].Balanced)
+
+	// the scheduled transfers survive the snapshot
+	data, err := ps.Snapshot()
+	require.NoError(t, err)
+
+	restored := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, restored.LoadSnapshot(data))
+	assert.Equal(t, ps.ScheduledTransfers(), restored.ScheduledTransfers())
+}
+
+func TestPaymentSystem_BackValueChecks(t *testing.T) {
+	now := time.Date(2024, 1, 30, 10, 0, 0, 0, time.UTC)
+	jan30 := time.Date(2024, 1, 30, 0, 0, 0, 0, time.UTC)
+
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithClock(func() time.Time { return now }),
+		payment.WithApprovalThreshold(500),
+		payment.WithApprovers("checker"),
+		payment.WithScreener(ruleScreener(t, payment.Rules{
+			Blacklist: &payment.BlacklistRule{Accounts: []string{sourceNum}, Decision: payment.DecisionDeny},
+		})),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+
+	_, err := ps.CloseDay()
+	require.NoError(t, err)
+
+	now = now.AddDate(0, 0, 1)
+
+	_, err = ps.BackValueTransfer("ops", sourceNum, destNum, 100, jan30)
+	assert.ErrorIs(t, err, payment.ErrTransferDenied, "screening applies")
+
+	ps = payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithClock(func() time.Time { return now }),
+		payment.WithApprovalThreshold(500),
+		payment.WithApprovers("checker"),
+	)
+	require.NoError(t, ps.Restore([]byte(pendingStore)))
+	ps.SetAccountLimit(sourceNum, payment.Limit{MaxAmount: 800})
+
+	now = jan30.Add(10 * time.Hour)
+	_, err = ps.CloseDay()
+	require.NoError(t, err)
+
+	now = now.AddDate(0, 0, 1)
+
+	_, err = ps.BackValueTransfer("ops", sourceNum, destNum, 900, jan30)
+	assert.ErrorIs(t, err, payment.ErrLimitExceeded, "limits apply")
+
+	// above the approval threshold the correction waits for the checker
+	c, err := ps.BackValueTransfer("ops", sourceNum, destNum, 600, jan30)
+	require.NoError(t, err)
+	require.NotEmpty(t, c.PendingId)
+	assert.Empty(t, c.TransactionId)
+	assert.Empty(t, ps.DayCloses()[0].Restatements)
+
+	assert.ErrorIs(t, ps.ApproveTransfer(c.PendingId, "ops"), payment.ErrApproverNotAllowed)
+	require.NoError(t, ps.ApproveTransfer(c.PendingId, "checker"))
+
+	txs := ps.Transactions(destNum)
+	require.Len(t, txs, 1)
+	assert.Equal(t, jan30, txs[0].ValueDate)
+	assert.Equal(t, jan30.AddDate(0, 0, 1), txs[0].BookingDate)
+
+	require.Len(t, ps.DayCloses()[0].Restatements, 1)
+	assert.Equal(t, txs[0].Id, ps.DayCloses()[0].Restatements[0].TransactionId)
+
+	s, err := ps.AccountByNumber(sourceNum)
+	require.NoError(t, err)
+	assert.Equal(t, float32(400), s.Balance)
+	assert.Zero(t, s.Reserved)
+}
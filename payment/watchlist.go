@@ -0,0 +1,266 @@
+package payment
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	CustomerBlocked   = "blocked"   // watchlist hit waiting for review
+	CustomerCleared   = "cleared"   // hit reviewed as a false positive
+	CustomerConfirmed = "confirmed" // hit reviewed as a true match
+)
+
+// WatchlistHit records a customer name matched against a watchlist entry.
+type WatchlistHit struct {
+	CustomerId string    `json:"customer_id"`
+	Name       string    `json:"name"`
+	Entry      string    `json:"entry"`
+	Distance   int       `json:"distance"`
+	Context    string    `json:"context"`
+	Time       time.Time `json:"time"`
+}
+
+// Watchlist is a list of sanctioned names matched fuzzily:
+// names are normalized, transliterated from Cyrillic and compared by edit distance.
+type Watchlist struct {
+	entries []string
+	keys    []string
+}
+
+func NewWatchlist(names ...string) *Watchlist {
+	wl := &Watchlist{}
+
+	for _, n := range names {
+		key := normalizeName(n)
+		if key == "" {
+			continue
+		}
+
+		wl.entries = append(wl.entries, n)
+		wl.keys = append(wl.keys, key)
+	}
+
+	return wl
+}
+
+/*
+This func reads the watchlist file: one name per line,
+empty lines and lines starting with # are skipped.
+*/
+func LoadWatchlist(path string) (*Watchlist, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var names []string
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		names = append(names, line)
+	}
+
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read watchlist file %s: %w", path, err)
+	}
+
+	return NewWatchlist(names...), nil
+}
+
+// Match returns the watchlist entries close enough to the name.
+func (wl *Watchlist) Match(name string) []WatchlistHit {
+	key := normalizeName(name)
+	if key == "" {
+		return nil
+	}
+
+	var res []WatchlistHit
+
+	for i, entry := range wl.keys {
+		d := min(levenshtein(key, entry), levenshtein(sortTokens(key), sortTokens(entry)))
+		if d <= maxDistance(entry) {
+			res = append(res, WatchlistHit{Name: name, Entry: wl.entries[i], Distance: d})
+		}
+	}
+
+	return res
+}
+
+// maxDistance allows one typo per five letters of the entry.
+func maxDistance(entry string) int {
+	return len([]rune(entry)) / 5
+}
+
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ў': "u",
+}
+
+// normalizeName lowercases the name, transliterates Cyrillic letters
+// and keeps only letters and digits separated by single spaces.
+func normalizeName(name string) string {
+	var b strings.Builder
+
+	for _, r := range strings.ToLower(name) {
+		if t, ok := cyrillicToLatin[r]; ok {
+			b.WriteString(t)
+
+			continue
+		}
+
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune(' ')
+		}
+	}
+
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+func sortTokens(s string) string {
+	tokens := strings.Fields(s)
+	sort.Strings(tokens)
+
+	return strings.Join(tokens, " ")
+}
+
+func levenshtein(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// WithWatchlist enables screening of customer names against the watchlist.
+func WithWatchlist(wl *Watchlist) Option {
+	return func(ps *PaymentSystem) {
+		ps.watchlist = wl
+	}
+}
+
+/*
+This func screens the customer against the watchlist.
+A customer with a hit is blocked until a reviewer clears it.
+*/
+func (ps *PaymentSystem) screenCustomer(c Customer, context string) error {
+	if ps.watchlist == nil {
+		return nil
+	}
+
+	switch ps.customerStatus[c.Id] {
+	case CustomerCleared:
+		return nil
+	case CustomerBlocked, CustomerConfirmed:
+		return fmt.Errorf("%w: %s", ErrCustomerBlocked, c.Id)
+	}
+
+	hits := ps.watchlist.Match(c.Name)
+	if len(hits) == 0 {
+		return nil
+	}
+
+	for _, h := range hits {
+		h.CustomerId = c.Id
+		h.Context = context
+		h.Time = ps.now()
+		ps.watchlistHits = append(ps.watchlistHits, h)
+
+		ps.logger.Warn("customer matches watchlist entry", "op", context, "customer_id", c.Id, "name", c.Name, "entry", h.Entry, "distance", h.Distance)
+	}
+
+	ps.customerStatus[c.Id] = CustomerBlocked
+
+	return fmt.Errorf("%w: %s", ErrCustomerBlocked, c.Id)
+}
+
+/*
+This func screens the owner of the account by the name it was registered with,
+or by the holder name in the description of the account when the customer
+was not registered, e.g. for the accounts of a restored store.
+The accounts of the bank, of correspondent banks and of other banks are not screened.
+*/
+func (ps *PaymentSystem) screenParty(a Account) error {
+	if ps.watchlist == nil || a.CustomerId == SpecialCustomerId || ps.foreign(a) || ps.vostro(a) {
+		return nil
+	}
+
+	c, ok := ps.customers[a.CustomerId]
+	if !ok {
+		c = Customer{Id: a.CustomerId, Name: ps.resolveAccount(a).Description}
+	}
+
+	return ps.screenCustomer(c, "transfer")
+}
+
+/*
+This func resolves the watchlist hit of the customer.
+Cleared customers can create accounts again, confirmed ones stay blocked.
+*/
+func (ps *PaymentSystem) ReviewCustomer(customerId string, reviewer string, clear bool) error {
+	if !ps.reviewers[reviewer] {
+		return fmt.Errorf("%w: %s", ErrReviewerNotAllowed, reviewer)
+	}
+
+	if ps.customerStatus[customerId] != CustomerBlocked {
+		return fmt.Errorf("%w: %s", ErrCustomerNotInReview, customerId)
+	}
+
+	if clear {
+		ps.customerStatus[customerId] = CustomerCleared
+	} else {
+		ps.customerStatus[customerId] = CustomerConfirmed
+	}
+
+	ps.logger.Info("watchlist hit was reviewed", "customer_id", customerId, "reviewer", reviewer, "status", ps.customerStatus[customerId])
+
+	return nil
+}
+
+// WatchlistHits returns all recorded watchlist hits in order of occurrence.
+func (ps *PaymentSystem) WatchlistHits() []WatchlistHit {
+	res := make([]WatchlistHit, len(ps.watchlistHits))
+	copy(res, ps.watchlistHits)
+
+	return res
+}
+
+// CustomerStatus returns the watchlist review status of the customer, empty when it has no hits.
+func (ps *PaymentSystem) CustomerStatus(customerId string) string {
+	return ps.customerStatus[customerId]
+}
@@ -0,0 +1,139 @@
+package payment_test
+
+import (
+	"testing"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchlist_Match(t *testing.T) {
+	wl, err := payment.LoadWatchlist("testdata/watchlist.txt")
+	require.NoError(t, err)
+
+	testCases := []struct {
+		desc          string
+		name          string
+		expectedEntry string
+	}{
+		{
+			desc:          "latin spelling matches cyrillic entry",
+			name:          "Ivan Petrov",
+			expectedEntry: "Иван Петров",
+		},
+		{
+			desc:          "reordered name matches",
+			name:          "PETROV, Ivan",
+			expectedEntry: "Иван Петров",
+		},
+		{
+			desc:          "punctuation and case are ignored",
+			name:          "global-trading l.l.c.",
+			expectedEntry: "Global Trading LLC",
+		},
+		{
+			desc: "different name does not match",
+			name: "Customer One",
+		},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			hits := wl.Match(tt.name)
+
+			if tt.expectedEntry == "" {
+				assert.Empty(t, hits)
+
+				return
+			}
+
+			require.Len(t, hits, 1)
+			assert.Equal(t, tt.expectedEntry, hits[0].Entry)
+		})
+	}
+}
+
+func TestPaymentSystem_CreateAccount_Watchlist(t *testing.T) {
+	ps := payment.NewPaymentSystem(
+		payment.WithWatchlist(payment.NewWatchlist("Иван Петров")),
+		payment.WithReviewers("compliance"),
+	)
+
+	c := payment.NewCustomer("7", "Ivan Petrow", payment.AccountPrefix)
+
+	err := ps.CreateAccount(c, c.AccPrefix, payment.BYN, 0)
+	assert.ErrorIs(t, err, payment.ErrCustomerBlocked)
+
+	err = ps.CreateAccount(c, c.AccPrefix, payment.USD, 0)
+	assert.ErrorIs(t, err, payment.ErrCustomerBlocked, "customer stays blocked until reviewed")
+
+	hits := ps.WatchlistHits()
+	require.Len(t, hits, 1)
+	assert.Equal(t, "7", hits[0].CustomerId)
+	assert.Equal(t, "create_account", hits[0].Context)
+
+	assert.ErrorIs(t, ps.ReviewCustomer("7", "nobody", true), payment.ErrReviewerNotAllowed)
+	require.NoError(t, ps.ReviewCustomer("7", "compliance", true))
+	assert.Equal(t, payment.CustomerCleared, ps.CustomerStatus("7"))
+
+	assert.NoError(t, ps.CreateAccount(c, c.AccPrefix, payment.BYN, 0))
+}
+
+func TestPaymentSystem_ScreenParty(t *testing.T) {
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithWatchlist(payment.NewWatchlist("Иван Петров")),
+	)
+
+	c := payment.NewCustomer("7", "Anna Smirnova", payment.AccountPrefix)
+	require.NoError(t, ps.CreateAccount(c, c.AccPrefix, payment.BYN, 100))
+
+	// the name given to a later account does not replace the registered one
+	require.NoError(t, ps.CreateAccount(payment.NewCustomer("7", "Someone Else", payment.AccountPrefix), payment.AccountPrefix, payment.USD, 0))
+
+	got, err := ps.GetCustomer("7")
+	require.NoError(t, err)
+	assert.Equal(t, "Anna Smirnova", got.Name)
+
+	// the accounts of a restored store have no registered customers
+	ps = payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithWatchlist(payment.NewWatchlist("Иван Петров")),
+	)
+
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "BY11ABCD00000000000000000001", "currency_code": "BYN", "status": "active", "balance": 100}},
+		"2": {"K2": {"customer_id": "2", "num": "BY22ABCD00000000000000000002", "currency_code": "BYN", "status": "active", "balance": 0}}
+	}`)))
+
+	require.NoError(t, ps.TransferByNumber("BY11ABCD00000000000000000001", "BY22ABCD00000000000000000002", 10))
+
+	// without a registered customer the holder name of the account is screened
+	require.NoError(t, ps.Restore([]byte(`{
+		"1": {"K1": {"customer_id": "1", "num": "BY11ABCD00000000000000000001", "currency_code": "BYN", "status": "active", "balance": 100}},
+		"3": {"K3": {"customer_id": "3", "num": "BY33ABCD00000000000000000003", "currency_code": "BYN", "status": "active", "balance": 0, "desc": "Ivan Petrow"}}
+	}`)))
+
+	err = ps.TransferByNumber("BY11ABCD00000000000000000001", "BY33ABCD00000000000000000003", 10)
+	assert.ErrorIs(t, err, payment.ErrCustomerBlocked)
+}
+
+func TestPaymentSystem_ScreenParty_Outbound(t *testing.T) {
+	ps := payment.NewPaymentSystem(
+		payment.WithProcessingDelay(0),
+		payment.WithBankCode("ABCD"),
+		payment.WithWatchlist(payment.NewWatchlist("Иван Петров")),
+	)
+
+	c := payment.NewCustomer("1", "Anna Smirnova", payment.AccountPrefix)
+	require.NoError(t, ps.CreateAccount(c, c.AccPrefix, payment.BYN, 100))
+
+	s, err := ps.FindAccount(c, payment.BYN)
+	require.NoError(t, err)
+
+	// the customer of another bank is not known here and is not screened
+	require.NoError(t, ps.TransferByNumber(s.Num, "BY00ZZZZ00000000000000000001", 40))
+	assert.Len(t, ps.Outbound(), 1)
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// maxErrorBody limits the part of the response body kept as the error of the delivery.
+const maxErrorBody = 256
+
+/*
+This func sends the deliveries which are due and returns the number of
+successful ones. A failed delivery is scheduled again with exponential
+backoff or moved to the dead letters after the last attempt.
+The lock is not held while the requests are sent.
+*/
+func (d *Dispatcher) ProcessDue(ctx context.Context) int {
+	d.mu.Lock()
+
+	now := d.now()
+
+	var (
+		due  []*Delivery
+		eps  []Endpoint
+		rest []*Delivery
+	)
+
+	for _, dl := range d.queue {
+		ep, ok := d.endpoints[dl.EndpointId]
+
+		if ok && !dl.NextAttempt.After(now) {
+			due = append(due, dl)
+			eps = append(eps, ep)
+		} else {
+			rest = append(rest, dl)
+		}
+	}
+
+	d.queue = rest
+
+	d.mu.Unlock()
+
+	sent := 0
+
+	for i, dl := range due {
+		err := d.send(ctx, eps[i], dl)
+
+		d.mu.Lock()
+		dl.Attempts++
+
+		switch {
+		case err == nil:
+			sent++
+
+			d.logger.Info("webhook delivered", "delivery_id", dl.Id, "endpoint_id", dl.EndpointId, "event_id", dl.Event.Id, "attempts", dl.Attempts)
+		case dl.Attempts >= d.maxAttempts:
+			dl.LastError = err.Error()
+			d.deadLetters = append(d.deadLetters, dl)
+
+			d.logger.Error("webhook moved to dead letters", "delivery_id", dl.Id, "endpoint_id", dl.EndpointId, "attempts", dl.Attempts, "error", err)
+		default:
+			dl.LastError = err.Error()
+			dl.NextAttempt = d.now().Add(d.backoff(dl.Attempts))
+
+			if _, ok := d.endpoints[dl.EndpointId]; ok {
+				d.queue = append(d.queue, dl)
+			}
+
+			d.logger.Warn("webhook delivery failed", "delivery_id", dl.Id, "endpoint_id", dl.EndpointId, "attempts", dl.Attempts, "next_attempt", dl.NextAttempt, "error", err)
+		}
+		d.mu.Unlock()
+	}
+
+	return sent
+}
+
+// backoff doubles the base delay after every failed attempt up to the maximum.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.baseBackoff
+
+	for i := 1; i < attempts && b < d.maxBackoff; i++ {
+		b *= 2
+	}
+
+	return min(b, d.maxBackoff)
+}
+
+func (d *Dispatcher) send(ctx context.Context, ep Endpoint, dl *Delivery) error {
+	body, err := json.Marshal(dl.Event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(SignatureHeader, Sign(ep.Secret, d.now(), body))
+	req.Header.Set(EventHeader, dl.Event.Type)
+	req.Header.Set(DeliveryHeader, dl.Id)
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+
+		return fmt.Errorf("endpoint responded %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
+
+// Queue returns the deliveries waiting for the next attempt.
+func (d *Dispatcher) Queue() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return copyDeliveries(d.queue)
+}
+
+// DeadLetters returns the deliveries which failed every attempt.
+func (d *Dispatcher) DeadLetters() []Delivery {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return copyDeliveries(d.deadLetters)
+}
+
+/*
+This func moves the dead letter back to the queue
+to be sent on the next ProcessDue with a fresh number of attempts.
+*/
+func (d *Dispatcher) Redeliver(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	i := slices.IndexFunc(d.deadLetters, func(dl *Delivery) bool { return dl.Id == id })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrDeliveryNotFound, id)
+	}
+
+	dl := d.deadLetters[i]
+
+	if _, ok := d.endpoints[dl.EndpointId]; !ok {
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, dl.EndpointId)
+	}
+
+	d.deadLetters = slices.Delete(d.deadLetters, i, i+1)
+
+	dl.Attempts = 0
+	dl.NextAttempt = d.now()
+	d.queue = append(d.queue, dl)
+
+	return nil
+}
+
+// Run sends the due deliveries every interval until the context is done.
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		d.ProcessDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func copyDeliveries(list []*Delivery) []Delivery {
+	res := make([]Delivery, 0, len(list))
+
+	for _, dl := range list {
+		res = append(res, *dl)
+	}
+
+	return res
+}
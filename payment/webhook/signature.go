@@ -0,0 +1,74 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SignatureHeader = "X-Payment-Signature"
+	EventHeader     = "X-Payment-Event"
+	DeliveryHeader  = "X-Payment-Delivery"
+)
+
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+/*
+This func signs the body sent at the timestamp with the secret of the endpoint.
+The result is the value of the SignatureHeader: "t=<unix seconds>,v1=<hex HMAC-SHA256>",
+the HMAC is computed over "<unix seconds>.<body>".
+*/
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	return "t=" + ts + ",v1=" + hex.EncodeToString(mac(secret, ts, body))
+}
+
+/*
+This func checks the SignatureHeader value received with the body.
+Signatures older than tolerance are rejected to prevent replays,
+a zero tolerance disables the check.
+*/
+func Verify(secret string, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts, sig string
+
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if tolerance > 0 && now.Sub(time.Unix(sec, 0)) > tolerance {
+		return ErrInvalidSignature
+	}
+
+	got, err := hex.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, mac(secret, ts, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func mac(secret string, ts string, body []byte) []byte {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(ts + "."))
+	h.Write(body)
+
+	return h.Sum(nil)
+}
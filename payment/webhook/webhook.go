@@ -0,0 +1,291 @@
+// Package webhook delivers the events of the payment store to HTTP endpoints of customers.
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+)
+
+const (
+	EndpointPrefix = "WE"
+	DeliveryPrefix = "WD"
+
+	DefaultMaxAttempts = 8
+	DefaultBaseBackoff = 10 * time.Second
+	DefaultMaxBackoff  = time.Hour
+	DefaultTimeout     = 10 * time.Second
+)
+
+var (
+	ErrEndpointNotFound = errors.New("webhook endpoint not found")
+	ErrDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidEndpoint  = errors.New("invalid webhook endpoint")
+)
+
+// Endpoint is an URL of the customer receiving the events of its accounts.
+type Endpoint struct {
+	Id         string    `json:"id"`
+	CustomerId string    `json:"customer_id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	Events     []string  `json:"events,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// accepts reports whether the endpoint is subscribed to the event type.
+func (ep Endpoint) accepts(eventType string) bool {
+	return len(ep.Events) == 0 || slices.Contains(ep.Events, eventType)
+}
+
+/*
+This struct is the body sent to the endpoint. It tells the customer what
+happened to its account and nothing more: the balances and versions of
+the accounts and the accounts of the counterparty are never sent,
+only the number of the counterparty account.
+*/
+type Notification struct {
+	Id            string    `json:"id"`
+	Type          string    `json:"type"`
+	Time          time.Time `json:"time"`
+	Account       string    `json:"account"`
+	CurrencyCode  string    `json:"currency_code"`
+	TransactionId string    `json:"transaction_id,omitempty"`
+	Amount        float32   `json:"amount,omitempty"`
+	Counterparty  string    `json:"counterparty,omitempty"`
+}
+
+/*
+This struct is one event to be sent to one endpoint.
+A failed delivery is retried at NextAttempt, after MaxAttempts
+it is moved to the dead letters until it is redelivered manually.
+*/
+type Delivery struct {
+	Id          string       `json:"id"`
+	EndpointId  string       `json:"endpoint_id"`
+	Event       Notification `json:"event"`
+	Attempts    int          `json:"attempts"`
+	NextAttempt time.Time    `json:"next_attempt"`
+	LastError   string       `json:"last_error,omitempty"`
+}
+
+/*
+This struct keeps the endpoints and the delivery queue.
+It implements payment.Subscriber, so it is registered with
+PaymentSystem.Subscribe and receives the events from the outbox.
+*/
+type Dispatcher struct {
+	client      *http.Client
+	now         func() time.Time
+	logger      *slog.Logger
+	maxAttempts int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+
+	mu          sync.Mutex
+	seq         int
+	endpoints   map[string]Endpoint
+	queue       []*Delivery
+	deadLetters []*Delivery
+}
+
+// Option configures optional behaviour of the Dispatcher.
+type Option func(d *Dispatcher)
+
+// WithClient sets the HTTP client used to send the events.
+func WithClient(c *http.Client) Option {
+	return func(d *Dispatcher) {
+		d.client = c
+	}
+}
+
+// WithClock replaces the time source of signatures and retries.
+func WithClock(now func() time.Time) Option {
+	return func(d *Dispatcher) {
+		d.now = now
+	}
+}
+
+// WithLogger sets the logger of the Dispatcher. slog.Default() is used otherwise.
+func WithLogger(l *slog.Logger) Option {
+	return func(d *Dispatcher) {
+		d.logger = l
+	}
+}
+
+// WithRetries sets the number of attempts and the backoff between them.
+func WithRetries(maxAttempts int, base time.Duration, limit time.Duration) Option {
+	return func(d *Dispatcher) {
+		d.maxAttempts = maxAttempts
+		d.baseBackoff = base
+		d.maxBackoff = limit
+	}
+}
+
+func NewDispatcher(opts ...Option) *Dispatcher {
+	d := &Dispatcher{
+		client:      &http.Client{Timeout: DefaultTimeout},
+		now:         time.Now,
+		logger:      slog.Default(),
+		maxAttempts: DefaultMaxAttempts,
+		baseBackoff: DefaultBaseBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		endpoints:   make(map[string]Endpoint),
+	}
+
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	return d
+}
+
+func (d *Dispatcher) nextID(prefix string) string {
+	d.seq++
+
+	return fmt.Sprintf("%s%08d", prefix, d.seq)
+}
+
+/*
+This func registers the endpoint of the customer. Events lists the event
+types sent to it, all types are sent when it is empty.
+*/
+func (d *Dispatcher) Register(customerId string, rawURL string, secret string, events ...string) (Endpoint, error) {
+	if customerId == "" {
+		return Endpoint{}, fmt.Errorf("%w: without customer ID", ErrInvalidEndpoint)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, fmt.Errorf("%w: url %q", ErrInvalidEndpoint, rawURL)
+	}
+
+	if secret == "" {
+		return Endpoint{}, fmt.Errorf("%w: empty secret", ErrInvalidEndpoint)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	ep := Endpoint{
+		Id:         d.nextID(EndpointPrefix),
+		CustomerId: customerId,
+		URL:        rawURL,
+		Secret:     secret,
+		Events:     events,
+		CreatedAt:  d.now(),
+	}
+
+	d.endpoints[ep.Id] = ep
+
+	return ep, nil
+}
+
+// Unregister removes the endpoint, its queued deliveries are dropped.
+func (d *Dispatcher) Unregister(id string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if _, ok := d.endpoints[id]; !ok {
+		return fmt.Errorf("%w: %s", ErrEndpointNotFound, id)
+	}
+
+	delete(d.endpoints, id)
+
+	d.queue = slices.DeleteFunc(d.queue, func(dl *Delivery) bool { return dl.EndpointId == id })
+
+	return nil
+}
+
+// Endpoints returns the endpoints of the customer ordered by id.
+func (d *Dispatcher) Endpoints(customerId string) []Endpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var res []Endpoint
+
+	for _, ep := range d.endpoints {
+		if ep.CustomerId == customerId {
+			res = append(res, ep)
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+
+	return res
+}
+
+/*
+This func queues the event for every endpoint of the customers it concerns.
+Money received by an account is reported to its owner only,
+changes of an account are reported to the owner of the account.
+*/
+func (d *Dispatcher) HandleEvent(e payment.Event) error {
+	customerId, n, ok := notification(e)
+	if !ok {
+		return nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, ep := range d.sortedEndpoints() {
+		if ep.CustomerId != customerId || !ep.accepts(e.Type) {
+			continue
+		}
+
+		d.queue = append(d.queue, &Delivery{
+			Id:          d.nextID(DeliveryPrefix),
+			EndpointId:  ep.Id,
+			Event:       n,
+			NextAttempt: d.now(),
+		})
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) sortedEndpoints() []Endpoint {
+	res := make([]Endpoint, 0, len(d.endpoints))
+
+	for _, ep := range d.endpoints {
+		res = append(res, ep)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Id < res[j].Id })
+
+	return res
+}
+
+// notification returns the customer notified about the event and what it is told.
+func notification(e payment.Event) (string, Notification, bool) {
+	n := Notification{Id: e.Id, Type: e.Type, Time: e.Time}
+
+	var a payment.Account
+
+	switch p := e.Payload.(type) {
+	case payment.TransferCompleted:
+		a = p.Destination
+		n.TransactionId, n.Amount, n.Counterparty = p.TransactionId, p.Amount, p.Source.Num
+	case payment.AccountCreated:
+		a = p.Account
+	case payment.AccountBlocked:
+		a = p.Account
+	case payment.AccountActivated:
+		a = p.Account
+	default:
+		return "", n, false
+	}
+
+	n.Account, n.CurrencyCode = a.Num, a.CurrencyCode
+
+	return a.CustomerId, n, true
+}
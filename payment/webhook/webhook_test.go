@@ -0,0 +1,269 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/soundrise/go-payment-system/payment"
+	"github.com/soundrise/go-payment-system/payment/webhook"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	secret    = "whsec_test"
+	sourceNum = "BY11ABCD00000000000000000001"
+	destNum   = "BY22ABCD00000000000000000002"
+)
+
+const seedStore = `{
+	"1": {"K1": {"customer_id": "1", "num": "` + sourceNum + `", "currency_code": "BYN", "status": "active", "balance": 1000}},
+	"2": {"K2": {"customer_id": "2", "num": "` + destNum + `", "currency_code": "BYN", "status": "active", "balance": 0}}
+}`
+
+// receiver is a merchant endpoint answering with the queued status codes, then 200.
+type receiver struct {
+	mu       sync.Mutex
+	statuses []int
+	received []webhook.Notification
+	bodies   []map[string]any
+	errors   []error
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	body, _ := io.ReadAll(req.Body)
+
+	if err := webhook.Verify(secret, req.Header.Get(webhook.SignatureHeader), body, 0, time.Now()); err != nil {
+		r.errors = append(r.errors, err)
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	if len(r.statuses) > 0 {
+		status := r.statuses[0]
+		r.statuses = r.statuses[1:]
+		http.Error(w, "try later", status)
+
+		return
+	}
+
+	var (
+		n   webhook.Notification
+		raw map[string]any
+	)
+
+	for _, v := range []any{&n, &raw} {
+		if err := json.Unmarshal(body, v); err != nil {
+			r.errors = append(r.errors, err)
+		}
+	}
+
+	r.received = append(r.received, n)
+	r.bodies = append(r.bodies, raw)
+}
+
+func newMerchant(t *testing.T, now *time.Time, statuses ...int) (*payment.PaymentSystem, *webhook.Dispatcher, *receiver) {
+	t.Helper()
+
+	rcv := &receiver{statuses: statuses}
+	srv := httptest.NewServer(rcv)
+	t.Cleanup(srv.Close)
+
+	ps := payment.NewPaymentSystem(payment.WithProcessingDelay(0))
+	require.NoError(t, ps.Restore([]byte(seedStore)))
+
+	d := webhook.NewDispatcher(
+		webhook.WithClient(srv.Client()),
+		webhook.WithClock(func() time.Time { return *now }),
+		webhook.WithRetries(3, time.Second, 3*time.Second),
+	)
+
+	_, err := d.Register("2", srv.URL, secret, payment.EventTransferCompleted)
+	require.NoError(t, err)
+
+	ps.Subscribe("webhooks", d)
+
+	return ps, d, rcv
+}
+
+func transfer(t *testing.T, ps *payment.PaymentSystem, amount float32) {
+	t.Helper()
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+
+	d, err := ps.FindAccount(payment.NewCustomer("2", "", ""), payment.BYN)
+	require.NoError(t, err)
+
+	require.NoError(t, ps.Transfer(s, d, amount))
+	ps.DispatchEvents()
+}
+
+func TestDispatcher_Deliver(t *testing.T) {
+	now := time.Now()
+	ps, d, rcv := newMerchant(t, &now)
+
+	s, err := ps.FindAccount(payment.NewCustomer("1", "", ""), payment.BYN)
+	require.NoError(t, err)
+	require.NoError(t, ps.CloseAccount(s))
+	ps.DispatchEvents()
+
+	assert.Empty(t, d.Queue(), "account events of other customers are not sent")
+
+	require.NoError(t, ps.ActivateAccount(s))
+	transfer(t, ps, 150)
+
+	assert.Equal(t, 1, d.ProcessDue(context.Background()))
+	assert.Empty(t, d.Queue())
+
+	require.Empty(t, rcv.errors)
+	require.Len(t, rcv.received, 1)
+
+	done := rcv.received[0]
+	assert.Equal(t, payment.EventTransferCompleted, done.Type)
+	assert.Equal(t, destNum, done.Account)
+	assert.Equal(t, sourceNum, done.Counterparty)
+	assert.Equal(t, payment.BYN, done.CurrencyCode)
+	assert.Equal(t, float32(150), done.Amount)
+	assert.NotEmpty(t, done.TransactionId)
+
+	// the accounts of the payer and the payee are not sent
+	assert.ElementsMatch(t, []string{"id", "type", "time", "account", "currency_code", "transaction_id", "amount", "counterparty"}, keys(rcv.bodies[0]))
+	assert.NotContains(t, rcv.bodies[0], "payload")
+}
+
+func keys(m map[string]any) []string {
+	res := make([]string, 0, len(m))
+
+	for k := range m {
+		res = append(res, k)
+	}
+
+	return res
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	now := time.Now()
+	ps, d, rcv := newMerchant(t, &now, http.StatusServiceUnavailable, http.StatusInternalServerError)
+	ctx := context.Background()
+
+	transfer(t, ps, 10)
+
+	assert.Equal(t, 0, d.ProcessDue(ctx))
+	require.Len(t, d.Queue(), 1)
+	assert.Equal(t, now.Add(time.Second), d.Queue()[0].NextAttempt)
+	assert.Contains(t, d.Queue()[0].LastError, "503")
+
+	assert.Equal(t, 0, d.ProcessDue(ctx), "retry is not due yet")
+
+	now = now.Add(time.Second)
+	assert.Equal(t, 0, d.ProcessDue(ctx))
+	assert.Equal(t, now.Add(2*time.Second), d.Queue()[0].NextAttempt)
+
+	now = now.Add(2 * time.Second)
+	assert.Equal(t, 1, d.ProcessDue(ctx))
+	assert.Empty(t, d.Queue())
+	assert.Len(t, rcv.received, 1)
+}
+
+func TestDispatcher_DeadLetters(t *testing.T) {
+	now := time.Now()
+	ps, d, rcv := newMerchant(t, &now, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	ctx := context.Background()
+
+	transfer(t, ps, 10)
+
+	for i := 0; i < 3; i++ {
+		d.ProcessDue(ctx)
+		now = now.Add(time.Minute)
+	}
+
+	assert.Empty(t, d.Queue())
+	require.Len(t, d.DeadLetters(), 1)
+
+	dead := d.DeadLetters()[0]
+	assert.Equal(t, 3, dead.Attempts)
+
+	assert.ErrorIs(t, d.Redeliver("WD404"), webhook.ErrDeliveryNotFound)
+	require.NoError(t, d.Redeliver(dead.Id))
+	assert.Empty(t, d.DeadLetters())
+
+	assert.Equal(t, 1, d.ProcessDue(ctx))
+	require.Len(t, rcv.received, 1)
+	assert.Equal(t, dead.Event.Id, rcv.received[0].Id)
+}
+
+func TestDispatcher_Register(t *testing.T) {
+	d := webhook.NewDispatcher()
+
+	testCases := []struct {
+		desc     string
+		customer string
+		url      string
+		secret   string
+	}{
+		{desc: "without customer", url: "https://example.com/hook", secret: secret},
+		{desc: "relative url", customer: "2", url: "/hook", secret: secret},
+		{desc: "not http", customer: "2", url: "ftp://example.com/hook", secret: secret},
+		{desc: "without secret", customer: "2", url: "https://example.com/hook"},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			_, err := d.Register(tt.customer, tt.url, tt.secret)
+			assert.ErrorIs(t, err, webhook.ErrInvalidEndpoint)
+		})
+	}
+
+	ep, err := d.Register("2", "https://example.com/hook", secret)
+	require.NoError(t, err)
+	assert.Equal(t, []webhook.Endpoint{ep}, d.Endpoints("2"))
+
+	require.NoError(t, d.Unregister(ep.Id))
+	assert.Empty(t, d.Endpoints("2"))
+	assert.ErrorIs(t, d.Unregister(ep.Id), webhook.ErrEndpointNotFound)
+}
+
+func TestVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"TransferCompleted"}`)
+	header := webhook.Sign(secret, now, body)
+
+	testCases := []struct {
+		desc   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		ok     bool
+	}{
+		{desc: "valid", secret: secret, header: header, body: body, now: now, ok: true},
+		{desc: "other secret", secret: "other", header: header, body: body, now: now},
+		{desc: "tampered body", secret: secret, header: header, body: []byte(`{}`), now: now},
+		{desc: "replayed", secret: secret, header: header, body: body, now: now.Add(time.Hour)},
+		{desc: "malformed", secret: secret, header: "v1=00", body: body, now: now},
+	}
+
+	for _, tt := range testCases {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			err := webhook.Verify(tt.secret, tt.header, tt.body, 5*time.Minute, tt.now)
+			if tt.ok {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, webhook.ErrInvalidSignature)
+			}
+		})
+	}
+}